@@ -0,0 +1,8 @@
+// Package api contains the calendar service protocol and documents generated from it.
+package api
+
+import _ "embed" // OpenAPI document is embedded into the service binary
+
+// OpenAPI - OpenAPI v2 document of the HTTP gateway generated by protoc-gen-openapiv2 from calendar_service.proto.
+//go:embed calendar_service.swagger.json
+var OpenAPI []byte
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/auth"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/logger"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server/grpc"
@@ -23,10 +24,14 @@ import (
 
 const ServerShutdownTimeout = time.Second * 3
 
-var configFilePath string
+var (
+	configFilePath string
+	dryRun         bool
+)
 
 func init() {
 	pflag.StringVarP(&configFilePath, "config", "c", "./configs/config.yaml", "Path to configuration file")
+	pflag.BoolVar(&dryRun, "dry-run", false, "Only report old events for purge subcommand, do not remove them")
 }
 
 func main() {
@@ -38,10 +43,14 @@ func main() {
 
 func mainImpl() error {
 	pflag.Parse()
+	purge := false
 	for _, arg := range pflag.Args() {
-		if arg == "version" {
+		switch arg {
+		case "version":
 			printVersion()
 			return nil
+		case "purge":
+			purge = true
 		}
 	}
 
@@ -59,35 +68,55 @@ func mainImpl() error {
 	notifyCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	defer stop()
 
-	var repo app.EventRepository
-	if cfg.Storage.UseMemoryStorage {
-		repo = memorystorage.NewMemStorage()
-	} else {
-		dsn := fmt.Sprintf(
-			"host=%s port=%d user=%s database=%s password=%s sslmode=disable",
-			cfg.Storage.DB.Host,
-			cfg.Storage.DB.Port,
-			cfg.Storage.DB.Username,
-			cfg.Storage.DB.DB,
-			cfg.Storage.DB.Password)
-		dbStorage := sqlstorage.NewDBStorage()
-		if err := dbStorage.Connect(notifyCtx, dsn); err != nil {
-			return fmt.Errorf("failed to init db storage: %w", err)
-		}
-		repo = dbStorage
-		defer func() {
-			if err := dbStorage.Close(); err != nil {
-				zap.L().Error("error during closing db storage", zap.Error(err))
-			}
-		}()
+	repo, closeRepo, err := newRepository(notifyCtx, cfg.Storage)
+	if err != nil {
+		return err
 	}
+	defer closeRepo()
 	zap.L().Info("calendar service storage started...")
 
-	apiService := app.New(repo)
-	httpAPI := internalhttp.NewHTTPApi(cfg.API.HTTP, apiService)
-	grpcAPI := grpc.NewGRPCApi(cfg.API.GRPC, apiService)
+	if purge {
+		return purgeOldEvents(notifyCtx, repo, cfg.Retention)
+	}
 
+	firstDayOfWeek, err := cfg.Calendar.WeekStart()
+	if err != nil {
+		return err
+	}
+	location, err := cfg.Calendar.Location()
+	if err != nil {
+		return err
+	}
 	wg := sync.WaitGroup{}
+	hub := app.NewChangeHub()
+	opts := []app.Option{app.WithFirstDayOfWeek(firstDayOfWeek), app.WithLocation(location), app.WithChangeHub(hub)}
+	// db storage shares event changes between all API replicas
+	if broadcaster, ok := repo.(app.Broadcaster); ok {
+		publisher := app.NewBroadcastPublisher(broadcaster, hub)
+		opts = append(opts, app.WithChangePublisher(publisher))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			publisher.Run(notifyCtx)
+		}()
+	}
+	authenticator, err := newAuthenticator(cfg.API.Auth)
+	if err != nil {
+		return err
+	}
+	apiService := app.New(repo, opts...)
+	grpcAPI := grpc.NewGRPCApi(cfg.API.GRPC, apiService, authenticator)
+	gatewayConn, err := grpcAPI.Dial(notifyCtx)
+	if err != nil {
+		return err
+	}
+	defer gatewayConn.Close()
+	gateway, err := grpc.NewGateway(notifyCtx, gatewayConn)
+	if err != nil {
+		return err
+	}
+	httpAPI := internalhttp.NewHTTPApi(cfg.API.HTTP, apiService, authenticator, gateway)
+
 	wg.Add(4)
 
 	go shutdownHTTP(notifyCtx, httpAPI, &wg)
@@ -108,6 +137,68 @@ func mainImpl() error {
 	return nil
 }
 
+func newRepository(ctx context.Context, cfg config.StorageConfig) (app.EventRepository, func(), error) {
+	if cfg.UseMemoryStorage {
+		return memorystorage.NewMemStorage(), func() {}, nil
+	}
+	dbStorage := sqlstorage.NewDBStorage()
+	if err := dbStorage.Connect(ctx, cfg.DB.DSN()); err != nil {
+		return nil, nil, fmt.Errorf("failed to init db storage: %w", err)
+	}
+	return dbStorage, func() {
+		if err := dbStorage.Close(); err != nil {
+			zap.L().Error("error during closing db storage", zap.Error(err))
+		}
+	}, nil
+}
+
+// newAuthenticator - accepts credentials of any configured authentication method.
+func newAuthenticator(cfg config.AuthConfig) (auth.Authenticator, error) {
+	var chain auth.Chain
+	if cfg.JWT.Secret != "" {
+		tokens, err := auth.NewJWT([]byte(cfg.JWT.Secret), cfg.JWT.Issuer, cfg.JWT.Audience)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init JWT authentication: %w", err)
+		}
+		chain = append(chain, tokens)
+	}
+	if len(cfg.APIKeys) > 0 {
+		keys := make(auth.APIKeys, len(cfg.APIKeys))
+		for i, key := range cfg.APIKeys {
+			if key.Key == "" || key.UserID == "" {
+				return nil, fmt.Errorf("API key #%d must have both key and user ID", i+1)
+			}
+			keys[key.Key] = key.UserID
+		}
+		chain = append(chain, keys)
+	}
+	if cfg.TrustUserIDHeader {
+		zap.L().Warn("user ID header is trusted without verification, it must not be used in production")
+		chain = append(chain, auth.TrustedUserID{})
+	}
+	if len(chain) == 0 {
+		zap.L().Warn("no authentication method is configured, all requests will be rejected")
+	}
+	return chain, nil
+}
+
+// purgeOldEvents - purge subcommand, removes events ended earlier than retention period ago.
+func purgeOldEvents(ctx context.Context, repo app.EventRepository, cfg config.RetentionConfig) error {
+	report, err := app.PurgeEvents(ctx, repo, time.Now().Add(-cfg.Period), cfg.DryRun || dryRun)
+	if err != nil {
+		return err
+	}
+	if report.DryRun {
+		for _, event := range report.Events {
+			fmt.Printf("%s\t%s\t%q\n", event.ID, event.EndTime.Format(time.RFC3339), event.Title)
+		}
+		fmt.Printf("%d events ended before %s would be removed\n", len(report.Events), report.Before.Format(time.RFC3339))
+		return nil
+	}
+	fmt.Printf("%d events ended before %s removed\n", report.Deleted, report.Before.Format(time.RFC3339))
+	return nil
+}
+
 func shutdownHTTP(ctx context.Context, api *internalhttp.API, wg *sync.WaitGroup) {
 	defer wg.Done()
 	<-ctx.Done()
@@ -124,5 +215,8 @@ func shutdownHTTP(ctx context.Context, api *internalhttp.API, wg *sync.WaitGroup
 func shutdownGRPC(ctx context.Context, api *grpc.API, wg *sync.WaitGroup) {
 	defer wg.Done()
 	<-ctx.Done()
-	api.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), ServerShutdownTimeout)
+	defer cancel()
+	api.Stop(ctx)
 }
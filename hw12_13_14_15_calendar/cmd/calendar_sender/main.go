@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/logger"
+	amqpqueue "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue/amqp"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/sender"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	sqlstorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/sql"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+var configFilePath string
+
+func init() {
+	pflag.StringVarP(&configFilePath, "config", "c", "./configs/sender_config.yaml", "Path to configuration file")
+}
+
+func main() {
+	// forcing only one Fatal in an app
+	if err := mainImpl(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func mainImpl() error {
+	pflag.Parse()
+	for _, arg := range pflag.Args() {
+		if arg == "version" {
+			printVersion()
+			return nil
+		}
+	}
+
+	zap.L().Info("calendar sender is running...")
+	cfg, err := config.NewSenderConfig(configFilePath)
+	if err != nil {
+		return fmt.Errorf("error during config read: %w", err)
+	}
+
+	err = logger.InitLogger(cfg.Logger)
+	if err != nil {
+		return fmt.Errorf("erro during logger init: %w", err)
+	}
+
+	notifyCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	var statuses sender.StatusRepository
+	if cfg.Storage.UseMemoryStorage {
+		statuses = memorystorage.NewMemStorage()
+	} else {
+		dbStorage := sqlstorage.NewDBStorage()
+		if err := dbStorage.Connect(notifyCtx, cfg.Storage.DB.DSN()); err != nil {
+			return fmt.Errorf("failed to init db storage: %w", err)
+		}
+		statuses = dbStorage
+		defer func() {
+			if err := dbStorage.Close(); err != nil {
+				zap.L().Error("error during closing db storage", zap.Error(err))
+			}
+		}()
+	}
+	zap.L().Info("calendar sender storage started...")
+
+	broker := amqpqueue.NewBroker()
+	if err := broker.Connect(notifyCtx, cfg.Queue.URI()); err != nil {
+		return fmt.Errorf("failed to connect to queue broker: %w", err)
+	}
+	defer func() {
+		if err := broker.Close(); err != nil {
+			zap.L().Error("error during closing queue broker connection", zap.Error(err))
+		}
+	}()
+	topology := cfg.Queue.Topology()
+	if err := broker.Declare(notifyCtx, topology); err != nil {
+		return fmt.Errorf("failed to declare queue topology: %w", err)
+	}
+	zap.L().Info("calendar sender queue broker connected...")
+
+	if err := sender.New(broker.Consumer(topology), sender.NewLogNotifier(os.Stdout), statuses).Run(notifyCtx); err != nil {
+		return fmt.Errorf("error during notifications consuming: %w", err)
+	}
+	zap.L().Info("calendar sender stopped")
+	return nil
+}
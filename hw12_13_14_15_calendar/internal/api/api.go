@@ -63,8 +63,19 @@ func (a *api) ListDayEvents(ctx context.Context, date time.Time) ([]storage.Even
 }
 
 func (a *api) ListWeekEvents(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	intervalStart := startOfWeek(date)
-	intervalEnd := endOfWeek(date)
+	firstDay, err := a.cfg.Calendar.WeekStart()
+	if err != nil {
+		return nil, err
+	}
+	loc, err := a.cfg.Calendar.Location()
+	if err != nil {
+		return nil, err
+	}
+	if loc != nil {
+		date = date.In(loc)
+	}
+	intervalStart := startOfWeek(date, firstDay)
+	intervalEnd := endOfWeek(date, firstDay)
 	events, err := a.repo.FindEventsInInterval(ctx, intervalStart, intervalEnd)
 	if err != nil {
 		return nil, fmt.Errorf("errod during finding events in week interval: %w", err)
@@ -92,12 +103,14 @@ func endOfDay(t time.Time) time.Time {
 	return time.Date(y, m, d, 23, 59, 59, int(time.Second-time.Nanosecond), t.Location())
 }
 
-func startOfWeek(t time.Time) time.Time {
-	return t.Truncate(24 * 7 * time.Hour)
+func startOfWeek(t time.Time, firstDay time.Weekday) time.Time {
+	daysSinceStart := (int(t.Weekday()) - int(firstDay) + 7) % 7
+	y, m, d := t.Date()
+	return time.Date(y, m, d-daysSinceStart, 0, 0, 0, 0, t.Location())
 }
 
-func endOfWeek(t time.Time) time.Time {
-	return startOfWeek(t).AddDate(0, 0, 7).Add(-time.Nanosecond)
+func endOfWeek(t time.Time, firstDay time.Weekday) time.Time {
+	return startOfWeek(t, firstDay).AddDate(0, 0, 7).Add(-time.Nanosecond)
 }
 
 func startOfMonth(t time.Time) time.Time {
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartOfWeek(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		firstDay time.Weekday
+		expected time.Time
+	}{
+		{
+			name:     "monday week",
+			date:     time.Date(2021, time.September, 8, 15, 0, 0, 0, time.UTC),
+			firstDay: time.Monday,
+			expected: time.Date(2021, time.September, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "year boundary",
+			date:     time.Date(2021, time.January, 2, 12, 0, 0, 0, time.UTC),
+			firstDay: time.Sunday,
+			expected: time.Date(2020, time.December, 27, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "DST transition",
+			date:     time.Date(2021, time.November, 7, 12, 0, 0, 0, newYork),
+			firstDay: time.Monday,
+			expected: time.Date(2021, time.November, 1, 0, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.True(t, tc.expected.Equal(startOfWeek(tc.date, tc.firstDay)))
+			require.True(t, tc.expected.AddDate(0, 0, 7).Add(-time.Nanosecond).Equal(endOfWeek(tc.date, tc.firstDay)))
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+var (
+	ErrMissingAttendee = errors.New("attendee user id is missing")
+	ErrOwnerAttendee   = errors.New("event owner could not be invited to the event")
+	ErrInvalidRole     = errors.New("role is not viewer or editor")
+	ErrInvalidResponse = errors.New("response is not accepted, declined or tentative")
+)
+
+// InviteAttendee - invites the user to the event of the user who made the request, only the owner can invite.
+// Inviting the existing attendee again changes the attendee role and keeps the response.
+// The event is sent to the invited user watchers as created one.
+func (a *EventsService) InviteAttendee(ctx context.Context, eventID, userID string, role storage.AttendeeRole) (storage.Attendee, error) {
+	callerID, err := UserID(ctx)
+	if err != nil {
+		return storage.Attendee{}, err
+	}
+	if userID == "" {
+		return storage.Attendee{}, &ValidationError{Field: "user_id", Err: ErrMissingAttendee}
+	}
+	if !role.IsValid() {
+		return storage.Attendee{}, &ValidationError{Field: "role", Err: ErrInvalidRole}
+	}
+	event, err := a.findVisibleEvent(ctx, callerID, eventID)
+	if err != nil {
+		return storage.Attendee{}, err
+	}
+	if event.OwnerID != callerID {
+		return storage.Attendee{}, ErrForbidden
+	}
+	if userID == event.OwnerID {
+		return storage.Attendee{}, &ValidationError{Field: "user_id", Err: ErrOwnerAttendee}
+	}
+	attendees, err := a.repo.FindAttendees(ctx, eventID)
+	if err != nil {
+		return storage.Attendee{}, fmt.Errorf("error during finding event attendees: %w", err)
+	}
+	attendee, ok := findAttendee(attendees, userID)
+	if !ok {
+		attendee = storage.Attendee{EventID: eventID, UserID: userID, Status: storage.RSVPNeedsAction}
+	}
+	attendee.Role = role
+	if err := a.repo.SaveAttendee(ctx, attendee); err != nil {
+		return storage.Attendee{}, fmt.Errorf("error during saving attendee: %w", err)
+	}
+	if !ok {
+		a.publishChange(ctx, EventChange{Type: ChangeCreated, Event: event, Recipients: []string{userID}})
+	}
+	return attendee, nil
+}
+
+// RemoveAttendee - removes the user from the event attendees.
+// The owner can remove any attendee, attendees can remove only themselves.
+// The event is sent to the removed user watchers as deleted one.
+func (a *EventsService) RemoveAttendee(ctx context.Context, eventID, userID string) error {
+	callerID, err := UserID(ctx)
+	if err != nil {
+		return err
+	}
+	event, err := a.findVisibleEvent(ctx, callerID, eventID)
+	if err != nil {
+		return err
+	}
+	if event.OwnerID != callerID && userID != callerID {
+		return ErrForbidden
+	}
+	if err := a.repo.DeleteAttendee(ctx, eventID, userID); err != nil {
+		return err
+	}
+	a.publishChange(ctx, EventChange{Type: ChangeDeleted, Event: event, Recipients: []string{userID}})
+	return nil
+}
+
+// RespondToInvitation - saves response of the user who made the request to the event invitation.
+// Events with declined invitation are not listed, but they are still found by ID, so the response can be changed.
+func (a *EventsService) RespondToInvitation(ctx context.Context, eventID string, response storage.RSVPStatus) (storage.Attendee, error) {
+	callerID, err := UserID(ctx)
+	if err != nil {
+		return storage.Attendee{}, err
+	}
+	if !response.IsResponse() {
+		return storage.Attendee{}, &ValidationError{Field: "status", Err: ErrInvalidResponse}
+	}
+	if _, err := a.findVisibleEvent(ctx, callerID, eventID); err != nil {
+		return storage.Attendee{}, err
+	}
+	attendees, err := a.repo.FindAttendees(ctx, eventID)
+	if err != nil {
+		return storage.Attendee{}, fmt.Errorf("error during finding event attendees: %w", err)
+	}
+	attendee, ok := findAttendee(attendees, callerID)
+	if !ok {
+		// the owner is not invited to own event
+		return storage.Attendee{}, storage.ErrAttendeeNotFound
+	}
+	attendee.Status = response
+	if err := a.repo.SaveAttendee(ctx, attendee); err != nil {
+		return storage.Attendee{}, fmt.Errorf("error during saving attendee: %w", err)
+	}
+	return attendee, nil
+}
+
+// ListAttendees - lists attendees of the event the user who made the request owns or attends.
+func (a *EventsService) ListAttendees(ctx context.Context, eventID string) ([]storage.Attendee, error) {
+	callerID, err := UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.findVisibleEvent(ctx, callerID, eventID); err != nil {
+		return nil, err
+	}
+	attendees, err := a.repo.FindAttendees(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("error during finding event attendees: %w", err)
+	}
+	return attendees, nil
+}
+
+// findVisibleEvent - finds event the user owns or attends, storage.ErrEventNotFound is returned otherwise.
+func (a *EventsService) findVisibleEvent(ctx context.Context, userID, eventID string) (storage.Event, error) {
+	found, err := a.repo.FindEventsByID(ctx, userID, eventID)
+	if err != nil {
+		return storage.Event{}, fmt.Errorf("error during finding event: %w", err)
+	}
+	if len(found) == 0 {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	return found[0], nil
+}
+
+// checkEditor - checks that the user is allowed to change the event, the owner and editor attendees are.
+func (a *EventsService) checkEditor(ctx context.Context, event storage.Event, userID string) error {
+	if event.OwnerID == userID {
+		return nil
+	}
+	attendees, err := a.repo.FindAttendees(ctx, event.ID)
+	if err != nil {
+		return fmt.Errorf("error during finding event attendees: %w", err)
+	}
+	return checkEditorRole(event, attendees, userID)
+}
+
+// checkEditorRole - checkEditor of the event with already found attendees.
+func checkEditorRole(event storage.Event, attendees []storage.Attendee, userID string) error {
+	if event.OwnerID == userID {
+		return nil
+	}
+	if attendee, ok := findAttendee(attendees, userID); ok && attendee.Role == storage.AttendeeRoleEditor {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func findAttendee(attendees []storage.Attendee, userID string) (storage.Attendee, bool) {
+	for _, attendee := range attendees {
+		if attendee.UserID == userID {
+			return attendee, true
+		}
+	}
+	return storage.Attendee{}, false
+}
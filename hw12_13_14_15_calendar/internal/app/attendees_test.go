@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttendees(t *testing.T) {
+	owner := WithUserID(context.Background(), "owner")
+	editor := WithUserID(context.Background(), "editor")
+	viewer := WithUserID(context.Background(), "viewer")
+	other := WithUserID(context.Background(), "other")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	event, err := service.CreateEvent(owner, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	_, err = service.InviteAttendee(owner, event.ID, "editor", "admin")
+	require.ErrorIs(t, err, ErrInvalidRole)
+	_, err = service.InviteAttendee(owner, event.ID, "owner", storage.AttendeeRoleViewer)
+	require.ErrorIs(t, err, ErrOwnerAttendee)
+	_, err = service.InviteAttendee(other, event.ID, "other", storage.AttendeeRoleViewer)
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	invited, err := service.InviteAttendee(owner, event.ID, "editor", storage.AttendeeRoleEditor)
+	require.NoError(t, err)
+	require.Equal(t, storage.RSVPNeedsAction, invited.Status)
+	_, err = service.InviteAttendee(owner, event.ID, "viewer", storage.AttendeeRoleViewer)
+	require.NoError(t, err)
+	// only the owner invites
+	_, err = service.InviteAttendee(editor, event.ID, "other", storage.AttendeeRoleViewer)
+	require.ErrorIs(t, err, ErrForbidden)
+
+	// attended events are listed
+	events, err := service.ListDayEvents(viewer, start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	found, err := service.GetEvent(viewer, event.ID)
+	require.NoError(t, err)
+	require.Equal(t, "owner", found.OwnerID)
+
+	// editors change the event, viewers and other users don't
+	found.Title = "Daily standup"
+	updated, err := service.UpdateEvent(editor, found)
+	require.NoError(t, err)
+	require.Equal(t, "owner", updated.OwnerID)
+	_, err = service.UpdateEvent(viewer, updated)
+	require.ErrorIs(t, err, ErrForbidden)
+	_, err = service.UpdateEvent(other, updated)
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	require.ErrorIs(t, service.DeleteEvent(editor, event.ID), ErrForbidden)
+
+	_, err = service.RespondToInvitation(viewer, event.ID, storage.RSVPNeedsAction)
+	require.ErrorIs(t, err, ErrInvalidResponse)
+	_, err = service.RespondToInvitation(owner, event.ID, storage.RSVPAccepted)
+	require.ErrorIs(t, err, storage.ErrAttendeeNotFound)
+	responded, err := service.RespondToInvitation(viewer, event.ID, storage.RSVPDeclined)
+	require.NoError(t, err)
+	require.Equal(t, storage.AttendeeRoleViewer, responded.Role)
+	events, err = service.ListDayEvents(viewer, start)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	// role change keeps the response
+	changed, err := service.InviteAttendee(owner, event.ID, "viewer", storage.AttendeeRoleEditor)
+	require.NoError(t, err)
+	require.Equal(t, storage.RSVPDeclined, changed.Status)
+
+	attendees, err := service.ListAttendees(editor, event.ID)
+	require.NoError(t, err)
+	require.Len(t, attendees, 2)
+	_, err = service.ListAttendees(other, event.ID)
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+
+	// attendees leave by themselves, the owner removes anyone
+	require.ErrorIs(t, service.RemoveAttendee(editor, event.ID, "viewer"), ErrForbidden)
+	require.NoError(t, service.RemoveAttendee(viewer, event.ID, "viewer"))
+	require.NoError(t, service.RemoveAttendee(owner, event.ID, "editor"))
+	require.ErrorIs(t, service.RemoveAttendee(owner, event.ID, "editor"), storage.ErrAttendeeNotFound)
+	_, err = service.GetEvent(editor, event.ID)
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+}
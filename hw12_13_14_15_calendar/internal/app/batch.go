@@ -0,0 +1,281 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/gofrs/uuid"
+)
+
+// MaxBatchSize - max number of operations of a single batch.
+const MaxBatchSize = 1000
+
+var (
+	ErrEmptyBatch    = errors.New("batch has no operations")
+	ErrBatchTooLarge = fmt.Errorf("batch has more than %d operations", MaxBatchSize)
+	ErrInvalidAction = errors.New("action is not create, update or delete")
+	ErrBatchAborted  = errors.New("batch is not applied because another operation failed")
+)
+
+type BatchStatus string
+
+const (
+	BatchStatusApplied BatchStatus = "applied"
+	BatchStatusFailed  BatchStatus = "failed"
+	// BatchStatusAborted - operation passed the checks, but it is not applied because another operation of the batch failed.
+	BatchStatusAborted BatchStatus = "aborted"
+)
+
+// BatchItemResult - result of a single batch operation.
+type BatchItemResult struct {
+	Action  storage.BatchAction `json:"action"`
+	EventID string              `json:"event_id,omitempty"`
+	// Version - version of created or updated event
+	Version int64       `json:"version,omitempty"`
+	Status  BatchStatus `json:"status"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// BatchReport - results of batch operations in the same order, either all of them are applied or none.
+type BatchReport struct {
+	Items   []BatchItemResult `json:"items"`
+	Applied bool              `json:"applied"`
+}
+
+// fail - marks the operation with index as failed with err.
+func (r *BatchReport) fail(index int, err error) {
+	r.Items[index].Status = BatchStatusFailed
+	r.Items[index].Error = err.Error()
+}
+
+// abort - marks operations which are not failed as aborted.
+func (r *BatchReport) abort() {
+	for i := range r.Items {
+		if r.Items[i].Status != BatchStatusFailed {
+			r.Items[i].Status = BatchStatusAborted
+			r.Items[i].Error = ErrBatchAborted.Error()
+		}
+	}
+}
+
+// BatchEvents - creates, updates and deletes events of the user who made the request all-or-nothing.
+// Every operation is checked as the single event operation of its action (see CreateEvent, UpdateEvent
+// and DeleteEvent), IDs of created events are generated, only event ID of delete operation is used.
+// All operations are checked before the batch is applied, so the report has every invalid operation failed.
+// Error is returned only if the batch itself is invalid, results of every operation are in the report.
+func (a *EventsService) BatchEvents(ctx context.Context, operations []storage.BatchOperation) (BatchReport, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return BatchReport{}, err
+	}
+	switch {
+	case len(operations) == 0:
+		return BatchReport{}, &ValidationError{Field: "operations", Err: ErrEmptyBatch}
+	case len(operations) > MaxBatchSize:
+		return BatchReport{}, &ValidationError{Field: "operations", Err: ErrBatchTooLarge}
+	}
+
+	lookup, err := a.loadBatch(ctx, userID, operations)
+	if err != nil {
+		return BatchReport{}, err
+	}
+	// time zone of recurring events is resolved once for the whole batch
+	zoneCtx := ctx
+	if loc, err := a.Location(ctx); err == nil {
+		zoneCtx = WithTimeZone(ctx, loc)
+	}
+
+	report := BatchReport{Items: make([]BatchItemResult, len(operations))}
+	prepared := make([]storage.BatchOperation, len(operations))
+	changes := make([]EventChange, len(operations))
+	valid := true
+	for i, operation := range operations {
+		report.Items[i].Action = operation.Action
+		prepared[i], changes[i], err = a.prepareOperation(zoneCtx, userID, lookup, operation)
+		if err != nil {
+			report.fail(i, err)
+			valid = false
+			continue
+		}
+		report.Items[i].EventID = prepared[i].Event.ID
+	}
+	if !valid {
+		report.abort()
+		return report, nil
+	}
+
+	err = a.repo.ApplyBatch(ctx, prepared)
+	var batchErr *storage.BatchError
+	if errors.As(err, &batchErr) {
+		report.fail(batchErr.Index, batchErr.Err)
+		report.abort()
+		return report, nil
+	}
+	if err != nil {
+		return BatchReport{}, fmt.Errorf("error during applying batch: %w", err)
+	}
+
+	report.Applied = true
+	for i := range report.Items {
+		report.Items[i].Status = BatchStatusApplied
+		if changes[i].Type != ChangeDeleted {
+			report.Items[i].Version = changes[i].Event.Version
+		}
+		a.publishChange(ctx, changes[i])
+	}
+	return report, nil
+}
+
+// batchLookup - stored state batch operations are checked against, it is found once for the whole batch.
+type batchLookup struct {
+	// events - events of update and delete operations the user owns or attends
+	events    map[string]storage.Event
+	attendees map[string][]storage.Attendee
+	// calendars - IDs of the user calendars
+	calendars map[string]bool
+}
+
+// loadBatch - finds events the operations refer to, their attendees and calendars of the user with a query of each kind.
+func (a *EventsService) loadBatch(ctx context.Context, userID string, operations []storage.BatchOperation) (batchLookup, error) {
+	lookup := batchLookup{
+		events:    make(map[string]storage.Event),
+		attendees: make(map[string][]storage.Attendee),
+		calendars: make(map[string]bool),
+	}
+	var eventIDs []string
+	withCalendars := false
+	for _, operation := range operations {
+		if operation.Action != storage.BatchCreate && operation.Event.ID != "" {
+			eventIDs = append(eventIDs, operation.Event.ID)
+		}
+		withCalendars = withCalendars || operation.Event.CalendarID != ""
+	}
+
+	if len(eventIDs) > 0 {
+		found, err := a.repo.FindEventsByID(ctx, userID, eventIDs...)
+		if err != nil {
+			return lookup, fmt.Errorf("error during finding batch events: %w", err)
+		}
+		foundIDs := make([]string, 0, len(found))
+		for _, event := range found {
+			lookup.events[event.ID] = event
+			foundIDs = append(foundIDs, event.ID)
+		}
+		attendees, err := a.repo.FindAttendees(ctx, foundIDs...)
+		if err != nil {
+			return lookup, fmt.Errorf("error during finding batch events attendees: %w", err)
+		}
+		for _, attendee := range attendees {
+			lookup.attendees[attendee.EventID] = append(lookup.attendees[attendee.EventID], attendee)
+		}
+	}
+	if withCalendars {
+		calendars, err := a.repo.FindCalendars(ctx, userID)
+		if err != nil {
+			return lookup, fmt.Errorf("error during finding calendars: %w", err)
+		}
+		for _, calendar := range calendars {
+			lookup.calendars[calendar.ID] = true
+		}
+	}
+	return lookup, nil
+}
+
+// event - returns the event the user owns or attends, storage.ErrEventNotFound is returned otherwise.
+func (l batchLookup) event(eventID string) (storage.Event, error) {
+	event, ok := l.events[eventID]
+	if !ok {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	return event, nil
+}
+
+// checkCalendar - checks that the calendar belongs to the user as checkCalendars does.
+func (l batchLookup) checkCalendar(calendarID string) error {
+	if !l.calendars[calendarID] {
+		return &ValidationError{Field: "calendar_id", Err: fmt.Errorf("%w: %s", ErrUnknownCalendar, calendarID)}
+	}
+	return nil
+}
+
+// prepareOperation - checks the operation, returns the operation to apply and the change it makes.
+func (a *EventsService) prepareOperation(
+	ctx context.Context,
+	userID string,
+	lookup batchLookup,
+	operation storage.BatchOperation,
+) (storage.BatchOperation, EventChange, error) {
+	event := operation.Event
+	event.OwnerID = userID
+	switch operation.Action {
+	case storage.BatchCreate:
+		if err := ValidateEvent(event); err != nil {
+			return operation, EventChange{}, err
+		}
+		event, err := a.withSeriesZone(ctx, event, nil)
+		if err != nil {
+			return operation, EventChange{}, err
+		}
+		if event.CalendarID != "" {
+			if err := lookup.checkCalendar(event.CalendarID); err != nil {
+				return operation, EventChange{}, err
+			}
+		}
+		uuid4, err := uuid.NewV4()
+		if err != nil {
+			return operation, EventChange{}, fmt.Errorf("error during generation uuid for event id: %w", err)
+		}
+		event.ID = uuid4.String()
+		event.Version = 1
+		operation.Event = event
+		return operation, EventChange{Type: ChangeCreated, Event: event}, nil
+	case storage.BatchUpdate:
+		if err := ValidateEvent(event); err != nil {
+			return operation, EventChange{}, err
+		}
+		if event.Version <= 0 {
+			return operation, EventChange{}, &ValidationError{Field: "version", Err: ErrVersionRequired}
+		}
+		previous, err := lookup.event(event.ID)
+		if err != nil {
+			return operation, EventChange{}, err
+		}
+		attendees := lookup.attendees[previous.ID]
+		if err := checkEditorRole(previous, attendees, userID); err != nil {
+			return operation, EventChange{}, err
+		}
+		if event, err = a.withSeriesZone(ctx, event, &previous); err != nil {
+			return operation, EventChange{}, err
+		}
+		event.OwnerID = previous.OwnerID
+		if event.OwnerID != userID {
+			event.CalendarID = previous.CalendarID
+		}
+		if event.CalendarID != "" && event.CalendarID != previous.CalendarID {
+			if err := lookup.checkCalendar(event.CalendarID); err != nil {
+				return operation, EventChange{}, err
+			}
+		}
+		operation.Event = event
+		updated := event
+		updated.Version++
+		change := EventChange{Type: ChangeUpdated, Event: updated, Previous: &previous, Recipients: recipientsOf(event, attendees)}
+		return operation, change, nil
+	case storage.BatchDelete:
+		deleted, err := lookup.event(event.ID)
+		if err != nil {
+			return operation, EventChange{}, err
+		}
+		if deleted.OwnerID != userID {
+			return operation, EventChange{}, ErrForbidden
+		}
+		operation.Event = storage.Event{ID: deleted.ID, OwnerID: userID}
+		// attendees are deleted with the event
+		change := EventChange{Type: ChangeDeleted, Event: deleted, Recipients: recipientsOf(deleted, lookup.attendees[deleted.ID])}
+		return operation, change, nil
+	default:
+		return operation, EventChange{}, &ValidationError{Field: "action", Err: ErrInvalidAction}
+	}
+}
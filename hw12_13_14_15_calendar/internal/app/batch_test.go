@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchEvents(t *testing.T) {
+	owner := WithUserID(context.Background(), "owner")
+	other := WithUserID(context.Background(), "other")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	newEvent := func(title string, startTime time.Time) storage.Event {
+		return storage.Event{Title: title, StartTime: startTime, EndTime: startTime.Add(time.Hour)}
+	}
+	standup, err := service.CreateEvent(owner, newEvent("Standup", start))
+	require.NoError(t, err)
+	lunch, err := service.CreateEvent(owner, newEvent("Lunch", start.Add(2*time.Hour)))
+	require.NoError(t, err)
+
+	_, err = service.BatchEvents(owner, nil)
+	require.ErrorIs(t, err, ErrEmptyBatch)
+	_, err = service.BatchEvents(owner, make([]storage.BatchOperation, MaxBatchSize+1))
+	require.ErrorIs(t, err, ErrBatchTooLarge)
+	_, err = service.BatchEvents(context.Background(), []storage.BatchOperation{{Action: storage.BatchDelete}})
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	t.Run("invalid operation", func(t *testing.T) {
+		report, err := service.BatchEvents(owner, []storage.BatchOperation{
+			{Action: storage.BatchCreate, Event: newEvent("Review", start.Add(4*time.Hour))},
+			{Action: storage.BatchCreate, Event: newEvent("", start.Add(6*time.Hour))},
+			{Action: "move", Event: newEvent("Retro", start.Add(8*time.Hour))},
+			{Action: storage.BatchDelete, Event: storage.Event{ID: lunch.ID}},
+		})
+		require.NoError(t, err)
+		require.False(t, report.Applied)
+		// every operation is checked, so operations after the first invalid one are not just aborted
+		require.Equal(t, BatchStatusAborted, report.Items[0].Status)
+		require.Equal(t, BatchStatusFailed, report.Items[1].Status)
+		require.Contains(t, report.Items[1].Error, ErrEmptyTitle.Error())
+		require.Equal(t, BatchStatusFailed, report.Items[2].Status)
+		require.Contains(t, report.Items[2].Error, ErrInvalidAction.Error())
+		require.Equal(t, BatchStatusAborted, report.Items[3].Status)
+		require.Equal(t, ErrBatchAborted.Error(), report.Items[3].Error)
+	})
+
+	t.Run("events of another user", func(t *testing.T) {
+		report, err := service.BatchEvents(other, []storage.BatchOperation{
+			{Action: storage.BatchDelete, Event: storage.Event{ID: standup.ID}},
+		})
+		require.NoError(t, err)
+		require.False(t, report.Applied)
+		require.Equal(t, BatchStatusFailed, report.Items[0].Status)
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		// the second event overlaps the standup, so the first one is not created as well
+		report, err := service.BatchEvents(owner, []storage.BatchOperation{
+			{Action: storage.BatchCreate, Event: newEvent("Review", start.Add(4*time.Hour))},
+			{Action: storage.BatchCreate, Event: newEvent("Planning", start.Add(30*time.Minute))},
+		})
+		require.NoError(t, err)
+		require.False(t, report.Applied)
+		require.Equal(t, BatchStatusAborted, report.Items[0].Status)
+		require.Zero(t, report.Items[0].Version)
+		require.Equal(t, BatchStatusFailed, report.Items[1].Status)
+		events, err := service.ListDayEvents(owner, start)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+	})
+
+	t.Run("applied", func(t *testing.T) {
+		changed := standup
+		changed.Title = "Daily standup"
+		report, err := service.BatchEvents(owner, []storage.BatchOperation{
+			{Action: storage.BatchUpdate, Event: changed},
+			{Action: storage.BatchDelete, Event: storage.Event{ID: lunch.ID}},
+			{Action: storage.BatchCreate, Event: newEvent("Review", lunch.StartTime)},
+		})
+		require.NoError(t, err)
+		require.True(t, report.Applied)
+		require.Equal(t, []BatchItemResult{
+			{Action: storage.BatchUpdate, EventID: standup.ID, Version: 2, Status: BatchStatusApplied},
+			{Action: storage.BatchDelete, EventID: lunch.ID, Status: BatchStatusApplied},
+			{Action: storage.BatchCreate, EventID: report.Items[2].EventID, Version: 1, Status: BatchStatusApplied},
+		}, report.Items)
+
+		events, err := service.ListDayEvents(owner, start)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		found, err := service.GetEvent(owner, standup.ID)
+		require.NoError(t, err)
+		require.Equal(t, "Daily standup", found.Title)
+		_, err = service.GetEvent(owner, lunch.ID)
+		require.ErrorIs(t, err, storage.ErrEventNotFound)
+	})
+}
+
+// countingRepository - counts queries of the events and their attendees.
+type countingRepository struct {
+	EventRepository
+	eventQueries    int
+	attendeeQueries int
+}
+
+func (r *countingRepository) FindEventsByID(ctx context.Context, userID string, eventIDs ...string) ([]storage.Event, error) {
+	r.eventQueries++
+	return r.EventRepository.FindEventsByID(ctx, userID, eventIDs...)
+}
+
+func (r *countingRepository) FindAttendees(ctx context.Context, eventIDs ...string) ([]storage.Attendee, error) {
+	r.attendeeQueries++
+	return r.EventRepository.FindAttendees(ctx, eventIDs...)
+}
+
+func TestBatchEventsQueries(t *testing.T) {
+	owner := WithUserID(context.Background(), "owner")
+	editor := WithUserID(context.Background(), "editor")
+	repo := &countingRepository{EventRepository: memorystorage.NewMemStorage()}
+	service := New(repo, WithLocation(time.UTC))
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+
+	var operations []storage.BatchOperation
+	for i := 0; i < 10; i++ {
+		startTime := start.Add(time.Duration(i) * time.Hour)
+		event, err := service.CreateEvent(owner, storage.Event{Title: "Meeting", StartTime: startTime, EndTime: startTime.Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = service.InviteAttendee(owner, event.ID, "editor", storage.AttendeeRoleEditor)
+		require.NoError(t, err)
+		action := storage.BatchUpdate
+		if i%2 == 0 {
+			action = storage.BatchDelete
+		}
+		event.Title = "Changed meeting"
+		operations = append(operations, storage.BatchOperation{Action: action, Event: event})
+	}
+
+	// the editor may update, but not delete the events
+	repo.eventQueries, repo.attendeeQueries = 0, 0
+	report, err := service.BatchEvents(editor, operations)
+	require.NoError(t, err)
+	require.False(t, report.Applied)
+	require.Equal(t, BatchStatusFailed, report.Items[0].Status)
+	require.Equal(t, ErrForbidden.Error(), report.Items[0].Error)
+	require.Equal(t, BatchStatusAborted, report.Items[1].Status)
+	// existing events and their attendees are found once for the whole batch
+	require.Equal(t, 1, repo.eventQueries)
+	require.Equal(t, 1, repo.attendeeQueries)
+
+	repo.eventQueries, repo.attendeeQueries = 0, 0
+	report, err = service.BatchEvents(owner, operations)
+	require.NoError(t, err)
+	require.True(t, report.Applied, report.Items)
+	require.Equal(t, 1, repo.eventQueries)
+	require.Equal(t, 1, repo.attendeeQueries)
+}
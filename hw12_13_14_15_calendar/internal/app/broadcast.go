@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ChangesChannel - broadcast channel of event changes.
+	ChangesChannel = "event_changes"
+	// maxBroadcastPayload - PostgreSQL NOTIFY payload must be shorter than 8000 bytes.
+	maxBroadcastPayload = 7999
+	// listenRetryInterval - pause before listening again after broadcast connection failure
+	listenRetryInterval = time.Second
+)
+
+// Broadcaster - delivers messages to every listener of the channel including the sender itself,
+// e.g. PostgreSQL NOTIFY shared by all API replicas.
+type Broadcaster interface {
+	Notify(ctx context.Context, channel, payload string) error
+	// Listen - calls handle for every channel message until ctx is done or connection fails.
+	Listen(ctx context.Context, channel string, handle func(payload string)) error
+}
+
+// broadcastChange - change with its recipients, they are not encoded with the change itself.
+type broadcastChange struct {
+	EventChange
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// BroadcastPublisher - publishes event changes to all API replicas, every replica delivers them to its own hub.
+type BroadcastPublisher struct {
+	broadcaster Broadcaster
+	hub         *ChangeHub
+}
+
+func NewBroadcastPublisher(broadcaster Broadcaster, hub *ChangeHub) *BroadcastPublisher {
+	return &BroadcastPublisher{broadcaster: broadcaster, hub: hub}
+}
+
+func (p *BroadcastPublisher) PublishChange(ctx context.Context, eventChange EventChange) error {
+	change := broadcastChange{EventChange: eventChange, Recipients: eventChange.Recipients}
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("error during event change encoding: %w", err)
+	}
+	if len(payload) > maxBroadcastPayload {
+		// descriptions are the only unbounded texts, watchers can get them by event ID
+		change.Event.Description = ""
+		if change.Previous != nil {
+			previous := *change.Previous
+			previous.Description = ""
+			change.Previous = &previous
+		}
+		if payload, err = json.Marshal(change); err != nil {
+			return fmt.Errorf("error during event change encoding: %w", err)
+		}
+	}
+	if err := p.broadcaster.Notify(ctx, ChangesChannel, string(payload)); err != nil {
+		return fmt.Errorf("error during event change broadcasting: %w", err)
+	}
+	return nil
+}
+
+// Run - delivers broadcast changes to the hub until ctx is done, listening is restarted after failures.
+func (p *BroadcastPublisher) Run(ctx context.Context) {
+	for {
+		err := p.broadcaster.Listen(ctx, ChangesChannel, func(payload string) {
+			var change broadcastChange
+			if err := json.Unmarshal([]byte(payload), &change); err != nil {
+				zap.L().Error("error during event change decoding", zap.Error(err))
+				return
+			}
+			change.EventChange.Recipients = change.Recipients
+			_ = p.hub.PublishChange(ctx, change.EventChange)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		zap.L().Error("event changes listening failed, changes made meanwhile are lost", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
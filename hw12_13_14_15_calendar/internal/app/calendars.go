@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/gofrs/uuid"
+)
+
+// MaxCalendarNameLength - max calendar name length in characters.
+const MaxCalendarNameLength = 100
+
+var (
+	ErrEmptyName       = errors.New("name is empty")
+	ErrNameTooLong     = fmt.Errorf("name is longer than %d characters", MaxCalendarNameLength)
+	ErrInvalidColor    = errors.New("color is not in #RRGGBB format")
+	ErrUnknownCalendar = errors.New("calendar is not found among calendars of the user")
+	colorPattern       = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// ValidateCalendar - checks calendar business rules, the first broken rule is returned as *ValidationError.
+func ValidateCalendar(calendar storage.Calendar) error {
+	switch {
+	case calendar.Name == "":
+		return &ValidationError{Field: "name", Err: ErrEmptyName}
+	case utf8.RuneCountInString(calendar.Name) > MaxCalendarNameLength:
+		return &ValidationError{Field: "name", Err: ErrNameTooLong}
+	case calendar.OwnerID == "":
+		return &ValidationError{Field: "owner_id", Err: ErrMissingOwner}
+	case calendar.Color != "" && !colorPattern.MatchString(calendar.Color):
+		return &ValidationError{Field: "color", Err: ErrInvalidColor}
+	}
+	if calendar.TimeZone != "" {
+		if _, err := LoadTimeZone(calendar.TimeZone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateCalendar - creates new calendar of the user who made the request,
+// calendar ID is generated by the service so the given one is ignored as well as the given owner.
+func (a *EventsService) CreateCalendar(ctx context.Context, calendar storage.Calendar) (storage.Calendar, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	calendar.OwnerID = userID
+	if err := ValidateCalendar(calendar); err != nil {
+		return storage.Calendar{}, err
+	}
+	uuid4, err := uuid.NewV4()
+	if err != nil {
+		return storage.Calendar{}, fmt.Errorf("error during generation uuid for calendar id: %w", err)
+	}
+	calendar.ID = uuid4.String()
+	if err := a.repo.AddCalendar(ctx, calendar); err != nil {
+		return storage.Calendar{}, fmt.Errorf("error during creating calendar: %w", err)
+	}
+	return calendar, nil
+}
+
+// GetCalendar - finds calendar of the user who made the request by ID.
+func (a *EventsService) GetCalendar(ctx context.Context, calendarID string) (storage.Calendar, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	calendars, err := a.repo.FindCalendars(ctx, userID)
+	if err != nil {
+		return storage.Calendar{}, fmt.Errorf("error during finding calendars: %w", err)
+	}
+	for _, calendar := range calendars {
+		if calendar.ID == calendarID {
+			return calendar, nil
+		}
+	}
+	return storage.Calendar{}, storage.ErrCalendarNotFound
+}
+
+// UpdateCalendar - updates calendar of the user who made the request, calendars of other users are not found.
+func (a *EventsService) UpdateCalendar(ctx context.Context, calendar storage.Calendar) (storage.Calendar, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	calendar.OwnerID = userID
+	if err := ValidateCalendar(calendar); err != nil {
+		return storage.Calendar{}, err
+	}
+	if err := a.repo.UpdateCalendar(ctx, calendar); err != nil {
+		return storage.Calendar{}, err
+	}
+	return calendar, nil
+}
+
+// DeleteCalendar - deletes calendar of the user who made the request,
+// storage.ErrCalendarNotEmpty is returned while the calendar has events.
+func (a *EventsService) DeleteCalendar(ctx context.Context, calendarID string) error {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return err
+	}
+	return a.repo.DeleteCalendar(ctx, userID, calendarID)
+}
+
+// ListCalendars - lists calendars of the user who made the request ordered by name.
+func (a *EventsService) ListCalendars(ctx context.Context) ([]storage.Calendar, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := a.repo.FindCalendars(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error during finding calendars: %w", err)
+	}
+	return calendars, nil
+}
+
+// checkCalendars - checks that all calendars belong to the user, ErrUnknownCalendar of the field is returned otherwise.
+func (a *EventsService) checkCalendars(ctx context.Context, userID, field string, calendarIDs ...string) error {
+	_, err := a.findCalendars(ctx, userID, field, calendarIDs...)
+	return err
+}
+
+// findCalendars - returns calendars of the user with the IDs, ErrUnknownCalendar of the field is returned
+// if one of them is not found among calendars of the user.
+func (a *EventsService) findCalendars(ctx context.Context, userID, field string, calendarIDs ...string) ([]storage.Calendar, error) {
+	if len(calendarIDs) == 0 {
+		return nil, nil
+	}
+	calendars, err := a.repo.FindCalendars(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error during finding calendars: %w", err)
+	}
+	owned := make(map[string]storage.Calendar, len(calendars))
+	for _, calendar := range calendars {
+		owned[calendar.ID] = calendar
+	}
+	result := make([]storage.Calendar, 0, len(calendarIDs))
+	for _, calendarID := range calendarIDs {
+		calendar, ok := owned[calendarID]
+		if !ok {
+			return nil, &ValidationError{Field: field, Err: fmt.Errorf("%w: %s", ErrUnknownCalendar, calendarID)}
+		}
+		result = append(result, calendar)
+	}
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCalendar(t *testing.T) {
+	valid := storage.Calendar{OwnerID: "owner", Name: "Work", Color: "#1A2b3C", TimeZone: "Europe/Berlin"}
+	require.NoError(t, ValidateCalendar(valid))
+
+	tests := []struct {
+		name     string
+		change   func(calendar *storage.Calendar)
+		expected error
+	}{
+		{name: "empty name", change: func(c *storage.Calendar) { c.Name = "" }, expected: ErrEmptyName},
+		{name: "short color", change: func(c *storage.Calendar) { c.Color = "#fff" }, expected: ErrInvalidColor},
+		{name: "named color", change: func(c *storage.Calendar) { c.Color = "green" }, expected: ErrInvalidColor},
+		{name: "unknown time zone", change: func(c *storage.Calendar) { c.TimeZone = "Mars/Olympus" }, expected: ErrUnknownTimeZone},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := valid
+			tt.change(&calendar)
+			require.ErrorIs(t, ValidateCalendar(calendar), tt.expected)
+		})
+	}
+}
+
+func TestCalendars(t *testing.T) {
+	owner := WithUserID(context.Background(), "owner")
+	other := WithUserID(context.Background(), "other")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+
+	work, err := service.CreateCalendar(owner, storage.Calendar{ID: "ignored", Name: "Work", TimeZone: "Europe/Berlin"})
+	require.NoError(t, err)
+	require.NotEqual(t, "ignored", work.ID)
+	require.Equal(t, "owner", work.OwnerID)
+	home, err := service.CreateCalendar(owner, storage.Calendar{Name: "Home"})
+	require.NoError(t, err)
+	_, err = service.GetCalendar(other, work.ID)
+	require.ErrorIs(t, err, storage.ErrCalendarNotFound)
+
+	work.Color = "#ff0000"
+	updated, err := service.UpdateCalendar(owner, work)
+	require.NoError(t, err)
+	found, err := service.GetCalendar(owner, work.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated, found)
+	_, err = service.UpdateCalendar(other, work)
+	require.ErrorIs(t, err, storage.ErrCalendarNotFound)
+
+	// events are created only in calendars of their owner
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	_, err = service.CreateEvent(other, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour), CalendarID: work.ID})
+	require.ErrorIs(t, err, ErrUnknownCalendar)
+	standup, err := service.CreateEvent(owner, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour), CalendarID: work.ID})
+	require.NoError(t, err)
+	_, err = service.CreateEvent(owner, storage.Event{Title: "Dinner", StartTime: start.Add(8 * time.Hour), EndTime: start.Add(9 * time.Hour), CalendarID: home.ID})
+	require.NoError(t, err)
+
+	events, err := service.ListDayEvents(owner, start)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	events, err = service.ListWeekEvents(owner, start, work.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, standup.ID, events[0].ID)
+	_, err = service.ListMonthEvents(other, start, work.ID)
+	require.ErrorIs(t, err, ErrUnknownCalendar)
+
+	// event moved out of the calendar lets it be deleted
+	require.ErrorIs(t, service.DeleteCalendar(owner, work.ID), storage.ErrCalendarNotEmpty)
+	standup.CalendarID = ""
+	_, err = service.UpdateEvent(owner, standup)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteCalendar(owner, work.ID))
+	calendars, err := service.ListCalendars(owner)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Calendar{home}, calendars)
+}
+
+func TestCalendarTimeZone(t *testing.T) {
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	newYork := loadLocation(t, "America/New_York")
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+
+	work, err := service.CreateCalendar(ctx, storage.Calendar{Name: "Work", TimeZone: "Asia/Tokyo"})
+	require.NoError(t, err)
+	trip, err := service.CreateCalendar(ctx, storage.Calendar{Name: "Trip", TimeZone: "Asia/Tokyo"})
+	require.NoError(t, err)
+	home, err := service.CreateCalendar(ctx, storage.Calendar{Name: "Home"})
+	require.NoError(t, err)
+	// 2021-09-07 08:30 in Tokyo
+	start := time.Date(2021, time.September, 6, 23, 30, 0, 0, time.UTC)
+	_, err = service.CreateEvent(ctx, storage.Event{Title: "Call", StartTime: start, EndTime: start.Add(15 * time.Minute), CalendarID: work.ID})
+	require.NoError(t, err)
+
+	day := time.Date(2021, time.September, 7, 0, 0, 0, 0, time.UTC)
+	events, err := service.ListDayEvents(ctx, day)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	// listing of calendars sharing the time zone uses it
+	loc, err := service.Location(ctx, work.ID, trip.ID)
+	require.NoError(t, err)
+	require.Equal(t, tokyo, loc)
+	events, err = service.ListDayEvents(ctx, day, work.ID, trip.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "2021-09-07T08:30:00+09:00", events[0].StartTime.Format(time.RFC3339))
+
+	// calendars with different time zones fall back to the request one
+	loc, err = service.Location(ctx, work.ID, home.ID)
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, loc)
+	events, err = service.ListDayEvents(ctx, day, work.ID, home.ID)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	// the client time zone wins over the calendar one
+	loc, err = service.Location(WithTimeZone(ctx, newYork), work.ID)
+	require.NoError(t, err)
+	require.Equal(t, newYork, loc)
+
+	_, err = service.Location(WithUserID(context.Background(), "other"), work.ID)
+	require.ErrorIs(t, err, ErrUnknownCalendar)
+}
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// subscriptionBufferSize - number of changes queued for a subscriber before it is considered lagging.
+	subscriptionBufferSize = 64
+	// changeHistorySize - number of the latest changes kept for resuming subscriptions.
+	changeHistorySize = 1024
+)
+
+var (
+	ErrSubscriptionLagging = errors.New("subscriber does not keep up with event changes")
+	ErrInvalidChangeID     = errors.New("change ID is malformed")
+)
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+	// ChangeReset - changes after the ID subscription was resumed from are not available anymore,
+	// so subscriber must reload events. Event of reset change is empty.
+	ChangeReset ChangeType = "reset"
+)
+
+// EventChange - event mutation, Event is the state after the change, or the last state for deleted event.
+type EventChange struct {
+	// ID - assigned by the hub the change is published to, subscription can be resumed after it
+	ID    string        `json:"id,omitempty"`
+	Type  ChangeType    `json:"type"`
+	Event storage.Event `json:"event"`
+	// Previous - event state before update, nil for created and deleted events
+	Previous *storage.Event `json:"previous,omitempty"`
+	// Recipients - IDs of users the change is sent to, the event owner only if it is empty.
+	// Attendees are not sent to watchers, they can list them by event ID.
+	Recipients []string `json:"-"`
+}
+
+// isRecipient - checks whether the change is sent to the user.
+func (c EventChange) isRecipient(userID string) bool {
+	if len(c.Recipients) == 0 {
+		return c.Event.OwnerID == userID
+	}
+	return containsUserID(c.Recipients, userID)
+}
+
+func containsUserID(userIDs []string, userID string) bool {
+	for _, id := range userIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangePublisher - delivers event changes to subscribers, ChangeHub delivers them inside the process,
+// other implementations may broadcast them to every API replica which then publishes them to its own hub.
+type ChangePublisher interface {
+	PublishChange(ctx context.Context, change EventChange) error
+}
+
+// ChangeHub - in process fan out of event changes to subscriptions of the change recipients.
+// Change IDs are "<hub instance>-<sequence number>", so IDs of another API replica
+// or of the hub before service restart are never mistaken for the own ones.
+type ChangeHub struct {
+	mu       sync.Mutex
+	instance string
+	// seq - sequence number of the last published change
+	seq uint64
+	// history - the latest changes, the last one has seq number
+	history []EventChange
+	// subscriptions by user ID
+	subscriptions map[string]map[*Subscription]struct{}
+}
+
+func NewChangeHub() *ChangeHub {
+	return &ChangeHub{
+		instance:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscriptions: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscription - stream of changes sent to one user accepted by the filter.
+type Subscription struct {
+	hub     *ChangeHub
+	userID  string
+	filter  func(EventChange) bool
+	changes chan EventChange
+	err     error
+}
+
+// Subscribe - subscribes to changes of events the user owns or attends, nil filter accepts all changes.
+// Non empty afterID resumes subscription after the change with this ID: missed changes are sent first,
+// or the only ChangeReset change is sent if they are not available anymore.
+// Subscription must be closed when it is not needed anymore.
+func (h *ChangeHub) Subscribe(userID, afterID string, filter func(EventChange) bool) (*Subscription, error) {
+	var instance string
+	var after uint64
+	if afterID != "" {
+		var err error
+		if instance, after, err = parseChangeID(afterID); err != nil {
+			return nil, err
+		}
+	}
+	sub := &Subscription{hub: h, userID: userID, filter: filter}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var missed []EventChange
+	if afterID != "" {
+		// sequence number of the change preceding the first change in history
+		historyStart := h.seq - uint64(len(h.history))
+		if instance != h.instance || after < historyStart || after > h.seq {
+			missed = []EventChange{{ID: h.changeID(h.seq), Type: ChangeReset}}
+		} else {
+			for _, change := range h.history[after-historyStart:] {
+				if sub.accepts(change) {
+					missed = append(missed, change)
+				}
+			}
+		}
+	}
+	sub.changes = make(chan EventChange, subscriptionBufferSize+len(missed))
+	for _, change := range missed {
+		sub.changes <- change
+	}
+	userSubs, ok := h.subscriptions[sub.userID]
+	if !ok {
+		userSubs = make(map[*Subscription]struct{})
+		h.subscriptions[sub.userID] = userSubs
+	}
+	userSubs[sub] = struct{}{}
+	return sub, nil
+}
+
+// PublishChange - assigns ID to the change and sends it to subscriptions of its recipients without blocking,
+// subscription which buffer is full is closed with ErrSubscriptionLagging.
+func (h *ChangeHub) PublishChange(ctx context.Context, change EventChange) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	change.ID = h.changeID(h.seq)
+	h.history = append(h.history, change)
+	if len(h.history) > changeHistorySize {
+		h.history = h.history[1:]
+	}
+	recipients := change.Recipients
+	if len(recipients) == 0 {
+		recipients = []string{change.Event.OwnerID}
+	}
+	for _, userID := range recipients {
+		for sub := range h.subscriptions[userID] {
+			if !sub.accepts(change) {
+				continue
+			}
+			select {
+			case sub.changes <- change:
+			default:
+				zap.L().Warn("event changes subscription is lagging", zap.String("user_id", sub.userID))
+				sub.err = ErrSubscriptionLagging
+				h.remove(sub)
+			}
+		}
+	}
+	return nil
+}
+
+func (h *ChangeHub) changeID(seq uint64) string {
+	return h.instance + "-" + strconv.FormatUint(seq, 10)
+}
+
+func parseChangeID(id string) (instance string, seq uint64, err error) {
+	sep := strings.LastIndex(id, "-")
+	if sep <= 0 {
+		return "", 0, ErrInvalidChangeID
+	}
+	if seq, err = strconv.ParseUint(id[sep+1:], 10, 64); err != nil {
+		return "", 0, ErrInvalidChangeID
+	}
+	return id[:sep], seq, nil
+}
+
+func (s *Subscription) accepts(change EventChange) bool {
+	return change.isRecipient(s.userID) && (s.filter == nil || s.filter(change))
+}
+
+// remove - removes subscription and closes its channel, must be called under lock.
+func (h *ChangeHub) remove(sub *Subscription) {
+	userSubs, ok := h.subscriptions[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := userSubs[sub]; !ok {
+		return
+	}
+	delete(userSubs, sub)
+	if len(userSubs) == 0 {
+		delete(h.subscriptions, sub.userID)
+	}
+	close(sub.changes)
+}
+
+// Changes - channel of changes, it is closed when the subscription is closed or lagging.
+func (s *Subscription) Changes() <-chan EventChange {
+	return s.changes
+}
+
+// Err - returns ErrSubscriptionLagging if the subscription was closed by the hub, call it after Changes is closed.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// WatchEvents - subscribes to changes of events the user who made the request owns or attends overlapping [from, to) range,
+// zero from or to means the range is not bounded from that side.
+// Updated event is sent if either its new or previous state overlaps the range.
+// Non empty afterChangeID resumes watching after the change with this ID, see ChangeHub.Subscribe.
+func (a *EventsService) WatchEvents(ctx context.Context, from, to time.Time, afterChangeID string) (*Subscription, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, &ValidationError{Field: "to", Err: ErrInvalidRange}
+	}
+	subscription, err := a.changes.Subscribe(userID, afterChangeID, func(change EventChange) bool {
+		if overlaps(change.Event, from, to) {
+			return true
+		}
+		return change.Previous != nil && overlaps(*change.Previous, from, to)
+	})
+	if err != nil {
+		return nil, &ValidationError{Field: "after_change_id", Err: err}
+	}
+	return subscription, nil
+}
+
+func overlaps(event storage.Event, from, to time.Time) bool {
+	ok, err := event.Overlaps(from, to)
+	if err != nil {
+		zap.L().Error("error during checking event range", zap.String("event_id", event.ID), zap.Error(err))
+	}
+	return ok
+}
+
+// eventRecipients - returns the owner and attendees of the event, attendees must be found before the event is deleted.
+// Failed attendees finding is only logged, so the change is sent to the owner at least.
+func (a *EventsService) eventRecipients(ctx context.Context, event storage.Event) []string {
+	attendees, err := a.repo.FindAttendees(ctx, event.ID)
+	if err != nil {
+		zap.L().Error("error during finding event change recipients", zap.String("event_id", event.ID), zap.Error(err))
+		return []string{event.OwnerID}
+	}
+	return recipientsOf(event, attendees)
+}
+
+// recipientsOf - returns the owner and attendees of the event with already found attendees.
+func recipientsOf(event storage.Event, attendees []storage.Attendee) []string {
+	recipients := []string{event.OwnerID}
+	for _, attendee := range attendees {
+		recipients = append(recipients, attendee.UserID)
+	}
+	return recipients
+}
+
+// publishChange - event is already saved, so failed publishing is only logged.
+func (a *EventsService) publishChange(ctx context.Context, change EventChange) {
+	if err := a.publisher.PublishChange(ctx, change); err != nil {
+		zap.L().Error("error during publishing event change",
+			zap.String("event_id", change.Event.ID), zap.String("type", string(change.Type)), zap.Error(err))
+	}
+}
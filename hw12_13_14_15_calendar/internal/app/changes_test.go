@@ -0,0 +1,269 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveChange(t *testing.T, subscription *Subscription) EventChange {
+	t.Helper()
+	select {
+	case change, ok := <-subscription.Changes():
+		require.True(t, ok, "subscription is closed")
+		return change
+	case <-time.After(time.Second):
+		require.FailNow(t, "change is not received")
+		return EventChange{}
+	}
+}
+
+func requireNoChanges(t *testing.T, subscription *Subscription) {
+	t.Helper()
+	select {
+	case change := <-subscription.Changes():
+		require.FailNow(t, "unexpected change", "%+v", change)
+	default:
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage())
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+
+	subscription, err := service.WatchEvents(ctx, start, start.AddDate(0, 0, 1), "")
+	require.NoError(t, err)
+	defer subscription.Close()
+	anotherSubscription, err := service.WatchEvents(WithUserID(context.Background(), "another owner"), time.Time{}, time.Time{}, "")
+	require.NoError(t, err)
+	defer anotherSubscription.Close()
+
+	event, err := service.CreateEvent(ctx, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	change := receiveChange(t, subscription)
+	require.Equal(t, ChangeCreated, change.Type)
+	require.Equal(t, event, change.Event)
+
+	// event moved out of the range is still sent, so the watcher can remove it
+	moved := event
+	moved.StartTime, moved.EndTime = start.AddDate(0, 0, 2), start.AddDate(0, 0, 2).Add(time.Hour)
+	moved, err = service.UpdateEvent(ctx, moved)
+	require.NoError(t, err)
+	change = receiveChange(t, subscription)
+	require.Equal(t, ChangeUpdated, change.Type)
+	require.True(t, moved.StartTime.Equal(change.Event.StartTime))
+	require.Equal(t, event, *change.Previous)
+
+	// changes outside the range are not sent
+	moved.Title = "Daily standup"
+	_, err = service.UpdateEvent(ctx, moved)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteEvent(ctx, moved.ID))
+	requireNoChanges(t, subscription)
+	requireNoChanges(t, anotherSubscription)
+
+	require.ErrorIs(t, service.DeleteEvent(ctx, moved.ID), storage.ErrEventNotFound)
+
+	subscription.Close()
+	_, ok := <-subscription.Changes()
+	require.False(t, ok)
+	require.NoError(t, subscription.Err())
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := service.WatchEvents(ctx, start, start.Add(-time.Hour), "")
+		require.ErrorIs(t, err, ErrInvalidRange)
+		_, err = service.WatchEvents(context.Background(), time.Time{}, time.Time{}, "")
+		require.ErrorIs(t, err, ErrUnauthenticated)
+	})
+}
+
+func TestWatchAttendedEvents(t *testing.T) {
+	owner := WithUserID(context.Background(), "owner")
+	attendee := WithUserID(context.Background(), "attendee")
+	service := New(memorystorage.NewMemStorage())
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	event, err := service.CreateEvent(owner, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	subscription, err := service.WatchEvents(attendee, time.Time{}, time.Time{}, "")
+	require.NoError(t, err)
+	defer subscription.Close()
+
+	// invited event appears for the attendee
+	_, err = service.InviteAttendee(owner, event.ID, "attendee", storage.AttendeeRoleViewer)
+	require.NoError(t, err)
+	change := receiveChange(t, subscription)
+	require.Equal(t, ChangeCreated, change.Type)
+	require.Equal(t, event.ID, change.Event.ID)
+	// role change is not an invitation
+	_, err = service.InviteAttendee(owner, event.ID, "attendee", storage.AttendeeRoleEditor)
+	require.NoError(t, err)
+	requireNoChanges(t, subscription)
+
+	event.Title = "Daily standup"
+	event, err = service.UpdateEvent(owner, event)
+	require.NoError(t, err)
+	change = receiveChange(t, subscription)
+	require.Equal(t, ChangeUpdated, change.Type)
+	require.Equal(t, "Daily standup", change.Event.Title)
+
+	// removed attendee gets the event deleted and no changes after that
+	require.NoError(t, service.RemoveAttendee(owner, event.ID, "attendee"))
+	change = receiveChange(t, subscription)
+	require.Equal(t, ChangeDeleted, change.Type)
+	require.Equal(t, event.ID, change.Event.ID)
+	event.Title = "Standup"
+	event, err = service.UpdateEvent(owner, event)
+	require.NoError(t, err)
+	requireNoChanges(t, subscription)
+
+	// attendees deleted with the event get the change as well
+	_, err = service.InviteAttendee(owner, event.ID, "attendee", storage.AttendeeRoleViewer)
+	require.NoError(t, err)
+	receiveChange(t, subscription)
+	require.NoError(t, service.DeleteEvent(owner, event.ID))
+	change = receiveChange(t, subscription)
+	require.Equal(t, ChangeDeleted, change.Type)
+	require.Equal(t, event.ID, change.Event.ID)
+}
+
+func TestLaggingSubscription(t *testing.T) {
+	hub := NewChangeHub()
+	subscription, err := hub.Subscribe("owner", "", nil)
+	require.NoError(t, err)
+	change := EventChange{Type: ChangeCreated, Event: storage.Event{OwnerID: "owner"}}
+	for i := 0; i <= subscriptionBufferSize; i++ {
+		require.NoError(t, hub.PublishChange(context.Background(), change))
+	}
+	received := 0
+	for range subscription.Changes() {
+		received++
+	}
+	require.Equal(t, subscriptionBufferSize, received)
+	require.ErrorIs(t, subscription.Err(), ErrSubscriptionLagging)
+	// closing of the removed subscription is safe
+	subscription.Close()
+}
+
+func TestResumeSubscription(t *testing.T) {
+	ctx := context.Background()
+	hub := NewChangeHub()
+	publish := func(ownerID string) {
+		require.NoError(t, hub.PublishChange(ctx, EventChange{Type: ChangeCreated, Event: storage.Event{OwnerID: ownerID}}))
+	}
+	subscription, err := hub.Subscribe("owner", "", nil)
+	require.NoError(t, err)
+	defer subscription.Close()
+	publish("owner")
+	publish("another owner")
+	publish("owner")
+	first := receiveChange(t, subscription)
+	last := receiveChange(t, subscription)
+
+	resumed, err := hub.Subscribe("owner", first.ID, nil)
+	require.NoError(t, err)
+	defer resumed.Close()
+	require.Equal(t, last, receiveChange(t, resumed))
+	requireNoChanges(t, resumed)
+
+	// IDs of another hub
+	resumed, err = NewChangeHub().Subscribe("owner", first.ID, nil)
+	require.NoError(t, err)
+	defer resumed.Close()
+	require.Equal(t, ChangeReset, receiveChange(t, resumed).Type)
+
+	// missed changes are out of history
+	for i := 0; i <= changeHistorySize; i++ {
+		publish("another owner")
+	}
+	resumed, err = hub.Subscribe("owner", last.ID, nil)
+	require.NoError(t, err)
+	defer resumed.Close()
+	reset := receiveChange(t, resumed)
+	require.Equal(t, ChangeReset, reset.Type)
+	requireNoChanges(t, resumed)
+
+	// reset change ID is the latest one, so the subscription can be resumed after it
+	resumed, err = hub.Subscribe("owner", reset.ID, nil)
+	require.NoError(t, err)
+	defer resumed.Close()
+	requireNoChanges(t, resumed)
+
+	_, err = hub.Subscribe("owner", "broken", nil)
+	require.ErrorIs(t, err, ErrInvalidChangeID)
+}
+
+// loopbackBroadcaster - broadcaster of the single process.
+type loopbackBroadcaster struct {
+	mu       sync.Mutex
+	handlers []func(payload string)
+	ready    chan struct{}
+}
+
+func (b *loopbackBroadcaster) Notify(ctx context.Context, channel, payload string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, handle := range b.handlers {
+		handle(payload)
+	}
+	return nil
+}
+
+func (b *loopbackBroadcaster) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handle)
+	b.mu.Unlock()
+	close(b.ready)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestBroadcastPublisher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broadcaster := &loopbackBroadcaster{ready: make(chan struct{})}
+	hub := NewChangeHub()
+	publisher := NewBroadcastPublisher(broadcaster, hub)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		publisher.Run(ctx)
+	}()
+	<-broadcaster.ready
+
+	service := New(memorystorage.NewMemStorage(), WithChangeHub(hub), WithChangePublisher(publisher))
+	userCtx := WithUserID(ctx, "owner")
+	subscription, err := service.WatchEvents(userCtx, time.Time{}, time.Time{}, "")
+	require.NoError(t, err)
+	defer subscription.Close()
+
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	event, err := service.CreateEvent(userCtx, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	change := receiveChange(t, subscription)
+	require.Equal(t, ChangeCreated, change.Type)
+	require.Equal(t, event.ID, change.Event.ID)
+	require.True(t, event.StartTime.Equal(change.Event.StartTime))
+
+	// recipients are broadcast with the change
+	attendeeCtx := WithUserID(ctx, "attendee")
+	attendeeSubscription, err := service.WatchEvents(attendeeCtx, time.Time{}, time.Time{}, "")
+	require.NoError(t, err)
+	defer attendeeSubscription.Close()
+	_, err = service.InviteAttendee(userCtx, event.ID, "attendee", storage.AttendeeRoleViewer)
+	require.NoError(t, err)
+	change = receiveChange(t, attendeeSubscription)
+	require.Equal(t, ChangeCreated, change.Type)
+	require.NoError(t, service.DeleteEvent(userCtx, event.ID))
+	require.Equal(t, ChangeDeleted, receiveChange(t, attendeeSubscription).Type)
+	require.Equal(t, ChangeDeleted, receiveChange(t, subscription).Type)
+
+	cancel()
+	<-done
+}
@@ -9,71 +9,288 @@ import (
 	"github.com/gofrs/uuid"
 )
 
+// EventRepository - events storage, events of other owners are never found, updated or deleted
+// by owner scoped methods, storage.ErrEventNotFound is returned instead.
+// Events found for the user are the ones the user owns or attends.
 type EventRepository interface {
+	// AddEvent and UpdateEvent return storage.ErrDateBusy if event overlaps another event of the same owner.
 	AddEvent(ctx context.Context, event storage.Event) error
+	// UpdateEvent - updates event of event.OwnerID if its stored version is event.Version,
+	// storage.ErrVersionConflict is returned otherwise. Stored event version is incremented.
 	UpdateEvent(ctx context.Context, event storage.Event) error
-	DeleteEvent(ctx context.Context, eventID string) error
-	FindEventsInInterval(ctx context.Context, intervalStart, intervalEnd time.Time) ([]storage.Event, error)
-	FindEventsByID(ctx context.Context, eventIDs ...string) ([]storage.Event, error)
+	DeleteEvent(ctx context.Context, ownerID, eventID string) error
+	// ApplyBatch - applies operations in order all-or-nothing, if one of them fails none are applied
+	// and *storage.BatchError with the failed operation is returned.
+	ApplyBatch(ctx context.Context, operations []storage.BatchOperation) error
+	// FindEventsInInterval - events with declined invitations of the user are not found.
+	// If calendar IDs are given, only owned events of these calendars are found.
+	FindEventsInInterval(
+		ctx context.Context,
+		userID string,
+		intervalStart, intervalEnd time.Time,
+		calendarIDs ...string,
+	) ([]storage.Event, error)
+	FindEventsByID(ctx context.Context, userID string, eventIDs ...string) ([]storage.Event, error)
+	// SaveAttendee - adds attendee or replaces the one with the same user ID,
+	// storage.ErrEventNotFound is returned if there is no such event. Attendees are deleted with their event.
+	SaveAttendee(ctx context.Context, attendee storage.Attendee) error
+	// DeleteAttendee - returns storage.ErrAttendeeNotFound if the user is not the event attendee.
+	DeleteAttendee(ctx context.Context, eventID, userID string) error
+	// FindAttendees - finds attendees of the events ordered by event ID and user ID.
+	FindAttendees(ctx context.Context, eventIDs ...string) ([]storage.Attendee, error)
+	AddCalendar(ctx context.Context, calendar storage.Calendar) error
+	// UpdateCalendar - returns storage.ErrCalendarNotFound if calendar.OwnerID has no such calendar.
+	UpdateCalendar(ctx context.Context, calendar storage.Calendar) error
+	// DeleteCalendar - returns storage.ErrCalendarNotEmpty if the calendar has events.
+	DeleteCalendar(ctx context.Context, ownerID, calendarID string) error
+	FindCalendars(ctx context.Context, ownerID string) ([]storage.Calendar, error)
+	// SaveUserSettings - adds or replaces settings of settings.UserID.
+	SaveUserSettings(ctx context.Context, settings storage.UserSettings) error
+	// FindUserSettings - returns empty settings of the user if the user has not saved any.
+	FindUserSettings(ctx context.Context, userID string) (storage.UserSettings, error)
+	// SearchEvents - full text search of query.OwnerID events, hits are ordered by rank descending.
+	SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchHit, error)
+	// FindEventsToNotify - finds events which notification time is inside [from, to) interval.
+	FindEventsToNotify(ctx context.Context, from, to time.Time) ([]storage.Event, error)
+	// FindEventsBefore - finds events which ended before the given moment.
+	FindEventsBefore(ctx context.Context, t time.Time) ([]storage.Event, error)
+	// DeleteEventsBefore - deletes events which ended before the given moment, returns number of deleted events.
+	DeleteEventsBefore(ctx context.Context, t time.Time) (int64, error)
 }
 
 type EventsService struct {
-	repo EventRepository
+	repo           EventRepository
+	firstDayOfWeek time.Weekday
+	// location - default time zone of day, week and month boundaries, nil means time zone of the requested date
+	location *time.Location
+	// changes - hub of event changes subscriptions, changes are delivered to it by publisher
+	changes   *ChangeHub
+	publisher ChangePublisher
 }
 
-func New(repo EventRepository) *EventsService {
-	return &EventsService{repo}
+type Option func(a *EventsService)
+
+// WithFirstDayOfWeek - sets the day weeks start on, weeks start on Monday by default.
+func WithFirstDayOfWeek(day time.Weekday) Option {
+	return func(a *EventsService) {
+		a.firstDayOfWeek = day
+	}
+}
+
+// WithLocation - sets default time zone of day, week and month boundaries,
+// it is used for requests without time zone passed by the client.
+func WithLocation(loc *time.Location) Option {
+	return func(a *EventsService) {
+		a.location = loc
+	}
+}
+
+// WithChangeHub - sets hub event changes are watched with, hub is created by the service by default.
+func WithChangeHub(hub *ChangeHub) Option {
+	return func(a *EventsService) {
+		a.changes = hub
+	}
+}
+
+// WithChangePublisher - sets publisher of event changes made by the service, changes are published
+// to the service hub by default. Publisher must deliver the changes to the hub by itself.
+func WithChangePublisher(publisher ChangePublisher) Option {
+	return func(a *EventsService) {
+		a.publisher = publisher
+	}
+}
+
+func New(repo EventRepository, opts ...Option) *EventsService {
+	a := &EventsService{repo: repo, firstDayOfWeek: time.Monday}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.changes == nil {
+		a.changes = NewChangeHub()
+	}
+	if a.publisher == nil {
+		a.publisher = a.changes
+	}
+	return a
 }
 
-func (a *EventsService) CreateEvent(ctx context.Context, title string, startTime, endTime time.Time, description, ownerID string) (storage.Event, error) {
+// CreateEvent - creates new event of the user who made the request,
+// event ID is generated by the service so the given one is ignored as well as the given owner.
+func (a *EventsService) CreateEvent(ctx context.Context, event storage.Event) (storage.Event, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	event.OwnerID = userID
+	if err := ValidateEvent(event); err != nil {
+		return storage.Event{}, err
+	}
+	if event, err = a.withSeriesZone(ctx, event, nil); err != nil {
+		return storage.Event{}, err
+	}
+	if event.CalendarID != "" {
+		if err := a.checkCalendars(ctx, userID, "calendar_id", event.CalendarID); err != nil {
+			return storage.Event{}, err
+		}
+	}
 	uuid4, err := uuid.NewV4()
 	if err != nil {
 		return storage.Event{}, fmt.Errorf("error during generation uuid for event id: %w", err)
 	}
-	event := storage.Event{ID: uuid4.String(), Title: title, StartTime: startTime, EndTime: endTime, Description: description, OwnerID: ownerID}
+	event.ID = uuid4.String()
+	event.Version = 1
 	err = a.repo.AddEvent(ctx, event)
 	if err != nil {
 		return storage.Event{}, fmt.Errorf("error during creating event: %w", err)
 	}
+	a.publishChange(ctx, EventChange{Type: ChangeCreated, Event: event})
 	return event, nil
 }
 
-func (a *EventsService) UpdateEvent(ctx context.Context, event storage.Event) error {
-	return a.repo.UpdateEvent(ctx, event)
-}
-
-func (a *EventsService) DeleteEvent(ctx context.Context, eventID string) error {
-	return a.repo.DeleteEvent(ctx, eventID)
+// GetEvent - finds event the user who made the request owns or attends by ID, event times are in the request time zone.
+func (a *EventsService) GetEvent(ctx context.Context, eventID string) (storage.Event, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	event, err := a.findVisibleEvent(ctx, userID, eventID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	loc, err := a.zone(ctx)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return eventsInZone(loc, []storage.Event{event})[0], nil
 }
 
-func (a *EventsService) ListDayEvents(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	intervalStart := startOfDay(date)
-	intervalEnd := endOfDay(date)
-	events, err := a.repo.FindEventsInInterval(ctx, intervalStart, intervalEnd)
+// UpdateEvent - updates event of the user who made the request, events of other users are not found.
+// Editor attendees can update the event as well, its owner and calendar stay the same, ErrForbidden is returned to viewers.
+// event.Version must be the version the update is based on, storage.ErrVersionConflict is returned
+// if the event was changed since then. Updated event with the new version is returned.
+func (a *EventsService) UpdateEvent(ctx context.Context, event storage.Event) (storage.Event, error) {
+	userID, err := UserID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("errod during finding app in day interval: %w", err)
+		return storage.Event{}, err
 	}
-	return events, nil
+	event.OwnerID = userID
+	if err := ValidateEvent(event); err != nil {
+		return storage.Event{}, err
+	}
+	if event.Version <= 0 {
+		return storage.Event{}, &ValidationError{Field: "version", Err: ErrVersionRequired}
+	}
+	// previous state lets watchers notice events moved out of their range
+	previous, err := a.repo.FindEventsByID(ctx, userID, event.ID)
+	if err != nil {
+		return storage.Event{}, fmt.Errorf("error during finding updated event: %w", err)
+	}
+	if len(previous) == 0 {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	if err := a.checkEditor(ctx, previous[0], userID); err != nil {
+		return storage.Event{}, err
+	}
+	if event, err = a.withSeriesZone(ctx, event, &previous[0]); err != nil {
+		return storage.Event{}, err
+	}
+	event.OwnerID = previous[0].OwnerID
+	if event.OwnerID != userID {
+		// calendars of the owner are not known to attendees
+		event.CalendarID = previous[0].CalendarID
+	}
+	if event.CalendarID != "" && event.CalendarID != previous[0].CalendarID {
+		if err := a.checkCalendars(ctx, userID, "calendar_id", event.CalendarID); err != nil {
+			return storage.Event{}, err
+		}
+	}
+	if err := a.repo.UpdateEvent(ctx, event); err != nil {
+		return storage.Event{}, err
+	}
+	event.Version++
+	a.publishChange(ctx, EventChange{
+		Type:       ChangeUpdated,
+		Event:      event,
+		Previous:   &previous[0],
+		Recipients: a.eventRecipients(ctx, event),
+	})
+	return event, nil
 }
 
-func (a *EventsService) ListWeekEvents(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	intervalStart := startOfWeek(date)
-	intervalEnd := endOfWeek(date)
-	events, err := a.repo.FindEventsInInterval(ctx, intervalStart, intervalEnd)
+// DeleteEvent - deletes event of the user who made the request, events of other users are not found.
+// Only the owner can delete the event, ErrForbidden is returned to attendees.
+func (a *EventsService) DeleteEvent(ctx context.Context, eventID string) error {
+	userID, err := UserID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("errod during finding app in week interval: %w", err)
+		return err
+	}
+	// deleted event is sent to watchers, so they know which range it was in
+	deleted, err := a.repo.FindEventsByID(ctx, userID, eventID)
+	if err != nil {
+		return fmt.Errorf("error during finding deleted event: %w", err)
+	}
+	if len(deleted) == 0 {
+		return storage.ErrEventNotFound
 	}
-	return events, nil
+	if deleted[0].OwnerID != userID {
+		return ErrForbidden
+	}
+	// attendees are deleted with the event
+	recipients := a.eventRecipients(ctx, deleted[0])
+	if err := a.repo.DeleteEvent(ctx, userID, eventID); err != nil {
+		return err
+	}
+	a.publishChange(ctx, EventChange{Type: ChangeDeleted, Event: deleted[0], Recipients: recipients})
+	return nil
+}
+
+func (a *EventsService) ListDayEvents(ctx context.Context, date time.Time, calendarIDs ...string) ([]storage.Event, error) {
+	return a.listEvents(ctx, date, "day", calendarIDs, func(date time.Time) (time.Time, time.Time) {
+		return startOfDay(date), endOfDay(date)
+	})
+}
+
+func (a *EventsService) ListWeekEvents(ctx context.Context, date time.Time, calendarIDs ...string) ([]storage.Event, error) {
+	return a.listEvents(ctx, date, "week", calendarIDs, func(date time.Time) (time.Time, time.Time) {
+		return startOfWeek(date, a.firstDayOfWeek), endOfWeek(date, a.firstDayOfWeek)
+	})
+}
+
+func (a *EventsService) ListMonthEvents(ctx context.Context, date time.Time, calendarIDs ...string) ([]storage.Event, error) {
+	return a.listEvents(ctx, date, "month", calendarIDs, func(date time.Time) (time.Time, time.Time) {
+		return startOfMonth(date), endOfMonth(date)
+	})
 }
 
-func (a *EventsService) ListMonthEvents(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	intervalStart := startOfMonth(date)
-	intervalEnd := endOfMonth(date)
-	events, err := a.repo.FindEventsInInterval(ctx, intervalStart, intervalEnd)
+// listEvents - lists events the user who made the request owns or attends within the interval the date is in,
+// interval bounds and event times are in the request time zone.
+// If calendar IDs are given, only events of these calendars of the user are listed,
+// interval bounds are in time zone of the calendars then if the client didn't pass its one (see Location).
+func (a *EventsService) listEvents(
+	ctx context.Context,
+	date time.Time,
+	intervalName string,
+	calendarIDs []string,
+	interval func(date time.Time) (time.Time, time.Time),
+) ([]storage.Event, error) {
+	userID, err := UserID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("errod during finding app in month interval: %w", err)
+		return nil, err
 	}
-	return events, nil
+	calendars, err := a.findCalendars(ctx, userID, "calendar_ids", calendarIDs...)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := a.calendarsZone(ctx, calendars)
+	if err != nil {
+		return nil, err
+	}
+	intervalStart, intervalEnd := interval(inZone(loc, date))
+	events, err := a.repo.FindEventsInInterval(ctx, userID, intervalStart, intervalEnd, calendarIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("errod during finding app in %s interval: %w", intervalName, err)
+	}
+	return eventsInZone(loc, events), nil
 }
 
 func startOfDay(t time.Time) time.Time {
@@ -86,12 +303,16 @@ func endOfDay(t time.Time) time.Time {
 	return time.Date(y, m, d, 23, 59, 59, int(time.Second-time.Nanosecond), t.Location())
 }
 
-func startOfWeek(t time.Time) time.Time {
-	return t.Truncate(24 * 7 * time.Hour)
+// startOfWeek - returns midnight of the first week day in t location.
+// Week is counted in calendar days rather than 24h durations, so DST transitions don't shift it.
+func startOfWeek(t time.Time, firstDay time.Weekday) time.Time {
+	daysSinceStart := (int(t.Weekday()) - int(firstDay) + 7) % 7
+	y, m, d := t.Date()
+	return time.Date(y, m, d-daysSinceStart, 0, 0, 0, 0, t.Location())
 }
 
-func endOfWeek(t time.Time) time.Time {
-	return startOfWeek(t).AddDate(0, 0, 7).Add(-time.Nanosecond)
+func endOfWeek(t time.Time, firstDay time.Weekday) time.Time {
+	return startOfWeek(t, firstDay).AddDate(0, 0, 7).Add(-time.Nanosecond)
 }
 
 func startOfMonth(t time.Time) time.Time {
@@ -0,0 +1,260 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestWeekBoundaries(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	berlin := loadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name          string
+		date          time.Time
+		firstDay      time.Weekday
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{
+			name:          "monday week in the middle",
+			date:          time.Date(2021, time.September, 8, 15, 0, 0, 0, time.UTC),
+			firstDay:      time.Monday,
+			expectedStart: time.Date(2021, time.September, 6, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.September, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "first day itself",
+			date:          time.Date(2021, time.September, 5, 0, 0, 0, 0, time.UTC),
+			firstDay:      time.Sunday,
+			expectedStart: time.Date(2021, time.September, 5, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.September, 12, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "sunday is the last day of monday week",
+			date:          time.Date(2021, time.September, 12, 23, 59, 0, 0, time.UTC),
+			firstDay:      time.Monday,
+			expectedStart: time.Date(2021, time.September, 6, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.September, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "year boundary with monday week",
+			date:          time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC),
+			firstDay:      time.Monday,
+			expectedStart: time.Date(2020, time.December, 28, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "year boundary with sunday week",
+			date:          time.Date(2020, time.December, 31, 12, 0, 0, 0, time.UTC),
+			firstDay:      time.Sunday,
+			expectedStart: time.Date(2020, time.December, 27, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "spring DST transition",
+			date:          time.Date(2021, time.March, 16, 9, 0, 0, 0, newYork),
+			firstDay:      time.Sunday,
+			expectedStart: time.Date(2021, time.March, 14, 0, 0, 0, 0, newYork),
+			expectedEnd:   time.Date(2021, time.March, 21, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:          "autumn DST transition",
+			date:          time.Date(2021, time.October, 31, 12, 0, 0, 0, berlin),
+			firstDay:      time.Monday,
+			expectedStart: time.Date(2021, time.October, 25, 0, 0, 0, 0, berlin),
+			expectedEnd:   time.Date(2021, time.November, 1, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			start := startOfWeek(tc.date, tc.firstDay)
+			end := endOfWeek(tc.date, tc.firstDay)
+			require.True(t, tc.expectedStart.Equal(start), "start: %s", start)
+			require.True(t, tc.expectedEnd.Add(-time.Nanosecond).Equal(end), "end: %s", end)
+			require.Equal(t, tc.firstDay, start.Weekday())
+		})
+	}
+}
+
+func TestListWeekEventsInLocation(t *testing.T) {
+	moscow := loadLocation(t, "Europe/Moscow")
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage(), WithFirstDayOfWeek(time.Sunday), WithLocation(moscow))
+
+	// Sunday 01:00 in Moscow is still Saturday in UTC
+	sunday := time.Date(2021, time.September, 5, 1, 0, 0, 0, moscow)
+	event, err := service.CreateEvent(ctx, storage.Event{
+		Title:     "Breakfast",
+		StartTime: sunday,
+		EndTime:   sunday.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	events, err := service.ListWeekEvents(ctx, time.Date(2021, time.September, 8, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, event.ID, events[0].ID)
+
+	// the previous Moscow week
+	events, err = service.ListWeekEvents(ctx, time.Date(2021, time.September, 4, 20, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestListDayEventsInRequestTimeZone(t *testing.T) {
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+
+	// 2021-09-07 08:30 in Tokyo
+	start := time.Date(2021, time.September, 6, 23, 30, 0, 0, time.UTC)
+	_, err := service.CreateEvent(ctx, storage.Event{Title: "Call", StartTime: start, EndTime: start.Add(15 * time.Minute)})
+	require.NoError(t, err)
+
+	day := time.Date(2021, time.September, 7, 0, 0, 0, 0, time.UTC)
+	events, err := service.ListDayEvents(ctx, day)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	tokyoCtx := WithTimeZone(ctx, tokyo)
+	loc, err := service.Location(tokyoCtx)
+	require.NoError(t, err)
+	require.Equal(t, tokyo, loc)
+	events, err = service.ListDayEvents(tokyoCtx, day)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	// event times are returned with the request time zone offset
+	require.Equal(t, tokyo, events[0].StartTime.Location())
+	require.Equal(t, "2021-09-07T08:30:00+09:00", events[0].StartTime.Format(time.RFC3339))
+}
+
+func TestLocation(t *testing.T) {
+	moscow := loadLocation(t, "Europe/Moscow")
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	ctx := context.Background()
+
+	// server local time zone is not used as a fallback
+	_, err := New(memorystorage.NewMemStorage()).Location(ctx)
+	require.ErrorIs(t, err, ErrTimeZoneRequired)
+	require.True(t, IsValidationError(err))
+
+	service := New(memorystorage.NewMemStorage(), WithLocation(moscow))
+	loc, err := service.Location(ctx)
+	require.NoError(t, err)
+	require.Equal(t, moscow, loc)
+	loc, err = service.Location(WithTimeZone(ctx, tokyo))
+	require.NoError(t, err)
+	require.Equal(t, tokyo, loc)
+}
+
+func TestSeriesTimeZone(t *testing.T) {
+	berlin := loadLocation(t, "Europe/Berlin")
+	ctx := WithUserID(context.Background(), "owner")
+	start := time.Date(2021, time.October, 18, 0, 30, 0, 0, berlin)
+	series := storage.Event{
+		Title:          "Standup",
+		StartTime:      start.UTC(),
+		EndTime:        start.Add(time.Hour).UTC(),
+		RecurrenceRule: "FREQ=WEEKLY;BYDAY=MO",
+	}
+
+	_, err := New(memorystorage.NewMemStorage()).CreateEvent(ctx, series)
+	require.ErrorIs(t, err, ErrTimeZoneRequired)
+
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	created, err := service.CreateEvent(WithTimeZone(ctx, berlin), series)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Berlin", created.TimeZone)
+	// the series keeps its time zone whatever time zone the update request is made in
+	created.Title = "Weekly standup"
+	updated, err := service.UpdateEvent(ctx, created)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Berlin", updated.TimeZone)
+
+	// occurrences after DST end are still on Monday at 00:30 in Berlin
+	events, err := service.ListMonthEvents(WithTimeZone(ctx, berlin), time.Date(2021, time.November, 1, 0, 0, 0, 0, berlin))
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+	for _, event := range events {
+		local := event.StartTime.In(berlin)
+		require.Equal(t, time.Monday, local.Weekday(), local)
+		require.Equal(t, 0, local.Hour(), local)
+		require.Equal(t, 30, local.Minute(), local)
+	}
+
+	series.TimeZone = "Mars/Olympus"
+	_, err = service.CreateEvent(ctx, series)
+	require.ErrorIs(t, err, ErrUnknownTimeZone)
+}
+
+func TestLoadTimeZone(t *testing.T) {
+	loc, err := LoadTimeZone("Europe/Moscow")
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Moscow", loc.String())
+
+	for _, name := range []string{"", "Local", "Mars/Olympus"} {
+		_, err := LoadTimeZone(name)
+		require.ErrorIs(t, err, ErrUnknownTimeZone, name)
+		require.True(t, IsValidationError(err))
+	}
+}
+
+func TestUpdateEventVersion(t *testing.T) {
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage())
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	event, err := service.CreateEvent(ctx, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), event.Version)
+
+	// two clients edit the same version, the second one loses
+	first, second := event, event
+	first.Title = "Daily standup"
+	updated, err := service.UpdateEvent(ctx, first)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), updated.Version)
+	second.Description = "in the meeting room"
+	_, err = service.UpdateEvent(ctx, second)
+	require.ErrorIs(t, err, storage.ErrVersionConflict)
+
+	found, err := service.repo.FindEventsByID(ctx, "owner", event.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated, found[0])
+
+	second.Version = 0
+	_, err = service.UpdateEvent(ctx, second)
+	require.ErrorIs(t, err, ErrVersionRequired)
+}
+
+func TestGetEvent(t *testing.T) {
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	event, err := service.CreateEvent(ctx, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	found, err := service.GetEvent(WithTimeZone(ctx, tokyo), event.ID)
+	require.NoError(t, err)
+	require.True(t, event.IsEqual(found))
+	require.Equal(t, tokyo, found.StartTime.Location())
+
+	// events of other users are not found
+	_, err = service.GetEvent(WithUserID(context.Background(), "other"), event.ID)
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	// MaxFreeBusyUsers - max number of users of a single free/busy query.
+	MaxFreeBusyUsers = 50
+	// MaxFreeBusyRange - max length of free/busy query range, recurring events are expanded within it.
+	MaxFreeBusyRange = 366 * 24 * time.Hour
+)
+
+var (
+	ErrNoUsers         = errors.New("user ids are missing")
+	ErrTooManyUsers    = fmt.Errorf("more than %d users are requested", MaxFreeBusyUsers)
+	ErrRangeTooLong    = fmt.Errorf("range is longer than %s", MaxFreeBusyRange)
+	ErrInvalidDuration = errors.New("duration is not positive")
+	ErrNoFreeSlot      = errors.New("no common free slot in range")
+)
+
+// TimeInterval - [Start, End) interval.
+type TimeInterval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// UserBusy - busy intervals of the user ordered by start time.
+type UserBusy struct {
+	UserID string         `json:"user_id"`
+	Busy   []TimeInterval `json:"busy"`
+}
+
+// FreeBusy - returns busy intervals of every user within [from, to) range in order of user IDs.
+// Events the user owns or attends (except declined ones) make the user busy, overlapping events are merged
+// into a single interval, events which only touch each other are not. Intervals are cut by the range bounds
+// and their times are in the request time zone.
+func (a *EventsService) FreeBusy(ctx context.Context, userIDs []string, from, to time.Time) ([]UserBusy, error) {
+	if _, err := UserID(ctx); err != nil {
+		return nil, err
+	}
+	userIDs = uniqueStrings(userIDs)
+	switch {
+	case len(userIDs) == 0:
+		return nil, &ValidationError{Field: "user_ids", Err: ErrNoUsers}
+	case len(userIDs) > MaxFreeBusyUsers:
+		return nil, &ValidationError{Field: "user_ids", Err: ErrTooManyUsers}
+	case to.Before(from):
+		return nil, &ValidationError{Field: "to", Err: ErrInvalidRange}
+	case to.Sub(from) > MaxFreeBusyRange:
+		return nil, &ValidationError{Field: "to", Err: ErrRangeTooLong}
+	}
+
+	loc, err := a.zone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]UserBusy, 0, len(userIDs))
+	for _, userID := range userIDs {
+		events, err := a.repo.FindEventsInInterval(ctx, userID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("error during finding events of user %s: %w", userID, err)
+		}
+		intervals := make([]TimeInterval, 0, len(events))
+		for _, event := range events {
+			intervals = append(intervals, TimeInterval{Start: event.StartTime, End: event.EndTime})
+		}
+		busy := mergeIntervals(intervals)
+		for i := range busy {
+			busy[i] = intervalInZone(loc, clipInterval(busy[i], from, to))
+		}
+		result = append(result, UserBusy{UserID: userID, Busy: busy})
+	}
+	return result, nil
+}
+
+// FindFreeSlot - finds the earliest interval of the duration within [from, to) range when all the users are free,
+// ErrNoFreeSlot is returned if there is no such interval.
+func (a *EventsService) FindFreeSlot(
+	ctx context.Context,
+	userIDs []string,
+	from, to time.Time,
+	duration time.Duration,
+) (TimeInterval, error) {
+	if duration <= 0 {
+		return TimeInterval{}, &ValidationError{Field: "duration", Err: ErrInvalidDuration}
+	}
+	usersBusy, err := a.FreeBusy(ctx, userIDs, from, to)
+	if err != nil {
+		return TimeInterval{}, err
+	}
+	var intervals []TimeInterval
+	for _, userBusy := range usersBusy {
+		intervals = append(intervals, userBusy.Busy...)
+	}
+
+	slotStart := from
+	for _, busy := range mergeIntervals(intervals) {
+		if busy.Start.Sub(slotStart) >= duration {
+			break
+		}
+		if busy.End.After(slotStart) {
+			slotStart = busy.End
+		}
+	}
+	if to.Sub(slotStart) < duration {
+		return TimeInterval{}, ErrNoFreeSlot
+	}
+	loc, err := a.zone(ctx)
+	if err != nil {
+		return TimeInterval{}, err
+	}
+	return intervalInZone(loc, TimeInterval{Start: slotStart, End: slotStart.Add(duration)}), nil
+}
+
+// mergeIntervals - sorts intervals by start time and merges overlapping ones,
+// the overlap is the same as the one of events found in time interval (see storage.IsEventInsideTimeInterval).
+func mergeIntervals(intervals []TimeInterval) []TimeInterval {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+	merged := make([]TimeInterval, 0, len(intervals))
+	for _, interval := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && storage.IsEventInsideTimeInterval(merged[last].Start, merged[last].End, interval.Start, interval.End) {
+			if interval.End.After(merged[last].End) {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+func clipInterval(interval TimeInterval, from, to time.Time) TimeInterval {
+	if interval.Start.Before(from) {
+		interval.Start = from
+	}
+	if interval.End.After(to) {
+		interval.End = to
+	}
+	return interval
+}
+
+func intervalInZone(loc *time.Location, interval TimeInterval) TimeInterval {
+	return TimeInterval{Start: inZone(loc, interval.Start), End: inZone(loc, interval.End)}
+}
+
+// uniqueStrings - removes empty values and duplicates keeping order of the first occurrences.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
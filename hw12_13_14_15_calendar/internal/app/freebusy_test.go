@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeBusy(t *testing.T) {
+	alice := WithUserID(context.Background(), "alice")
+	bob := WithUserID(context.Background(), "bob")
+	carol := WithUserID(context.Background(), "carol")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	day := time.Date(2021, time.September, 6, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	create := func(ctx context.Context, title string, start, end time.Time) storage.Event {
+		event, err := service.CreateEvent(ctx, storage.Event{Title: title, StartTime: start, EndTime: end})
+		require.NoError(t, err)
+		return event
+	}
+
+	create(alice, "Standup", at(10, 0), at(11, 0))
+	review := create(bob, "Review", at(10, 30), at(12, 0))
+	create(bob, "Lunch", at(12, 0), at(13, 0))
+	create(bob, "Gym", at(16, 0), at(18, 0))
+	party := create(carol, "Party", at(14, 0), at(15, 0))
+	// invitations make the user busy until they are declined
+	_, err := service.InviteAttendee(bob, review.ID, "alice", storage.AttendeeRoleViewer)
+	require.NoError(t, err)
+	_, err = service.InviteAttendee(carol, party.ID, "alice", storage.AttendeeRoleViewer)
+	require.NoError(t, err)
+	_, err = service.RespondToInvitation(alice, party.ID, storage.RSVPDeclined)
+	require.NoError(t, err)
+
+	busy, err := service.FreeBusy(carol, []string{"alice", "bob", "alice", ""}, at(9, 0), at(17, 0))
+	require.NoError(t, err)
+	require.Equal(t, []UserBusy{
+		// overlapping events are merged
+		{UserID: "alice", Busy: []TimeInterval{{Start: at(10, 0), End: at(12, 0)}}},
+		// touching events are not, intervals are cut by the range
+		{UserID: "bob", Busy: []TimeInterval{
+			{Start: at(10, 30), End: at(12, 0)},
+			{Start: at(12, 0), End: at(13, 0)},
+			{Start: at(16, 0), End: at(17, 0)},
+		}},
+	}, busy)
+
+	t.Run("free slot", func(t *testing.T) {
+		users := []string{"alice", "bob"}
+		slot, err := service.FindFreeSlot(carol, users, at(9, 0), at(17, 0), time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, TimeInterval{Start: at(9, 0), End: at(10, 0)}, slot)
+		slot, err = service.FindFreeSlot(carol, users, at(9, 0), at(17, 0), 2*time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, TimeInterval{Start: at(13, 0), End: at(15, 0)}, slot)
+		_, err = service.FindFreeSlot(carol, users, at(9, 0), at(17, 0), 4*time.Hour)
+		require.ErrorIs(t, err, ErrNoFreeSlot)
+		_, err = service.FindFreeSlot(carol, users, at(9, 0), at(17, 0), 0)
+		require.ErrorIs(t, err, ErrInvalidDuration)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := service.FreeBusy(context.Background(), []string{"alice"}, at(9, 0), at(17, 0))
+		require.ErrorIs(t, err, ErrUnauthenticated)
+		_, err = service.FreeBusy(carol, []string{""}, at(9, 0), at(17, 0))
+		require.ErrorIs(t, err, ErrNoUsers)
+		_, err = service.FreeBusy(carol, []string{"alice"}, at(17, 0), at(9, 0))
+		require.ErrorIs(t, err, ErrInvalidRange)
+		_, err = service.FreeBusy(carol, []string{"alice"}, day, day.Add(MaxFreeBusyRange+time.Hour))
+		require.ErrorIs(t, err, ErrRangeTooLong)
+	})
+}
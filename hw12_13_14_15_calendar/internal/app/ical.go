@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/ical"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/gofrs/uuid"
+)
+
+var (
+	ErrAnotherOwner    = errors.New("event belongs to another owner")
+	ErrInvalidRange    = errors.New("range end is before range start")
+	importUIDNamespace = uuid.Must(uuid.FromString("6f1c2a58-9e0b-4b53-a7a4-3d2f3c9b8e41"))
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusCreated ImportStatus = "created"
+	ImportStatusUpdated ImportStatus = "updated"
+	ImportStatusFailed  ImportStatus = "failed"
+)
+
+// ImportItemResult - import result of a single VEVENT.
+type ImportItemResult struct {
+	UID     string       `json:"uid"`
+	EventID string       `json:"event_id,omitempty"`
+	Status  ImportStatus `json:"status"`
+	Error   string       `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Items   []ImportItemResult `json:"items"`
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Failed  int                `json:"failed"`
+}
+
+func (r *ImportReport) add(item ImportItemResult) {
+	r.Items = append(r.Items, item)
+	switch item.Status {
+	case ImportStatusCreated:
+		r.Created++
+	case ImportStatusUpdated:
+		r.Updated++
+	case ImportStatusFailed:
+		r.Failed++
+	}
+}
+
+// ExportEvents - writes events of the user who made the request overlapping [from, to] range as iCalendar.
+// Recurring events are exported as whole series with their recurrence rules.
+func (a *EventsService) ExportEvents(ctx context.Context, from, to time.Time, w io.Writer) error {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return err
+	}
+	if to.Before(from) {
+		return &ValidationError{Field: "to", Err: ErrInvalidRange}
+	}
+	occurrences, err := a.repo.FindEventsInInterval(ctx, userID, from, to)
+	if err != nil {
+		return fmt.Errorf("error during finding events to export: %w", err)
+	}
+
+	var events []storage.Event
+	var seriesIDs []string
+	exported := make(map[string]bool)
+	for _, occurrence := range occurrences {
+		if exported[occurrence.ID] {
+			continue
+		}
+		exported[occurrence.ID] = true
+		if occurrence.IsRecurring() {
+			seriesIDs = append(seriesIDs, occurrence.ID)
+			continue
+		}
+		events = append(events, occurrence)
+	}
+	if len(seriesIDs) > 0 {
+		series, err := a.repo.FindEventsByID(ctx, userID, seriesIDs...)
+		if err != nil {
+			return fmt.Errorf("error during finding recurring events to export: %w", err)
+		}
+		events = append(events, series...)
+	}
+
+	return ical.Encode(w, events)
+}
+
+// ImportEvents - creates or updates events of the user who made the request from iCalendar.
+// VEVENT UID is mapped onto event ID of the user, so importing the same calendar again updates previously imported events.
+// Floating times are in the request time zone (see Location), the import is rejected if it is not set.
+// Error is returned only if the calendar could not be read, results of every VEVENT are in the report.
+func (a *EventsService) ImportEvents(ctx context.Context, r io.Reader) (ImportReport, error) {
+	var report ImportReport
+	userID, err := UserID(ctx)
+	if err != nil {
+		return report, err
+	}
+	loc, err := a.Location(ctx)
+	if err != nil {
+		return report, err
+	}
+	items, err := ical.Decode(r, loc)
+	if err != nil {
+		return report, fmt.Errorf("error during calendar decoding: %w", err)
+	}
+	for _, item := range items {
+		result := ImportItemResult{UID: item.UID}
+		if item.Err != nil {
+			result.Status = ImportStatusFailed
+			result.Error = item.Err.Error()
+			report.add(result)
+			continue
+		}
+		event := item.Event
+		event.OwnerID = userID
+		result.EventID, result.Status, err = a.importEvent(ctx, item.UID, event)
+		if err != nil {
+			result.Status = ImportStatusFailed
+			result.Error = err.Error()
+		}
+		report.add(result)
+	}
+	return report, nil
+}
+
+// importEvent - creates or updates event of its owner with the UID, ID of the event is returned.
+func (a *EventsService) importEvent(ctx context.Context, uid string, event storage.Event) (string, ImportStatus, error) {
+	if err := ValidateEvent(event); err != nil {
+		return "", ImportStatusFailed, err
+	}
+	ids := eventIDsFromUID(event.OwnerID, uid)
+	found, err := a.repo.FindEventsByID(ctx, event.OwnerID, ids...)
+	if err != nil {
+		return "", ImportStatusFailed, fmt.Errorf("error during finding imported event: %w", err)
+	}
+	// attended events are found as well, but they are not overridden by the attendee calendar
+	previous := ownedEvent(found, event.OwnerID, ids)
+	event.ID = ids[len(ids)-1]
+	if previous != nil {
+		event.ID = previous.ID
+	}
+	zoned, err := a.withSeriesZone(ctx, event, previous)
+	if err != nil {
+		return event.ID, ImportStatusFailed, err
+	}
+	event = zoned
+	if previous == nil {
+		event.Version = 1
+		err := a.repo.AddEvent(ctx, event)
+		if errors.Is(err, storage.ErrEventAlreadyExists) {
+			// event with the same ID is not found among owner events, so it belongs to another owner
+			return event.ID, ImportStatusFailed, ErrAnotherOwner
+		}
+		if err != nil {
+			return event.ID, ImportStatusFailed, fmt.Errorf("error during creating event: %w", err)
+		}
+		a.publishChange(ctx, EventChange{Type: ChangeCreated, Event: event})
+		return event.ID, ImportStatusCreated, nil
+	}
+	// notification settings and calendar are not a part of iCalendar event, so they are kept
+	event.NotifyBefore = previous.NotifyBefore
+	event.CalendarID = previous.CalendarID
+	// imported calendar overrides the current event state
+	event.Version = previous.Version
+	if err := a.repo.UpdateEvent(ctx, event); err != nil {
+		return event.ID, ImportStatusFailed, fmt.Errorf("error during updating event: %w", err)
+	}
+	event.Version++
+	a.publishChange(ctx, EventChange{
+		Type:       ChangeUpdated,
+		Event:      event,
+		Previous:   previous,
+		Recipients: a.eventRecipients(ctx, event),
+	})
+	return event.ID, ImportStatusUpdated, nil
+}
+
+// eventIDsFromUID - returns IDs the event with the UID may be imported under, the preferred one goes first.
+// UID of events exported by this service is already an event ID, it is used if the owner has such event.
+// Otherwise the UID is mapped onto name based UUID of the owner, so the same calendar imported by
+// different users creates events of each of them.
+func eventIDsFromUID(ownerID, uid string) []string {
+	derived := uuid.NewV5(importUIDNamespace, ownerID+"\x00"+uid).String()
+	if id, err := uuid.FromString(uid); err == nil {
+		return []string{id.String(), derived}
+	}
+	return []string{derived}
+}
+
+// ownedEvent - returns the first event of the owner with one of the IDs in their order, nil if there is none.
+func ownedEvent(events []storage.Event, ownerID string, ids []string) *storage.Event {
+	for _, id := range ids {
+		for i := range events {
+			if events[i].ID == id && events[i].OwnerID == ownerID {
+				return &events[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportEvents(t *testing.T) {
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	alice := WithUserID(context.Background(), "alice")
+	bob := WithUserID(context.Background(), "bob")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"SUMMARY:Standup",
+		"DTSTART:20210906T100000",
+		"DURATION:PT15M",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	// the same calendar imported by different users creates events of each of them
+	aliceReport, err := service.ImportEvents(alice, strings.NewReader(calendar))
+	require.NoError(t, err)
+	require.Equal(t, 1, aliceReport.Created)
+	bobReport, err := service.ImportEvents(WithTimeZone(bob, tokyo), strings.NewReader(calendar))
+	require.NoError(t, err)
+	require.Equal(t, 1, bobReport.Created, bobReport.Items)
+	require.NotEqual(t, aliceReport.Items[0].EventID, bobReport.Items[0].EventID)
+
+	// floating times are in the request time zone
+	aliceEvent, err := service.GetEvent(alice, aliceReport.Items[0].EventID)
+	require.NoError(t, err)
+	require.True(t, time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC).Equal(aliceEvent.StartTime))
+	bobEvent, err := service.GetEvent(bob, bobReport.Items[0].EventID)
+	require.NoError(t, err)
+	require.True(t, time.Date(2021, time.September, 6, 10, 0, 0, 0, tokyo).Equal(bobEvent.StartTime))
+
+	report, err := service.ImportEvents(alice, strings.NewReader(calendar))
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Updated)
+	require.Equal(t, aliceReport.Items[0].EventID, report.Items[0].EventID)
+
+	// exported event is updated by its owner and copied by another user
+	var exported bytes.Buffer
+	from := time.Date(2021, time.September, 6, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, service.ExportEvents(alice, from, from.AddDate(0, 0, 1), &exported))
+	report, err = service.ImportEvents(alice, bytes.NewReader(exported.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Updated)
+	require.Equal(t, aliceEvent.ID, report.Items[0].EventID)
+	report, err = service.ImportEvents(bob, bytes.NewReader(exported.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Created, report.Items)
+	require.NotEqual(t, aliceEvent.ID, report.Items[0].EventID)
+
+	// floating times can't be interpreted without time zone
+	_, err = New(memorystorage.NewMemStorage()).ImportEvents(alice, strings.NewReader(calendar))
+	require.ErrorIs(t, err, ErrTimeZoneRequired)
+	events, err := service.ListDayEvents(alice, from)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, aliceEvent.ID, events[0].ID)
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	// DefaultPageLimit - page size used if limit is not set.
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+var (
+	ErrInvalidPageToken = errors.New("page token is malformed")
+	ErrInvalidLimit     = fmt.Errorf("limit is not in range [0, %d]", MaxPageLimit)
+)
+
+// EventFilter - optional conditions of ListEvents, zero filter matches all events.
+type EventFilter struct {
+	// Text - case insensitive substring of event title or description
+	Text string
+}
+
+func (f EventFilter) matches(event storage.Event) bool {
+	if f.Text == "" {
+		return true
+	}
+	text := strings.ToLower(f.Text)
+	return strings.Contains(strings.ToLower(event.Title), text) ||
+		strings.Contains(strings.ToLower(event.Description), text)
+}
+
+type EventsPage struct {
+	Events []storage.Event `json:"events"`
+	// NextPageToken - token of the next page, empty if the page is the last one
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+// pageCursor - position of the last event of the page, events are ordered by start time and then by ID,
+// so the cursor stays valid even if events are added or deleted between page requests.
+type pageCursor struct {
+	startTime time.Time
+	eventID   string
+}
+
+// ListEvents - lists events the user who made the request owns or attends overlapping [from, to) range page by page.
+// Recurring events are expanded into occurrences, event times are in the request time zone.
+// Empty page token means the first page, limit equal to zero means DefaultPageLimit.
+func (a *EventsService) ListEvents(
+	ctx context.Context,
+	from, to time.Time,
+	filter EventFilter,
+	pageToken string,
+	limit int,
+) (EventsPage, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return EventsPage{}, err
+	}
+	if to.Before(from) {
+		return EventsPage{}, &ValidationError{Field: "to", Err: ErrInvalidRange}
+	}
+	if limit < 0 || limit > MaxPageLimit {
+		return EventsPage{}, &ValidationError{Field: "limit", Err: ErrInvalidLimit}
+	}
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+	var cursor *pageCursor
+	if pageToken != "" {
+		if cursor, err = decodePageToken(pageToken); err != nil {
+			return EventsPage{}, &ValidationError{Field: "page_token", Err: err}
+		}
+	}
+
+	events, err := a.repo.FindEventsInInterval(ctx, userID, from, to)
+	if err != nil {
+		return EventsPage{}, fmt.Errorf("error during finding events in interval: %w", err)
+	}
+	matched := make([]storage.Event, 0, len(events))
+	for _, event := range events {
+		if filter.matches(event) {
+			matched = append(matched, event)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return eventLess(matched[i], matched[j])
+	})
+
+	first := 0
+	if cursor != nil {
+		first = sort.Search(len(matched), func(i int) bool {
+			return cursor.before(matched[i])
+		})
+	}
+	loc, err := a.zone(ctx)
+	if err != nil {
+		return EventsPage{}, err
+	}
+	last := first + limit
+	if last >= len(matched) {
+		return EventsPage{Events: eventsInZone(loc, matched[first:])}, nil
+	}
+	page := matched[first:last]
+	return EventsPage{Events: eventsInZone(loc, page), NextPageToken: encodePageToken(page[len(page)-1])}, nil
+}
+
+func eventLess(e1, e2 storage.Event) bool {
+	if !e1.StartTime.Equal(e2.StartTime) {
+		return e1.StartTime.Before(e2.StartTime)
+	}
+	return e1.ID < e2.ID
+}
+
+// before - checks whether the event is after the cursor position.
+func (c pageCursor) before(event storage.Event) bool {
+	return eventLess(storage.Event{ID: c.eventID, StartTime: c.startTime}, event)
+}
+
+// encodePageToken - page token is an opaque string for clients: base64 encoded "<start unix nanos>/<event id>".
+func encodePageToken(event storage.Event) string {
+	token := strconv.FormatInt(event.StartTime.UnixNano(), 10) + "/" + event.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+func decodePageToken(pageToken string) (*pageCursor, error) {
+	token, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	parts := strings.SplitN(string(token), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidPageToken
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	return &pageCursor{startTime: time.Unix(0, nanos), eventID: parts[1]}, nil
+}
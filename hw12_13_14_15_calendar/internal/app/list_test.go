@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEvents(t *testing.T) {
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+
+	add := func(title string, startTime time.Time, rule string) storage.Event {
+		event, err := service.CreateEvent(ctx, storage.Event{
+			Title:          title,
+			StartTime:      startTime,
+			EndTime:        startTime.Add(30 * time.Minute),
+			RecurrenceRule: rule,
+		})
+		require.NoError(t, err)
+		return event
+	}
+	add("Standup", start, "FREQ=DAILY;COUNT=5")
+	add("Lunch", start.Add(2*time.Hour), "")
+	add("Retro", start.AddDate(0, 0, 4).Add(time.Hour), "")
+	add("Review", start.AddDate(0, 0, 2).Add(3*time.Hour), "")
+	// event of another user
+	_, err := service.CreateEvent(WithUserID(context.Background(), "another owner"), storage.Event{
+		Title:     "Standup",
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+	})
+	require.NoError(t, err)
+
+	from, to := start.AddDate(0, 0, -1), start.AddDate(0, 0, 7)
+	all, err := service.ListEvents(ctx, from, to, EventFilter{}, "", 0)
+	require.NoError(t, err)
+	require.Len(t, all.Events, 8)
+	require.Empty(t, all.NextPageToken)
+	for i := 1; i < len(all.Events); i++ {
+		require.True(t, eventLess(all.Events[i-1], all.Events[i]), "events must be ordered")
+	}
+
+	var paged []storage.Event
+	pageToken := ""
+	for pages := 1; ; pages++ {
+		page, err := service.ListEvents(ctx, from, to, EventFilter{}, pageToken, 2)
+		require.NoError(t, err)
+		paged = append(paged, page.Events...)
+		if page.NextPageToken == "" {
+			require.Equal(t, 4, pages)
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	require.Equal(t, all.Events, paged)
+
+	filtered, err := service.ListEvents(ctx, from, to, EventFilter{Text: "STAND"}, "", 2)
+	require.NoError(t, err)
+	require.Len(t, filtered.Events, 2)
+	require.Equal(t, "Standup", filtered.Events[1].Title)
+	require.NotEmpty(t, filtered.NextPageToken)
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := service.ListEvents(ctx, to, from, EventFilter{}, "", 0)
+		require.ErrorIs(t, err, ErrInvalidRange)
+		_, err = service.ListEvents(ctx, from, to, EventFilter{}, "", MaxPageLimit+1)
+		require.ErrorIs(t, err, ErrInvalidLimit)
+		_, err = service.ListEvents(ctx, from, to, EventFilter{}, "not a token", 0)
+		require.ErrorIs(t, err, ErrInvalidPageToken)
+		require.True(t, IsValidationError(err))
+		_, err = service.ListEvents(context.Background(), from, to, EventFilter{}, "", 0)
+		require.ErrorIs(t, err, ErrUnauthenticated)
+	})
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+// patchAttempts - number of read-modify-write attempts of the patch not bound to the event version.
+const patchAttempts = 3
+
+// EventPatch - partial event update, nil fields are kept as is.
+type EventPatch struct {
+	Title          *string
+	Description    *string
+	StartTime      *time.Time
+	EndTime        *time.Time
+	NotifyBefore   *time.Duration
+	RecurrenceRule *string
+	ExceptionDates *storage.ExceptionDates
+	CalendarID     *string
+	TimeZone       *string
+}
+
+func (p EventPatch) apply(event storage.Event) storage.Event {
+	if p.Title != nil {
+		event.Title = *p.Title
+	}
+	if p.Description != nil {
+		event.Description = *p.Description
+	}
+	if p.StartTime != nil {
+		event.StartTime = *p.StartTime
+	}
+	if p.EndTime != nil {
+		event.EndTime = *p.EndTime
+	}
+	if p.NotifyBefore != nil {
+		event.NotifyBefore = *p.NotifyBefore
+	}
+	if p.RecurrenceRule != nil {
+		event.RecurrenceRule = *p.RecurrenceRule
+	}
+	if p.ExceptionDates != nil {
+		event.ExceptionDates = *p.ExceptionDates
+	}
+	if p.CalendarID != nil {
+		event.CalendarID = *p.CalendarID
+	}
+	if p.TimeZone != nil {
+		event.TimeZone = *p.TimeZone
+	}
+	return event
+}
+
+// PatchEvent - updates only the patch fields of the event of the user who made the request.
+// Non zero version must be the current event version, storage.ErrVersionConflict is returned otherwise.
+// Zero version means the patch is applied to the current event state, it is reapplied
+// if the event is changed concurrently. Updated event with the new version is returned.
+func (a *EventsService) PatchEvent(ctx context.Context, eventID string, patch EventPatch, version int64) (storage.Event, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	for attempt := 1; ; attempt++ {
+		found, err := a.repo.FindEventsByID(ctx, userID, eventID)
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("error during finding patched event: %w", err)
+		}
+		if len(found) == 0 {
+			return storage.Event{}, storage.ErrEventNotFound
+		}
+		current := found[0]
+		if version != 0 && version != current.Version {
+			return storage.Event{}, storage.ErrVersionConflict
+		}
+		updated, err := a.UpdateEvent(ctx, patch.apply(current))
+		if version == 0 && attempt < patchAttempts && errors.Is(err, storage.ErrVersionConflict) {
+			continue
+		}
+		return updated, err
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchEvent(t *testing.T) {
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	event, err := service.CreateEvent(ctx, storage.Event{
+		Title:          "Standup",
+		Description:    "Daily sync",
+		StartTime:      start,
+		EndTime:        start.Add(15 * time.Minute),
+		NotifyBefore:   5 * time.Minute,
+		RecurrenceRule: "FREQ=DAILY",
+	})
+	require.NoError(t, err)
+
+	title, rule := "Weekly sync", ""
+	patched, err := service.PatchEvent(ctx, event.ID, EventPatch{Title: &title, RecurrenceRule: &rule}, 0)
+	require.NoError(t, err)
+	expected := event
+	expected.Title, expected.RecurrenceRule, expected.Version = title, rule, 2
+	require.Equal(t, expected, patched)
+
+	// the patch is bound to the stale version
+	description := "Sync of the whole team"
+	_, err = service.PatchEvent(ctx, event.ID, EventPatch{Description: &description}, event.Version)
+	require.ErrorIs(t, err, storage.ErrVersionConflict)
+	patched, err = service.PatchEvent(ctx, event.ID, EventPatch{Description: &description}, patched.Version)
+	require.NoError(t, err)
+	require.Equal(t, description, patched.Description)
+	require.Equal(t, title, patched.Title)
+
+	// patched event is validated as a whole
+	endTime := start.Add(-time.Hour)
+	_, err = service.PatchEvent(ctx, event.ID, EventPatch{EndTime: &endTime}, 0)
+	require.ErrorIs(t, err, ErrInvalidInterval)
+
+	_, err = service.PatchEvent(ctx, "not existing id", EventPatch{Title: &title}, 0)
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	_, err = service.PatchEvent(WithUserID(context.Background(), "another owner"), event.ID, EventPatch{Title: &title}, 0)
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+}
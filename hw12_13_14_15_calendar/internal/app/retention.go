@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"go.uber.org/zap"
+)
+
+// PurgeReport - result of old events purge.
+type PurgeReport struct {
+	// Before - events ended before this moment are purged
+	Before time.Time
+	DryRun bool
+	// Events - events which would be removed, filled only in dry run mode
+	Events []storage.Event
+	// Deleted - number of removed events, always zero in dry run mode
+	Deleted int64
+}
+
+// PurgeEvents - removes all events which ended before the given moment.
+// In dry run mode events are not removed, only reported.
+func PurgeEvents(ctx context.Context, repo EventRepository, before time.Time, dryRun bool) (PurgeReport, error) {
+	report := PurgeReport{Before: before, DryRun: dryRun}
+	if dryRun {
+		events, err := repo.FindEventsBefore(ctx, before)
+		if err != nil {
+			return report, fmt.Errorf("error during finding events to purge: %w", err)
+		}
+		for _, event := range events {
+			zap.L().Info("event would be purged", zap.String("event_id", event.ID), zap.Time("end_time", event.EndTime))
+		}
+		report.Events = events
+		return report, nil
+	}
+
+	deleted, err := repo.DeleteEventsBefore(ctx, before)
+	if err != nil {
+		return report, fmt.Errorf("error during purging events: %w", err)
+	}
+	zap.L().Info("old events purged", zap.Int64("deleted", deleted), zap.Time("before", before))
+	report.Deleted = deleted
+	return report, nil
+}
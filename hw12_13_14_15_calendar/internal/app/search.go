@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+var ErrEmptyQuery = errors.New("search query has no words")
+
+// SearchEvents - full text search over titles and descriptions of events of the user who made the request.
+// Only events containing all query words are found, the most relevant events go first.
+// Zero from or to means the search range is not bounded from that side, limit equal to zero means DefaultPageLimit.
+// Recurring events are returned as whole series, event times are in the request time zone.
+func (a *EventsService) SearchEvents(ctx context.Context, text string, from, to time.Time, limit int) ([]storage.SearchHit, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := storage.SearchQuery{OwnerID: userID, Text: text, From: from, To: to, Limit: limit}
+	if len(query.Terms()) == 0 {
+		return nil, &ValidationError{Field: "query", Err: ErrEmptyQuery}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, &ValidationError{Field: "to", Err: ErrInvalidRange}
+	}
+	if limit < 0 || limit > MaxPageLimit {
+		return nil, &ValidationError{Field: "limit", Err: ErrInvalidLimit}
+	}
+	if limit == 0 {
+		query.Limit = DefaultPageLimit
+	}
+
+	hits, err := a.repo.SearchEvents(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error during searching events: %w", err)
+	}
+	loc, err := a.zone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Event = eventsInZone(loc, []storage.Event{hits[i].Event})[0]
+	}
+	return hits, nil
+}
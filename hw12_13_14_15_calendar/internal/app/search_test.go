@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchEvents(t *testing.T) {
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage())
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	_, err := service.CreateEvent(ctx, storage.Event{
+		Title:       "Code review",
+		Description: "Review of the search feature",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	// event of another user
+	_, err = service.CreateEvent(WithUserID(context.Background(), "another owner"), storage.Event{
+		Title:     "Code review",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	hits, err := service.SearchEvents(WithTimeZone(ctx, moscow), "review", time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, "owner", hits[0].Event.OwnerID)
+	require.Equal(t, moscow, hits[0].Event.StartTime.Location())
+	require.Contains(t, hits[0].Snippet, "<b>review</b>")
+
+	hits, err = service.SearchEvents(ctx, "review", start.Add(time.Hour), time.Time{}, 0)
+	require.NoError(t, err)
+	require.Empty(t, hits)
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := service.SearchEvents(ctx, " ,. ", time.Time{}, time.Time{}, 0)
+		require.ErrorIs(t, err, ErrEmptyQuery)
+		require.True(t, IsValidationError(err))
+		_, err = service.SearchEvents(ctx, "review", start, start.Add(-time.Hour), 0)
+		require.ErrorIs(t, err, ErrInvalidRange)
+		_, err = service.SearchEvents(ctx, "review", time.Time{}, time.Time{}, -1)
+		require.ErrorIs(t, err, ErrInvalidLimit)
+		_, err = service.SearchEvents(context.Background(), "review", time.Time{}, time.Time{}, 0)
+		require.ErrorIs(t, err, ErrUnauthenticated)
+	})
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+// GetUserSettings - returns settings of the user who made the request, all of them are empty if the user has not saved any.
+func (a *EventsService) GetUserSettings(ctx context.Context) (storage.UserSettings, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.UserSettings{}, err
+	}
+	settings, err := a.repo.FindUserSettings(ctx, userID)
+	if err != nil {
+		return storage.UserSettings{}, fmt.Errorf("error during finding user settings: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateUserSettings - replaces settings of the user who made the request, the given user ID is ignored.
+// Default time zone of the user is used for requests without time zone passed by the client,
+// empty time zone means the calendar one (see WithLocation).
+func (a *EventsService) UpdateUserSettings(ctx context.Context, settings storage.UserSettings) (storage.UserSettings, error) {
+	userID, err := UserID(ctx)
+	if err != nil {
+		return storage.UserSettings{}, err
+	}
+	settings.UserID = userID
+	if settings.TimeZone != "" {
+		if _, err := LoadTimeZone(settings.TimeZone); err != nil {
+			return storage.UserSettings{}, err
+		}
+	}
+	if err := a.repo.SaveUserSettings(ctx, settings); err != nil {
+		return storage.UserSettings{}, fmt.Errorf("error during saving user settings: %w", err)
+	}
+	return settings, nil
+}
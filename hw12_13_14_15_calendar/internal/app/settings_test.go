@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSettings(t *testing.T) {
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	ctx := WithUserID(context.Background(), "owner")
+	service := New(memorystorage.NewMemStorage(), WithLocation(time.UTC))
+
+	settings, err := service.GetUserSettings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, storage.UserSettings{UserID: "owner"}, settings)
+	_, err = service.UpdateUserSettings(ctx, storage.UserSettings{TimeZone: "Mars/Olympus"})
+	require.ErrorIs(t, err, ErrUnknownTimeZone)
+
+	settings, err = service.UpdateUserSettings(ctx, storage.UserSettings{UserID: "another owner", TimeZone: "Asia/Tokyo"})
+	require.NoError(t, err)
+	require.Equal(t, storage.UserSettings{UserID: "owner", TimeZone: "Asia/Tokyo"}, settings)
+	found, err := service.GetUserSettings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, settings, found)
+
+	// the user time zone is used instead of the calendar one, the client one overrides it
+	loc, err := service.Location(ctx)
+	require.NoError(t, err)
+	require.Equal(t, tokyo, loc)
+	loc, err = service.Location(WithTimeZone(ctx, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, loc)
+	loc, err = service.Location(WithUserID(context.Background(), "another owner"))
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, loc)
+
+	// 2021-09-06 20:00 UTC is September 7 in Tokyo
+	start := time.Date(2021, time.September, 6, 20, 0, 0, 0, time.UTC)
+	_, err = service.CreateEvent(ctx, storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	events, err := service.ListDayEvents(ctx, time.Date(2021, time.September, 7, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, tokyo, events[0].StartTime.Location())
+	events, err = service.ListDayEvents(WithTimeZone(ctx, time.UTC), time.Date(2021, time.September, 7, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Time zone of the request is passed by the client, transports put it into the request context.
+// If the client doesn't pass it, time zone of the listed user calendars is used if all of them have the same one,
+// then default time zone of the user (see UpdateUserSettings), then time zone of the calendar (see WithLocation).
+// Server local time zone is never used, it depends on the host the server runs on.
+
+var (
+	ErrUnknownTimeZone  = errors.New("unknown time zone")
+	ErrTimeZoneRequired = errors.New("time zone is required, neither the client nor the calendar set it")
+)
+
+type timeZoneKey struct{}
+
+// WithTimeZone - returns context of the request made in the given time zone.
+func WithTimeZone(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, timeZoneKey{}, loc)
+}
+
+// TimeZone - returns time zone of the request if the client passed it.
+func TimeZone(ctx context.Context) (*time.Location, bool) {
+	loc, ok := ctx.Value(timeZoneKey{}).(*time.Location)
+	return loc, ok && loc != nil
+}
+
+// LoadTimeZone - loads IANA time zone passed by the client, server local time zone is not accepted.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, &ValidationError{Field: "time_zone", Err: fmt.Errorf("%w: %q", ErrUnknownTimeZone, name)}
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, &ValidationError{Field: "time_zone", Err: fmt.Errorf("%w: %q", ErrUnknownTimeZone, name)}
+	}
+	return loc, nil
+}
+
+// Location - returns time zone calendar dates of the request are interpreted in: the client one,
+// the common one of the user calendars with the IDs, the user one or the calendar one,
+// *ValidationError is returned if none is set.
+func (a *EventsService) Location(ctx context.Context, calendarIDs ...string) (*time.Location, error) {
+	var calendars []storage.Calendar
+	if len(calendarIDs) > 0 {
+		userID, err := UserID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if calendars, err = a.findCalendars(ctx, userID, "calendar_ids", calendarIDs...); err != nil {
+			return nil, err
+		}
+	}
+	loc, err := a.calendarsZone(ctx, calendars)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		return nil, &ValidationError{Field: "time_zone", Err: ErrTimeZoneRequired}
+	}
+	return loc, nil
+}
+
+// withSeriesZone - sets time zone of the recurring event series if the client didn't pass it:
+// the zone of the previous event state if there is one, otherwise the request one (see Location).
+// Series is expanded in its zone, so occurrences keep their wall clock time across DST transitions.
+func (a *EventsService) withSeriesZone(ctx context.Context, event storage.Event, previous *storage.Event) (storage.Event, error) {
+	if !event.IsRecurring() || event.TimeZone != "" {
+		return event, nil
+	}
+	if previous != nil && previous.TimeZone != "" {
+		event.TimeZone = previous.TimeZone
+		return event, nil
+	}
+	loc, err := a.Location(ctx)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	event.TimeZone = loc.String()
+	return event, nil
+}
+
+// zone - returns the client, the user or the calendar time zone, nil if none is set.
+func (a *EventsService) zone(ctx context.Context) (*time.Location, error) {
+	if loc, ok := TimeZone(ctx); ok {
+		return loc, nil
+	}
+	if userID, err := UserID(ctx); err == nil {
+		loc, err := a.userZone(ctx, userID)
+		if err != nil || loc != nil {
+			return loc, err
+		}
+	}
+	return a.location, nil
+}
+
+// calendarsZone - returns the client time zone, then the time zone of the calendars if all of them have the same one,
+// then the request one (see zone), nil if none is set.
+func (a *EventsService) calendarsZone(ctx context.Context, calendars []storage.Calendar) (*time.Location, error) {
+	if loc, ok := TimeZone(ctx); ok {
+		return loc, nil
+	}
+	if len(calendars) == 0 {
+		return a.zone(ctx)
+	}
+	name := calendars[0].TimeZone
+	for _, calendar := range calendars[1:] {
+		if calendar.TimeZone != name {
+			return a.zone(ctx)
+		}
+	}
+	if name == "" {
+		return a.zone(ctx)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("error during loading calendar %s time zone: %w", calendars[0].ID, err)
+	}
+	return loc, nil
+}
+
+// userZone - returns default time zone of the user, nil if the user has not set it.
+func (a *EventsService) userZone(ctx context.Context, userID string) (*time.Location, error) {
+	settings, err := a.repo.FindUserSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error during finding user settings: %w", err)
+	}
+	if settings.TimeZone == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(settings.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("error during loading user %s time zone: %w", userID, err)
+	}
+	return loc, nil
+}
+
+// inZone - converts the date into the time zone, the date is returned as is if there is no one.
+func inZone(loc *time.Location, date time.Time) time.Time {
+	if loc != nil {
+		return date.In(loc)
+	}
+	return date
+}
+
+// eventsInZone - converts event times into the request time zone, so they are sent to the client with its offset,
+// events are returned as is if there is no one.
+func eventsInZone(loc *time.Location, events []storage.Event) []storage.Event {
+	if loc == nil {
+		return events
+	}
+	for i := range events {
+		events[i].StartTime = events[i].StartTime.In(loc)
+		events[i].EndTime = events[i].EndTime.In(loc)
+		if len(events[i].ExceptionDates) == 0 {
+			continue
+		}
+		dates := make(storage.ExceptionDates, 0, len(events[i].ExceptionDates))
+		for _, date := range events[i].ExceptionDates {
+			dates = append(dates, date.In(loc))
+		}
+		events[i].ExceptionDates = dates
+	}
+	return events
+}
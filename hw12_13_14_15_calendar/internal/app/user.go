@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// Authorization is out of the service scope, so user ID is just passed by the client
+// in the request header or metadata and transports put it into the request context.
+
+var (
+	ErrUnauthenticated = errors.New("user id is not provided")
+	// ErrForbidden - the user sees the event, but is not allowed to make the change, e.g. viewer attendee edits it
+	ErrForbidden = errors.New("user is not allowed to change the event")
+)
+
+type userIDKey struct{}
+
+// WithUserID - returns context of the request made by the user.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID - returns ID of the user who made the request.
+func UserID(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(userIDKey{}).(string)
+	if !ok || userID == "" {
+		return "", ErrUnauthenticated
+	}
+	return userID, nil
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/recurrence"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+// MaxTitleLength - max event title length in characters.
+const MaxTitleLength = 255
+
+var (
+	ErrEmptyTitle           = errors.New("title is empty")
+	ErrTitleTooLong         = fmt.Errorf("title is longer than %d characters", MaxTitleLength)
+	ErrInvalidInterval      = errors.New("end time is not after start time")
+	ErrMissingOwner         = errors.New("owner id is missing")
+	ErrNegativeNotifyBefore = errors.New("notify before is negative")
+	ErrVersionRequired      = errors.New("version of the updated event is required")
+)
+
+// ValidationError - event doesn't satisfy business rules, Err is one of the validation errors above
+// or recurrence rule parsing error.
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s validation error: %s", e.Field, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// IsValidationError - checks whether err is caused by invalid data passed by the client.
+func IsValidationError(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr)
+}
+
+// ValidateEvent - checks event business rules, the first broken rule is returned as *ValidationError.
+func ValidateEvent(event storage.Event) error {
+	switch {
+	case event.Title == "":
+		return &ValidationError{Field: "title", Err: ErrEmptyTitle}
+	case utf8.RuneCountInString(event.Title) > MaxTitleLength:
+		return &ValidationError{Field: "title", Err: ErrTitleTooLong}
+	case event.OwnerID == "":
+		return &ValidationError{Field: "owner_id", Err: ErrMissingOwner}
+	// zero length event never overlaps anything, so it could neither conflict nor be listed
+	case !event.EndTime.After(event.StartTime):
+		return &ValidationError{Field: "end_time", Err: ErrInvalidInterval}
+	case event.NotifyBefore < 0:
+		return &ValidationError{Field: "notify_before", Err: ErrNegativeNotifyBefore}
+	}
+	if event.IsRecurring() {
+		if _, err := recurrence.Parse(event.RecurrenceRule); err != nil {
+			return &ValidationError{Field: "recurrence_rule", Err: err}
+		}
+	}
+	if event.TimeZone != "" {
+		if _, err := LoadTimeZone(event.TimeZone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
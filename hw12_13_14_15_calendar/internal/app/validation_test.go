@@ -0,0 +1,52 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/recurrence"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEvent(t *testing.T) {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	valid := storage.Event{
+		Title:     "Standup",
+		StartTime: start,
+		EndTime:   start.Add(15 * time.Minute),
+		OwnerID:   "owner",
+	}
+	require.NoError(t, ValidateEvent(valid))
+
+	tests := []struct {
+		name     string
+		modify   func(event *storage.Event)
+		expected error
+	}{
+		{"empty title", func(e *storage.Event) { e.Title = "" }, ErrEmptyTitle},
+		{"title too long", func(e *storage.Event) { e.Title = strings.Repeat("я", MaxTitleLength+1) }, ErrTitleTooLong},
+		{"missing owner", func(e *storage.Event) { e.OwnerID = "" }, ErrMissingOwner},
+		{"end before start", func(e *storage.Event) { e.EndTime = start.Add(-time.Minute) }, ErrInvalidInterval},
+		{"zero length", func(e *storage.Event) { e.EndTime = start }, ErrInvalidInterval},
+		{"negative notify before", func(e *storage.Event) { e.NotifyBefore = -time.Minute }, ErrNegativeNotifyBefore},
+		{"invalid recurrence rule", func(e *storage.Event) { e.RecurrenceRule = "FREQ=DAILY;COUNT=0" }, recurrence.ErrInvalidRule},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			event := valid
+			tc.modify(&event)
+			err := ValidateEvent(event)
+			require.ErrorIs(t, err, tc.expected)
+			require.True(t, IsValidationError(err))
+		})
+	}
+
+	t.Run("title of max length", func(t *testing.T) {
+		event := valid
+		event.Title = strings.Repeat("я", MaxTitleLength)
+		require.NoError(t, ValidateEvent(event))
+	})
+}
@@ -0,0 +1,91 @@
+// Package auth authenticates API callers by the credentials of their requests.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+)
+
+const (
+	// APIKeyHeader - HTTP header with static API key, the same lowercased key is used for gRPC metadata.
+	APIKeyHeader = "X-Api-Key"
+	// AuthorizationHeader - HTTP header with "Bearer <token>" credentials, the same lowercased key is used for gRPC metadata.
+	AuthorizationHeader = "Authorization"
+	bearerScheme        = "bearer "
+)
+
+var (
+	ErrNoCredentials      = errors.New("credentials are not provided")
+	ErrInvalidCredentials = errors.New("credentials are invalid")
+)
+
+// Credentials - caller credentials found in request headers or metadata.
+type Credentials struct {
+	APIKey      string
+	BearerToken string
+	// UserID - user ID claimed by the caller, it is verified by nothing and is accepted only by TrustedUserID
+	UserID string
+}
+
+// BearerToken - returns token of "Bearer <token>" authorization value, scheme is case insensitive.
+// Empty token is returned for other authorization schemes.
+func BearerToken(authorization string) string {
+	if len(authorization) < len(bearerScheme) || !strings.EqualFold(authorization[:len(bearerScheme)], bearerScheme) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(bearerScheme):])
+}
+
+// Authenticator - verifies request credentials and returns ID of the authenticated user.
+// ErrNoCredentials is returned if the credentials of the authenticator kind are not provided,
+// so the request may be authenticated by other means, ErrInvalidCredentials is returned if they are not valid.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credentials Credentials) (userID string, err error)
+}
+
+// Chain - authenticates the request by the first authenticator which kind of credentials is provided.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(ctx context.Context, credentials Credentials) (string, error) {
+	for _, authenticator := range c {
+		userID, err := authenticator.Authenticate(ctx, credentials)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return userID, err
+	}
+	return "", ErrNoCredentials
+}
+
+// APIKeys - static API keys mapped to IDs of their users.
+type APIKeys map[string]string
+
+func (k APIKeys) Authenticate(ctx context.Context, credentials Credentials) (string, error) {
+	if credentials.APIKey == "" {
+		return "", ErrNoCredentials
+	}
+	// all keys are compared in constant time, so the response time does not tell how close the key is
+	var userID string
+	for key, keyUserID := range k {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(credentials.APIKey)) == 1 {
+			userID = keyUserID
+		}
+	}
+	if userID == "" {
+		return "", ErrInvalidCredentials
+	}
+	return userID, nil
+}
+
+// TrustedUserID - accepts user ID claimed by the caller without any verification.
+// It must be used only for development or for the API behind a proxy which authenticates users itself.
+type TrustedUserID struct{}
+
+func (TrustedUserID) Authenticate(ctx context.Context, credentials Credentials) (string, error) {
+	if credentials.UserID == "" {
+		return "", ErrNoCredentials
+	}
+	return credentials.UserID, nil
+}
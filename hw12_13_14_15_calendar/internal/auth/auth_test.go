@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte(strings.Repeat("s", minSecretSize))
+
+func TestBearerToken(t *testing.T) {
+	require.Equal(t, "abc", BearerToken("Bearer abc"))
+	require.Equal(t, "abc", BearerToken("bearer  abc"))
+	require.Empty(t, BearerToken("Basic YWJjOmRlZg=="))
+	require.Empty(t, BearerToken("Bearer"))
+	require.Empty(t, BearerToken(""))
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys := APIKeys{"key-1": "user-1", "key-2": "user-2"}
+
+	userID, err := keys.Authenticate(context.Background(), Credentials{APIKey: "key-2"})
+	require.NoError(t, err)
+	require.Equal(t, "user-2", userID)
+
+	_, err = keys.Authenticate(context.Background(), Credentials{APIKey: "key-3"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = keys.Authenticate(context.Background(), Credentials{UserID: "user-1"})
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestJWT(t *testing.T) {
+	_, err := NewJWT([]byte("short"), "", "")
+	require.ErrorIs(t, err, ErrWeakSecret)
+
+	verifier, err := NewJWT(testSecret, "calendar-idp", "calendar")
+	require.NoError(t, err)
+	token, err := verifier.Issue("user-1", time.Minute)
+	require.NoError(t, err)
+	userID, err := verifier.Authenticate(context.Background(), Credentials{BearerToken: token})
+	require.NoError(t, err)
+	require.Equal(t, "user-1", userID)
+
+	_, err = verifier.Authenticate(context.Background(), Credentials{})
+	require.ErrorIs(t, err, ErrNoCredentials)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		secret []byte
+		claims jwt.MapClaims
+	}{
+		{
+			name:   "expired",
+			claims: jwt.MapClaims{"sub": "user-1", "iss": "calendar-idp", "aud": "calendar", "exp": time.Now().Add(-time.Minute).Unix()},
+		},
+		{
+			name:   "without expiration",
+			claims: jwt.MapClaims{"sub": "user-1", "iss": "calendar-idp", "aud": "calendar"},
+		},
+		{
+			name:   "without subject",
+			claims: jwt.MapClaims{"iss": "calendar-idp", "aud": "calendar", "exp": time.Now().Add(time.Minute).Unix()},
+		},
+		{
+			name:   "another issuer",
+			claims: jwt.MapClaims{"sub": "user-1", "iss": "idp", "aud": "calendar", "exp": time.Now().Add(time.Minute).Unix()},
+		},
+		{
+			name:   "another audience",
+			claims: jwt.MapClaims{"sub": "user-1", "iss": "calendar-idp", "aud": []string{"mail"}, "exp": time.Now().Add(time.Minute).Unix()},
+		},
+		{
+			name:   "another secret",
+			secret: []byte(strings.Repeat("x", minSecretSize)),
+			claims: jwt.MapClaims{"sub": "user-1", "iss": "calendar-idp", "aud": "calendar", "exp": time.Now().Add(time.Minute).Unix()},
+		},
+		{
+			name:   "not HMAC",
+			method: jwt.SigningMethodNone,
+			claims: jwt.MapClaims{"sub": "user-1", "iss": "calendar-idp", "aud": "calendar", "exp": time.Now().Add(time.Minute).Unix()},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			method, key := jwt.SigningMethod(jwt.SigningMethodHS256), interface{}(testSecret)
+			if tt.secret != nil {
+				key = tt.secret
+			}
+			if tt.method == jwt.SigningMethodNone {
+				method, key = tt.method, jwt.UnsafeAllowNoneSignatureType
+			}
+			token, err := jwt.NewWithClaims(method, tt.claims).SignedString(key)
+			require.NoError(t, err)
+			_, err = verifier.Authenticate(context.Background(), Credentials{BearerToken: token})
+			require.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := Chain{APIKeys{"key-1": "user-1"}, TrustedUserID{}}
+
+	userID, err := chain.Authenticate(context.Background(), Credentials{APIKey: "key-1", UserID: "user-2"})
+	require.NoError(t, err)
+	require.Equal(t, "user-1", userID)
+
+	userID, err = chain.Authenticate(context.Background(), Credentials{UserID: "user-2"})
+	require.NoError(t, err)
+	require.Equal(t, "user-2", userID)
+
+	// invalid credentials are not ignored even if the next authenticator accepts the request
+	_, err = chain.Authenticate(context.Background(), Credentials{APIKey: "key-2", UserID: "user-2"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = chain.Authenticate(context.Background(), Credentials{})
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
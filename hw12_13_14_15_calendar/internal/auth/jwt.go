@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minSecretSize - HMAC key must be at least as long as SHA-256 output.
+const minSecretSize = 32
+
+var (
+	ErrWeakSecret      = fmt.Errorf("JWT secret is shorter than %d bytes", minSecretSize)
+	ErrTokenExpiration = errors.New("token expiration is required")
+)
+
+// JWT - verifies HMAC signed bearer tokens locally, user ID is the token subject.
+// Tokens must have expiration, issuer and audience are checked if they are set.
+type JWT struct {
+	secret   []byte
+	issuer   string
+	audience string
+}
+
+func NewJWT(secret []byte, issuer, audience string) (*JWT, error) {
+	if len(secret) < minSecretSize {
+		return nil, ErrWeakSecret
+	}
+	return &JWT{secret: secret, issuer: issuer, audience: audience}, nil
+}
+
+func (j *JWT) Authenticate(ctx context.Context, credentials Credentials) (string, error) {
+	if credentials.BearerToken == "" {
+		return "", ErrNoCredentials
+	}
+	claims := jwt.MapClaims{}
+	parser := jwt.Parser{ValidMethods: []string{
+		jwt.SigningMethodHS256.Alg(), jwt.SigningMethodHS384.Alg(), jwt.SigningMethodHS512.Alg(),
+	}}
+	_, err := parser.ParseWithClaims(credentials.BearerToken, claims, func(*jwt.Token) (interface{}, error) {
+		return j.secret, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	// exp is optional for the parser, but tokens without it are valid forever
+	if _, ok := claims["exp"]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCredentials, ErrTokenExpiration)
+	}
+	if j.issuer != "" && !claims.VerifyIssuer(j.issuer, true) {
+		return "", fmt.Errorf("%w: token issuer is not %q", ErrInvalidCredentials, j.issuer)
+	}
+	if j.audience != "" && !claims.VerifyAudience(j.audience, true) {
+		return "", fmt.Errorf("%w: token audience is not %q", ErrInvalidCredentials, j.audience)
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return "", fmt.Errorf("%w: token subject is required", ErrInvalidCredentials)
+	}
+	return subject, nil
+}
+
+// Issue - signs token of the user valid for ttl, e.g. for tests and service accounts.
+func (j *JWT) Issue(userID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userID,
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	}
+	if j.issuer != "" {
+		claims["iss"] = j.issuer
+	}
+	if j.audience != "" {
+		claims["aud"] = j.audience
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	if err != nil {
+		return "", fmt.Errorf("error during token signing: %w", err)
+	}
+	return token, nil
+}
@@ -3,9 +3,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
@@ -13,23 +18,50 @@ import (
 const configErrorCausedFallthroughToDefaultsMsg = "configuration file error detected, default value will be used"
 
 var (
-	ErrLoggerLevelIsEmpty   = errors.New("logger level is empty")
-	ErrLoggerFileIsEmpty    = errors.New("logger output file path is empty")
-	ErrDBHostIsEmpty        = errors.New("db host is empty")
-	ErrDBPortIsInvalid      = errors.New("db port is invalid")
-	ErrDBUsernameIsEmpty    = errors.New("db username is empty")
-	ErrDBPassIsEmpty        = errors.New("db pass is empty")
-	ErrDBDBIsEmpty          = errors.New("database name is empty")
-	ErrHTTPPortIsInvalid    = errors.New("http port is invalid")
-	ErrHTTPTimeoutIsInvalid = errors.New("http connection timeout is invalid")
-	ErrGRPCPortIsInvalid    = errors.New("grpc port is invalid")
-	ErrGRPCTimeoutIsInvalid = errors.New("grpc connection timeout is invalid")
+	ErrLoggerLevelIsEmpty    = errors.New("logger level is empty")
+	ErrLoggerFileIsEmpty     = errors.New("logger output file path is empty")
+	ErrDBHostIsEmpty         = errors.New("db host is empty")
+	ErrDBPortIsInvalid       = errors.New("db port is invalid")
+	ErrDBUsernameIsEmpty     = errors.New("db username is empty")
+	ErrDBPassIsEmpty         = errors.New("db pass is empty")
+	ErrDBDBIsEmpty           = errors.New("database name is empty")
+	ErrHTTPPortIsInvalid     = errors.New("http port is invalid")
+	ErrHTTPTimeoutIsInvalid  = errors.New("http connection timeout is invalid")
+	ErrGRPCPortIsInvalid     = errors.New("grpc port is invalid")
+	ErrGRPCTimeoutIsInvalid  = errors.New("grpc connection timeout is invalid")
+	ErrQueueHostIsEmpty      = errors.New("queue host is empty")
+	ErrQueuePortIsInvalid    = errors.New("queue port is invalid")
+	ErrQueueUsernameIsEmpty  = errors.New("queue username is empty")
+	ErrQueuePassIsEmpty      = errors.New("queue pass is empty")
+	ErrQueueExchangeIsEmpty  = errors.New("queue exchange name is empty")
+	ErrQueueNameIsEmpty      = errors.New("queue name is empty")
+	ErrScanIntervalInvalid   = errors.New("scheduler scan interval is invalid")
+	ErrPurgeIntervalInvalid  = errors.New("scheduler purge interval is invalid")
+	ErrRetentionInvalid      = errors.New("events retention period is invalid")
+	ErrFirstDayOfWeekInvalid = errors.New("first day of week is invalid")
+	ErrTimeZoneInvalid       = errors.New("calendar time zone is invalid")
 )
 
 type Config struct {
+	Logger    LoggerConfig
+	Storage   StorageConfig
+	API       APIConfig
+	Retention RetentionConfig
+	Calendar  CalendarConfig
+}
+
+type SchedulerConfig struct {
+	Logger    LoggerConfig
+	Storage   StorageConfig
+	Queue     QueueConfig
+	Scheduler SchedulingConfig
+	Retention RetentionConfig
+}
+
+type SenderConfig struct {
 	Logger  LoggerConfig
 	Storage StorageConfig
-	API     APIConfig
+	Queue   QueueConfig
 }
 
 type LoggerConfig struct {
@@ -53,6 +85,31 @@ type DBConfig struct {
 type APIConfig struct {
 	GRPC GRPCApiConfig `mapstructure:"grpc"`
 	HTTP HTTPApiConfig `mapstructure:"http"`
+	Auth AuthConfig    `mapstructure:"auth"`
+}
+
+// AuthConfig - authentication methods of HTTP and gRPC APIs, requests may be authenticated by any of them.
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `mapstructure:"apikeys"`
+	JWT     JWTConfig      `mapstructure:"jwt"`
+	// TrustUserIDHeader - user ID is taken from X-User-ID header or x-user-id metadata without verification,
+	// only for development or for the API behind a proxy which authenticates users itself.
+	TrustUserIDHeader bool `mapstructure:"trustuseridheader"`
+}
+
+// APIKeyConfig - static API key of the user.
+type APIKeyConfig struct {
+	Key    string
+	UserID string `mapstructure:"userid"`
+}
+
+// JWTConfig - HMAC signed bearer tokens, user ID is the token subject.
+type JWTConfig struct {
+	// Secret - HMAC key at least 32 bytes long, tokens are not accepted if it is empty.
+	Secret string
+	// Issuer and Audience - expected token iss and aud claims, optional.
+	Issuer   string
+	Audience string
 }
 
 type GRPCApiConfig struct {
@@ -63,6 +120,82 @@ type HTTPApiConfig struct {
 	Port int
 }
 
+type QueueConfig struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	Exchange   string
+	Queue      string
+	RoutingKey string
+}
+
+type SchedulingConfig struct {
+	// ScanInterval - how often the events storage is scanned for events to notify about.
+	ScanInterval time.Duration
+	// PurgeInterval - how often events older than retention period are purged.
+	PurgeInterval time.Duration
+}
+
+type RetentionConfig struct {
+	// Period - events ended earlier than Period ago are purged.
+	Period time.Duration
+	// DryRun - old events are only reported and not removed.
+	DryRun bool
+}
+
+type CalendarConfig struct {
+	// FirstDayOfWeek - english weekday name, e.g. "monday" or "sunday".
+	FirstDayOfWeek string
+	// TimeZone - IANA time zone name, day, week and month boundaries are calculated in it
+	// for requests without the client or the user default time zone.
+	// If it is empty, such requests of calendar dates are rejected.
+	TimeZone string
+}
+
+// WeekStart - parses first day of week, case insensitive.
+func (c CalendarConfig) WeekStart() (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(c.FirstDayOfWeek, day.String()) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("%w: %q", ErrFirstDayOfWeekInvalid, c.FirstDayOfWeek)
+}
+
+// Location - loads calendar time zone, nil location is returned for empty time zone.
+func (c CalendarConfig) Location() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTimeZoneInvalid, err)
+	}
+	return loc, nil
+}
+
+// DSN - builds postgres connection string from db config.
+func (db DBConfig) DSN() string {
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s database=%s password=%s sslmode=disable",
+		db.Host,
+		db.Port,
+		db.Username,
+		db.DB,
+		db.Password)
+}
+
+// URI - builds amqp connection string from queue config.
+func (q QueueConfig) URI() string {
+	return fmt.Sprintf("amqp://%s:%s@%s/", q.Username, q.Password, net.JoinHostPort(q.Host, strconv.Itoa(q.Port)))
+}
+
+// Topology - broker structures described by queue config.
+func (q QueueConfig) Topology() queue.Topology {
+	return queue.Topology{Exchange: q.Exchange, Queue: q.Queue, RoutingKey: q.RoutingKey}
+}
+
 func (db *DBConfig) fallthroughToDefaults() {
 	if db.Host == "" {
 		db.Host = "localhost"
@@ -119,22 +252,121 @@ func (conf *APIConfig) fallthroughToDefaults() {
 	}
 }
 
+func (conf *QueueConfig) fallthroughToDefaults() {
+	if conf.Host == "" {
+		conf.Host = "localhost"
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrQueueHostIsEmpty), zap.String("default", conf.Host))
+	}
+	if conf.Port == 0 {
+		conf.Port = 5672
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrQueuePortIsInvalid), zap.Int("default", conf.Port))
+	}
+	if conf.Username == "" {
+		conf.Username = "guest"
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrQueueUsernameIsEmpty), zap.String("default", conf.Username))
+	}
+	if conf.Password == "" {
+		conf.Password = "guest"
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrQueuePassIsEmpty), zap.String("default", conf.Password))
+	}
+	if conf.Exchange == "" {
+		conf.Exchange = "calendar"
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrQueueExchangeIsEmpty), zap.String("default", conf.Exchange))
+	}
+	if conf.Queue == "" {
+		conf.Queue = "notifications"
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrQueueNameIsEmpty), zap.String("default", conf.Queue))
+	}
+	if conf.RoutingKey == "" {
+		// routing key is optional, by default messages are routed by queue name
+		conf.RoutingKey = conf.Queue
+	}
+}
+
+func (conf *SchedulingConfig) fallthroughToDefaults() {
+	if conf.ScanInterval <= 0 {
+		conf.ScanInterval = time.Minute
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrScanIntervalInvalid), zap.Duration("default", conf.ScanInterval))
+	}
+	if conf.PurgeInterval <= 0 {
+		conf.PurgeInterval = time.Hour
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrPurgeIntervalInvalid), zap.Duration("default", conf.PurgeInterval))
+	}
+}
+
+func (conf *RetentionConfig) fallthroughToDefaults() {
+	if conf.Period <= 0 {
+		// one year
+		conf.Period = 365 * 24 * time.Hour
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(ErrRetentionInvalid), zap.Duration("default", conf.Period))
+	}
+}
+
+func (conf *CalendarConfig) fallthroughToDefaults() {
+	if _, err := conf.WeekStart(); err != nil {
+		conf.FirstDayOfWeek = time.Monday.String()
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(err), zap.String("default", conf.FirstDayOfWeek))
+	}
+	if _, err := conf.Location(); err != nil {
+		conf.TimeZone = ""
+		zap.L().Error(configErrorCausedFallthroughToDefaultsMsg, zap.Error(err), zap.String("default", "time zone passed by the client"))
+	}
+}
+
+func (conf *SchedulerConfig) fallthroughToDefaults() {
+	conf.Storage.fallthroughToDefaults()
+	conf.Logger.fallthroughToDefaults()
+	conf.Queue.fallthroughToDefaults()
+	conf.Scheduler.fallthroughToDefaults()
+	conf.Retention.fallthroughToDefaults()
+}
+
+func (conf *SenderConfig) fallthroughToDefaults() {
+	conf.Storage.fallthroughToDefaults()
+	conf.Logger.fallthroughToDefaults()
+	conf.Queue.fallthroughToDefaults()
+}
+
 func (conf *Config) fallthroughToDefaults() {
 	conf.Storage.fallthroughToDefaults()
 	conf.Logger.fallthroughToDefaults()
 	conf.API.fallthroughToDefaults()
+	conf.Retention.fallthroughToDefaults()
+	conf.Calendar.fallthroughToDefaults()
 }
 
 func NewConfig(configFilePath string) (cfg *Config, err error) {
-	viper.SetConfigFile(configFilePath)
-	viper.SetConfigType("yaml")
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("error during reading config file: %w", err)
+	if err := readConfig(configFilePath, &cfg); err != nil {
+		return nil, err
+	}
+	cfg.fallthroughToDefaults()
+	zap.L().Info("result config", zap.String("config", fmt.Sprintf("%v", cfg)))
+	return cfg, nil
+}
+
+func NewSchedulerConfig(configFilePath string) (cfg *SchedulerConfig, err error) {
+	if err := readConfig(configFilePath, &cfg); err != nil {
+		return nil, err
 	}
-	if err = viper.Unmarshal(&cfg); err != nil {
+	cfg.fallthroughToDefaults()
+	zap.L().Info("result config", zap.String("config", fmt.Sprintf("%v", cfg)))
+	return cfg, nil
+}
+
+func NewSenderConfig(configFilePath string) (cfg *SenderConfig, err error) {
+	if err := readConfig(configFilePath, &cfg); err != nil {
 		return nil, err
 	}
 	cfg.fallthroughToDefaults()
 	zap.L().Info("result config", zap.String("config", fmt.Sprintf("%v", cfg)))
 	return cfg, nil
 }
+
+func readConfig(configFilePath string, cfg interface{}) error {
+	viper.SetConfigFile(configFilePath)
+	viper.SetConfigType("yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("error during reading config file: %w", err)
+	}
+	return viper.Unmarshal(cfg)
+}
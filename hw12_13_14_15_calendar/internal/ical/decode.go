@@ -0,0 +1,339 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+var (
+	ErrMalformedCalendar = errors.New("malformed calendar")
+	ErrMissingProperty   = errors.New("required property is missing")
+	ErrInvalidProperty   = errors.New("invalid property value")
+)
+
+const (
+	localDateTimeLayout = "20060102T150405"
+	// maxContentLineLength - max length of unfolded content line
+	maxContentLineLength = 1 << 20
+)
+
+// Item - decoded VEVENT component.
+// Err is set if the component could not be mapped onto storage.Event,
+// Event ID and OwnerID are never filled by decoder.
+type Item struct {
+	UID   string
+	Event storage.Event
+	Err   error
+}
+
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode - reads all VEVENT components of VCALENDAR, floating DATE-TIME and DATE values are interpreted in loc.
+// Error is returned only if the calendar itself is malformed, errors of single events are reported in items.
+func Decode(r io.Reader, loc *time.Location) ([]Item, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	var stack []string
+	var props []contentLine
+	calendarFound := false
+	for i, line := range lines {
+		cl, err := parseContentLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: content line %d: %s", ErrMalformedCalendar, i+1, err)
+		}
+		switch cl.name {
+		case "BEGIN":
+			component := strings.ToUpper(cl.value)
+			if len(stack) == 0 {
+				if component != "VCALENDAR" {
+					return nil, fmt.Errorf("%w: content line %d: VCALENDAR expected", ErrMalformedCalendar, i+1)
+				}
+				calendarFound = true
+			}
+			if component == "VEVENT" && len(stack) == 1 {
+				props = nil
+			}
+			stack = append(stack, component)
+		case "END":
+			component := strings.ToUpper(cl.value)
+			if len(stack) == 0 || stack[len(stack)-1] != component {
+				return nil, fmt.Errorf("%w: content line %d: unexpected END:%s", ErrMalformedCalendar, i+1, cl.value)
+			}
+			stack = stack[:len(stack)-1]
+			if component == "VEVENT" && len(stack) == 1 {
+				items = append(items, newItem(props, loc))
+			}
+		default:
+			// properties of nested components (e.g. VALARM) are ignored
+			if len(stack) == 2 && stack[1] == "VEVENT" {
+				props = append(props, cl)
+			}
+		}
+	}
+	if !calendarFound {
+		return nil, fmt.Errorf("%w: VCALENDAR not found", ErrMalformedCalendar)
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%w: %s is not closed", ErrMalformedCalendar, stack[len(stack)-1])
+	}
+	return items, nil
+}
+
+// unfold - reads content lines, continuation lines (starting with space or tab) are joined with previous one.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxContentLineLength)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error during calendar reading: %w", err)
+	}
+	return lines, nil
+}
+
+// parseContentLine - parses "NAME;PARAM=VALUE;PARAM="QUOTED VALUE":value" line.
+func parseContentLine(line string) (contentLine, error) {
+	inQuotes := false
+	colon := -1
+	var separators []int
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes {
+				separators = append(separators, i)
+			}
+		case ':':
+			if !inQuotes {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return contentLine{}, errors.New("name and value separator not found")
+	}
+	separators = append(separators, colon)
+
+	cl := contentLine{
+		name:   strings.ToUpper(line[:separators[0]]),
+		params: make(map[string]string),
+		value:  line[colon+1:],
+	}
+	if cl.name == "" {
+		return contentLine{}, errors.New("empty property name")
+	}
+	for i := 0; i < len(separators)-1; i++ {
+		param := line[separators[i]+1 : separators[i+1]]
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return contentLine{}, fmt.Errorf("malformed parameter %q", param)
+		}
+		cl.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return cl, nil
+}
+
+func newItem(props []contentLine, loc *time.Location) Item {
+	var item Item
+	var start, end *contentLine
+	var duration string
+	for i := range props {
+		prop := &props[i]
+		switch prop.name {
+		case "UID":
+			item.UID = strings.TrimSpace(prop.value)
+		case "SUMMARY":
+			item.Event.Title = unescapeText(prop.value)
+		case "DESCRIPTION":
+			item.Event.Description = unescapeText(prop.value)
+		case "DTSTART":
+			start = prop
+		case "DTEND":
+			end = prop
+		case "DURATION":
+			duration = prop.value
+		case "RRULE":
+			item.Event.RecurrenceRule = prop.value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				date, _, err := parseDateTime(value, prop.params, loc)
+				if err != nil {
+					item.Err = fmt.Errorf("EXDATE: %w", err)
+					return item
+				}
+				item.Event.ExceptionDates = append(item.Event.ExceptionDates, date)
+			}
+		}
+	}
+
+	if item.UID == "" {
+		item.Err = fmt.Errorf("%w: UID", ErrMissingProperty)
+		return item
+	}
+	if start == nil {
+		item.Err = fmt.Errorf("%w: DTSTART", ErrMissingProperty)
+		return item
+	}
+	startTime, isDate, err := parseDateTime(start.value, start.params, loc)
+	if err != nil {
+		item.Err = fmt.Errorf("DTSTART: %w", err)
+		return item
+	}
+	item.Event.StartTime = startTime
+	// recurring series is expanded in the time zone of its start
+	item.Event.TimeZone = start.params["TZID"]
+
+	switch {
+	case end != nil:
+		item.Event.EndTime, _, err = parseDateTime(end.value, end.params, loc)
+		if err != nil {
+			item.Err = fmt.Errorf("DTEND: %w", err)
+			return item
+		}
+	case duration != "":
+		d, err := parseDuration(duration)
+		if err != nil {
+			item.Err = fmt.Errorf("DURATION: %w", err)
+			return item
+		}
+		item.Event.EndTime = startTime.Add(d)
+	case isDate:
+		// all day event without end lasts one day
+		item.Event.EndTime = startTime.AddDate(0, 0, 1)
+	default:
+		item.Event.EndTime = startTime
+	}
+	return item
+}
+
+// parseDateTime - parses DATE or DATE-TIME value, isDate is true for DATE values.
+// DATE-TIME without "Z" suffix and TZID parameter is a floating time and is parsed in floating time zone.
+func parseDateTime(value string, params map[string]string, floating *time.Location) (t time.Time, isDate bool, err error) {
+	loc := floating
+	if tzid, ok := params["TZID"]; ok {
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: unknown TZID %s", ErrInvalidProperty, tzid)
+		}
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(dateLayout):
+		t, err = time.ParseInLocation(dateLayout, value, loc)
+		isDate = true
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse(dateTimeLayout, value)
+	default:
+		t, err = time.ParseInLocation(localDateTimeLayout, value, loc)
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %s", ErrInvalidProperty, value)
+	}
+	return t, isDate, nil
+}
+
+// parseDuration - parses RFC 5545 duration, e.g. "PT1H30M", "P1D", "P2W", "-PT15M".
+func parseDuration(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("%w: duration %s", ErrInvalidProperty, value)
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, invalid
+	}
+	var result time.Duration
+	inTime := false
+	number := ""
+	for _, c := range value[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		case c == 'T' && number == "" && !inTime:
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, invalid
+		}
+		number = ""
+		unit, ok := durationUnit(c, inTime)
+		if !ok {
+			return 0, invalid
+		}
+		result += time.Duration(n) * unit
+	}
+	if number != "" {
+		return 0, invalid
+	}
+	return sign * result, nil
+}
+
+func durationUnit(designator rune, inTime bool) (time.Duration, bool) {
+	switch {
+	case !inTime && designator == 'W':
+		return 7 * 24 * time.Hour, true
+	case !inTime && designator == 'D':
+		return 24 * time.Hour, true
+	case inTime && designator == 'H':
+		return time.Hour, true
+	case inTime && designator == 'M':
+		return time.Minute, true
+	case inTime && designator == 'S':
+		return time.Second, true
+	}
+	return 0, false
+}
+
+func unescapeText(value string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, c := range value {
+		if !escaped {
+			if c == '\\' {
+				escaped = true
+				continue
+			}
+			sb.WriteRune(c)
+			continue
+		}
+		escaped = false
+		if c == 'n' || c == 'N' {
+			sb.WriteRune('\n')
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,124 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Only a subset of RFC 5545 is supported: VCALENDAR with VEVENT components,
+// VEVENT properties UID, SUMMARY, DESCRIPTION, DTSTART, DTEND, DURATION, RRULE and EXDATE.
+
+const (
+	ContentType = "text/calendar"
+
+	prodID         = "-//otus_go_homeworks//calendar//EN"
+	dateTimeLayout = "20060102T150405Z"
+	dateLayout     = "20060102"
+	// maxLineLength - content lines longer than this (in octets) must be folded
+	maxLineLength = 75
+)
+
+// Encode - writes events as VCALENDAR, event ID is used as VEVENT UID.
+func Encode(w io.Writer, events []storage.Event) error {
+	bw := bufio.NewWriter(w)
+	e := encoder{w: bw}
+	stamp := formatDateTime(time.Now())
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	for _, event := range events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", event.ID)
+		e.line("DTSTAMP", stamp)
+		e.dateTime("DTSTART", event.StartTime, event.TimeZone)
+		e.dateTime("DTEND", event.EndTime, event.TimeZone)
+		e.line("SUMMARY", escapeText(event.Title))
+		if event.Description != "" {
+			e.line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.IsRecurring() {
+			e.line("RRULE", strings.TrimPrefix(event.RecurrenceRule, "RRULE:"))
+		}
+		if len(event.ExceptionDates) > 0 {
+			dates := make([]string, 0, len(event.ExceptionDates))
+			for _, date := range event.ExceptionDates {
+				dates = append(dates, formatDateTime(date))
+			}
+			e.line("EXDATE", strings.Join(dates, ","))
+		}
+		e.line("END", "VEVENT")
+	}
+	e.line("END", "VCALENDAR")
+
+	if e.err != nil {
+		return fmt.Errorf("error during calendar encoding: %w", e.err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error during calendar encoding: %w", err)
+	}
+	return nil
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line - writes folded content line, the first write error is saved and all next writes are skipped.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.WriteString(fold(name + ":" + value))
+}
+
+// dateTime - writes DATE-TIME property in the time zone with TZID parameter, so recurring series keeps
+// its wall clock time across DST transitions, UTC is used if the time zone is not set or unknown.
+func (e *encoder) dateTime(name string, t time.Time, timeZone string) {
+	if timeZone != "" {
+		if loc, err := time.LoadLocation(timeZone); err == nil {
+			e.line(name+";TZID="+timeZone, t.In(loc).Format(localDateTimeLayout))
+			return
+		}
+	}
+	e.line(name, formatDateTime(t))
+}
+
+// fold - splits long content line into several lines, continuation lines start with a space.
+// Line is never split inside a multi-octet UTF-8 sequence.
+func fold(line string) string {
+	var sb strings.Builder
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		// leading space of continuation line is counted too
+		limit = maxLineLength - 1
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+	return sb.String()
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
@@ -0,0 +1,144 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	events := []storage.Event{
+		{
+			ID:             "3b1c9a4e-4f3c-4a7e-9d7a-2f8f0b0c9e11",
+			Title:          "Standup; daily, short",
+			StartTime:      start,
+			EndTime:        start.Add(15 * time.Minute),
+			Description:    "Line one\nline two with back\\slash",
+			RecurrenceRule: "FREQ=WEEKLY;BYDAY=MO,TH",
+			ExceptionDates: storage.ExceptionDates{start.AddDate(0, 0, 3)},
+			TimeZone:       "Europe/Berlin",
+		},
+		{
+			ID: "9f5d2c7b-0e1a-4d6b-8c3f-7a2e4b1d6c05",
+			// long title with multi-octet characters forces line folding
+			Title:       strings.Repeat("Длинный заголовок ", 10),
+			StartTime:   start.AddDate(0, 0, 1),
+			EndTime:     start.AddDate(0, 0, 1).Add(time.Hour),
+			Description: "",
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Encode(buf, events))
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLineLength)
+	}
+
+	items, err := Decode(buf, time.UTC)
+	require.NoError(t, err)
+	require.Len(t, items, len(events))
+	for i, item := range items {
+		require.NoError(t, item.Err)
+		require.Equal(t, events[i].ID, item.UID)
+		item.Event.ID = item.UID
+		require.True(t, events[i].IsEqual(item.Event), "event %d: %+v", i, item.Event)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:with-timezone@example.com",
+		"SUMMARY:Meeting",
+		"DTSTART;TZID=Europe/Moscow:20210906T100000",
+		"DURATION:PT1H30M",
+		"BEGIN:VALARM",
+		"DESCRIPTION:alarm description is ignored",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:all-day@example.com",
+		"SUMMARY:Holi",
+		" day",
+		"DTSTART;VALUE=DATE:20210906",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Without UID",
+		"DTSTART:20210906T100000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:bad-start@example.com",
+		"DTSTART:yesterday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:floating@example.com",
+		"DTSTART:20210906T100000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	items, err := Decode(strings.NewReader(calendar), tokyo)
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	require.NoError(t, items[0].Err)
+	require.Equal(t, "Meeting", items[0].Event.Title)
+	require.Empty(t, items[0].Event.Description)
+	require.True(t, time.Date(2021, time.September, 6, 10, 0, 0, 0, moscow).Equal(items[0].Event.StartTime))
+	require.Equal(t, 90*time.Minute, items[0].Event.EndTime.Sub(items[0].Event.StartTime))
+
+	require.NoError(t, items[1].Err)
+	require.Equal(t, "Holiday", items[1].Event.Title)
+	require.Equal(t, 24*time.Hour, items[1].Event.EndTime.Sub(items[1].Event.StartTime))
+	// floating dates and times are in the given time zone
+	require.True(t, time.Date(2021, time.September, 6, 0, 0, 0, 0, tokyo).Equal(items[1].Event.StartTime))
+
+	require.ErrorIs(t, items[2].Err, ErrMissingProperty)
+	require.ErrorIs(t, items[3].Err, ErrInvalidProperty)
+	require.NoError(t, items[4].Err)
+	require.True(t, time.Date(2021, time.September, 6, 10, 0, 0, 0, tokyo).Equal(items[4].Event.StartTime))
+	require.Empty(t, items[4].Event.TimeZone)
+}
+
+func TestDecodeMalformedCalendar(t *testing.T) {
+	for _, calendar := range []string{
+		"",
+		"BEGIN:VEVENT\r\nEND:VEVENT\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VEVENT\r\n",
+		"BEGIN:VCALENDAR\r\nnot a content line\r\nEND:VCALENDAR\r\n",
+	} {
+		_, err := Decode(strings.NewReader(calendar), time.UTC)
+		require.ErrorIs(t, err, ErrMalformedCalendar, calendar)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"PT15M":    15 * time.Minute,
+		"-PT15M":   -15 * time.Minute,
+		"P1DT2H":   26 * time.Hour,
+		"P2W":      14 * 24 * time.Hour,
+		"PT1H0M5S": time.Hour + 5*time.Second,
+	} {
+		d, err := parseDuration(value)
+		require.NoError(t, err, value)
+		require.Equal(t, expected, d, value)
+	}
+	for _, value := range []string{"", "P", "PT", "1H", "PT5", "P1H", "PT1D"} {
+		_, err := parseDuration(value)
+		require.ErrorIs(t, err, ErrInvalidProperty, value)
+	}
+}
@@ -0,0 +1,150 @@
+package amqpqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+var (
+	_ queue.Broker    = (*Broker)(nil)
+	_ queue.Publisher = (*publisher)(nil)
+	_ queue.Consumer  = (*consumer)(nil)
+	_ queue.Delivery  = (*delivery)(nil)
+
+	ErrDeliveriesClosed = errors.New("amqp deliveries channel closed")
+)
+
+// Broker - AMQP 0-9-1 (RabbitMQ) adapter.
+type Broker struct {
+	conn *amqp.Connection
+	// channel is not safe for concurrent publishing, so all publishes are serialized
+	mu      sync.Mutex
+	channel *amqp.Channel
+}
+
+func NewBroker() *Broker {
+	return &Broker{}
+}
+
+func (b *Broker) Connect(ctx context.Context, uri string) error {
+	conn, err := amqp.DialConfig(uri, amqp.Config{
+		Dial: func(network, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to amqp broker: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to open amqp channel: %w", err)
+	}
+	b.conn = conn
+	b.channel = channel
+	return nil
+}
+
+func (b *Broker) Close() error {
+	if err := b.channel.Close(); err != nil {
+		zap.L().Error("error during amqp channel closing", zap.Error(err))
+	}
+	if err := b.conn.Close(); err != nil {
+		return fmt.Errorf("error during amqp connection closing: %w", err)
+	}
+	return nil
+}
+
+// Declare - declares durable direct exchange, durable queue and binds them with routing key.
+func (b *Broker) Declare(ctx context.Context, topology queue.Topology) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.channel.ExchangeDeclare(topology.Exchange, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", topology.Exchange, err)
+	}
+	if _, err := b.channel.QueueDeclare(topology.Queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", topology.Queue, err)
+	}
+	if err := b.channel.QueueBind(topology.Queue, topology.RoutingKey, topology.Exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s to exchange %s: %w", topology.Queue, topology.Exchange, err)
+	}
+	return nil
+}
+
+func (b *Broker) Publisher(topology queue.Topology) queue.Publisher {
+	return &publisher{broker: b, exchange: topology.Exchange, routingKey: topology.RoutingKey}
+}
+
+func (b *Broker) Consumer(topology queue.Topology) queue.Consumer {
+	return &consumer{broker: b, queueName: topology.Queue}
+}
+
+type publisher struct {
+	broker     *Broker
+	exchange   string
+	routingKey string
+}
+
+func (p *publisher) Publish(ctx context.Context, body []byte) error {
+	p.broker.mu.Lock()
+	defer p.broker.mu.Unlock()
+	err := p.broker.channel.Publish(p.exchange, p.routingKey, false, false, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	if err != nil {
+		return fmt.Errorf("error during publishing message: %w", err)
+	}
+	return nil
+}
+
+type consumer struct {
+	broker    *Broker
+	queueName string
+}
+
+func (c *consumer) Consume(ctx context.Context, handler queue.Handler) error {
+	c.broker.mu.Lock()
+	deliveries, err := c.broker.channel.Consume(c.queueName, "", false, false, false, false, nil)
+	c.broker.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to start consuming queue %s: %w", c.queueName, err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				return ErrDeliveriesClosed
+			}
+			handler(ctx, &delivery{d})
+		}
+	}
+}
+
+type delivery struct {
+	d amqp.Delivery
+}
+
+func (d *delivery) Body() []byte {
+	return d.d.Body
+}
+
+func (d *delivery) Ack() error {
+	return d.d.Ack(false)
+}
+
+func (d *delivery) Nack(requeue bool) error {
+	return d.d.Nack(false, requeue)
+}
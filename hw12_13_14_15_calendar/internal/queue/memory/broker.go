@@ -0,0 +1,228 @@
+package memoryqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue"
+)
+
+var (
+	_ queue.Broker    = (*Broker)(nil)
+	_ queue.Publisher = (*publisher)(nil)
+	_ queue.Consumer  = (*consumer)(nil)
+	_ queue.Delivery  = (*delivery)(nil)
+
+	ErrExchangeNotFound = errors.New("exchange not found")
+	ErrQueueNotFound    = errors.New("queue not found")
+)
+
+// Broker - in-process message broker with direct exchanges,
+// it is mostly used for running processes without a real message broker and in tests.
+type Broker struct {
+	mu sync.RWMutex
+	// bindings - exchange name -> routing key -> bound queue names
+	bindings map[string]map[string][]string
+	queues   map[string]*memoryQueue
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		bindings: make(map[string]map[string][]string),
+		queues:   make(map[string]*memoryQueue),
+	}
+}
+
+func (b *Broker) Declare(ctx context.Context, topology queue.Topology) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.queues[topology.Queue]; !ok {
+		b.queues[topology.Queue] = newMemoryQueue()
+	}
+	routes, ok := b.bindings[topology.Exchange]
+	if !ok {
+		routes = make(map[string][]string)
+		b.bindings[topology.Exchange] = routes
+	}
+	for _, queueName := range routes[topology.RoutingKey] {
+		if queueName == topology.Queue {
+			return nil
+		}
+	}
+	routes[topology.RoutingKey] = append(routes[topology.RoutingKey], topology.Queue)
+	return nil
+}
+
+func (b *Broker) Publisher(topology queue.Topology) queue.Publisher {
+	return &publisher{broker: b, exchange: topology.Exchange, routingKey: topology.RoutingKey}
+}
+
+func (b *Broker) Consumer(topology queue.Topology) queue.Consumer {
+	return &consumer{broker: b, queueName: topology.Queue}
+}
+
+// Len - returns number of messages waiting for delivery in the queue.
+func (b *Broker) Len(queueName string) int {
+	q, err := b.queue(queueName)
+	if err != nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+// Unacked - returns number of delivered but not yet acknowledged messages in the queue.
+func (b *Broker) Unacked(queueName string) int {
+	q, err := b.queue(queueName)
+	if err != nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.unacked
+}
+
+func (b *Broker) queue(queueName string) (*memoryQueue, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	q, ok := b.queues[queueName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrQueueNotFound, queueName)
+	}
+	return q, nil
+}
+
+type publisher struct {
+	broker     *Broker
+	exchange   string
+	routingKey string
+}
+
+// Publish - routes message into all queues bound to the exchange with publisher routing key.
+// Message is dropped if there are no such queues, just like a real broker does.
+func (p *publisher) Publish(ctx context.Context, body []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.broker.mu.RLock()
+	defer p.broker.mu.RUnlock()
+	routes, ok := p.broker.bindings[p.exchange]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrExchangeNotFound, p.exchange)
+	}
+	for _, queueName := range routes[p.routingKey] {
+		p.broker.queues[queueName].push(body)
+	}
+	return nil
+}
+
+type consumer struct {
+	broker    *Broker
+	queueName string
+}
+
+func (c *consumer) Consume(ctx context.Context, handler queue.Handler) error {
+	q, err := c.broker.queue(c.queueName)
+	if err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		if body, ok := q.pop(); ok {
+			handler(ctx, &delivery{queue: q, body: body})
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.ready:
+		}
+	}
+	return nil
+}
+
+type memoryQueue struct {
+	mu       sync.Mutex
+	messages [][]byte
+	unacked  int
+	// ready - signals waiting consumers that new message was pushed
+	ready chan struct{}
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *memoryQueue) push(body []byte) {
+	q.mu.Lock()
+	q.messages = append(q.messages, body)
+	q.mu.Unlock()
+	q.signal()
+}
+
+// pushFront - returns rejected message to the head of the queue, so it will be delivered next.
+func (q *memoryQueue) pushFront(body []byte) {
+	q.mu.Lock()
+	q.messages = append([][]byte{body}, q.messages...)
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *memoryQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (q *memoryQueue) pop() ([]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.messages) == 0 {
+		return nil, false
+	}
+	body := q.messages[0]
+	q.messages = q.messages[1:]
+	q.unacked++
+	return body, true
+}
+
+type delivery struct {
+	once  sync.Once
+	queue *memoryQueue
+	body  []byte
+}
+
+func (d *delivery) Body() []byte {
+	return d.body
+}
+
+func (d *delivery) Ack() error {
+	return d.settle(func() {})
+}
+
+func (d *delivery) Nack(requeue bool) error {
+	return d.settle(func() {
+		if requeue {
+			d.queue.pushFront(d.body)
+		}
+	})
+}
+
+// settle - removes delivery from unacked ones, every delivery could be settled only once.
+func (d *delivery) settle(after func()) error {
+	settled := false
+	d.once.Do(func() {
+		d.queue.mu.Lock()
+		d.queue.unacked--
+		d.queue.mu.Unlock()
+		after()
+		settled = true
+	})
+	if !settled {
+		return queue.ErrAlreadyAcknowledged
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package memoryqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue"
+	"github.com/stretchr/testify/require"
+)
+
+var testTopology = queue.Topology{Exchange: "exchange", Queue: "queue", RoutingKey: "key"}
+
+// consumeOne - consumes single delivery from the queue and stops consuming.
+func consumeOne(t *testing.T, consumer queue.Consumer) queue.Delivery {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var result queue.Delivery
+	err := consumer.Consume(ctx, func(ctx context.Context, delivery queue.Delivery) {
+		result = delivery
+		cancel()
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result, "no message consumed")
+	return result
+}
+
+func TestPublishToUndeclaredExchange(t *testing.T) {
+	broker := NewBroker()
+	err := broker.Publisher(testTopology).Publish(context.Background(), []byte("message"))
+	require.ErrorIs(t, err, ErrExchangeNotFound)
+	err = broker.Consumer(testTopology).Consume(context.Background(), func(context.Context, queue.Delivery) {})
+	require.ErrorIs(t, err, ErrQueueNotFound)
+}
+
+func TestDeclareIsIdempotent(t *testing.T) {
+	broker := NewBroker()
+	require.NoError(t, broker.Declare(context.Background(), testTopology))
+	require.NoError(t, broker.Declare(context.Background(), testTopology))
+	require.NoError(t, broker.Publisher(testTopology).Publish(context.Background(), []byte("message")))
+	require.Equal(t, 1, broker.Len(testTopology.Queue))
+}
+
+func TestNotRoutedMessageDropped(t *testing.T) {
+	broker := NewBroker()
+	require.NoError(t, broker.Declare(context.Background(), testTopology))
+	other := queue.Topology{Exchange: testTopology.Exchange, RoutingKey: "other key"}
+	require.NoError(t, broker.Publisher(other).Publish(context.Background(), []byte("message")))
+	require.Equal(t, 0, broker.Len(testTopology.Queue))
+}
+
+func TestAckNack(t *testing.T) {
+	broker := NewBroker()
+	require.NoError(t, broker.Declare(context.Background(), testTopology))
+	publisher := broker.Publisher(testTopology)
+	consumer := broker.Consumer(testTopology)
+	require.NoError(t, publisher.Publish(context.Background(), []byte("first")))
+	require.NoError(t, publisher.Publish(context.Background(), []byte("second")))
+
+	// requeued message is delivered again before others
+	delivery := consumeOne(t, consumer)
+	require.Equal(t, "first", string(delivery.Body()))
+	require.Equal(t, 1, broker.Unacked(testTopology.Queue))
+	require.NoError(t, delivery.Nack(true))
+	require.ErrorIs(t, delivery.Ack(), queue.ErrAlreadyAcknowledged)
+	require.Equal(t, 0, broker.Unacked(testTopology.Queue))
+	require.Equal(t, 2, broker.Len(testTopology.Queue))
+
+	delivery = consumeOne(t, consumer)
+	require.Equal(t, "first", string(delivery.Body()))
+	require.NoError(t, delivery.Ack())
+
+	// rejected without requeue message is dropped
+	delivery = consumeOne(t, consumer)
+	require.Equal(t, "second", string(delivery.Body()))
+	require.NoError(t, delivery.Nack(false))
+	require.Equal(t, 0, broker.Len(testTopology.Queue))
+	require.Equal(t, 0, broker.Unacked(testTopology.Queue))
+}
+
+func TestConsumerWaitsForMessages(t *testing.T) {
+	broker := NewBroker()
+	require.NoError(t, broker.Declare(context.Background(), testTopology))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = broker.Publisher(testTopology).Publish(context.Background(), []byte("late message"))
+	}()
+	delivery := consumeOne(t, broker.Consumer(testTopology))
+	require.Equal(t, "late message", string(delivery.Body()))
+	require.NoError(t, delivery.Ack())
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"context"
+	"errors"
+)
+
+// Processes must depend on interfaces below and not on a concrete broker client.
+
+var ErrAlreadyAcknowledged = errors.New("delivery is already acknowledged")
+
+// Topology - broker structures required for passing messages from publishers to consumers.
+// Messages published into Exchange with RoutingKey are routed into Queue.
+// All structures are declared durable, so they survive broker restarts.
+type Topology struct {
+	Exchange   string
+	Queue      string
+	RoutingKey string
+}
+
+// Broker - message broker connection which is able to declare topology,
+// publish and consume messages.
+type Broker interface {
+	// Declare - creates all topology structures if they are not exist yet.
+	Declare(ctx context.Context, topology Topology) error
+	Publisher(topology Topology) Publisher
+	Consumer(topology Topology) Consumer
+}
+
+// Publisher - sends messages to a message broker.
+type Publisher interface {
+	Publish(ctx context.Context, body []byte) error
+}
+
+// Delivery - consumed message, every delivery must be either acked or nacked by the consumer.
+type Delivery interface {
+	Body() []byte
+	// Ack - confirms that message is processed and can be removed from the queue.
+	Ack() error
+	// Nack - rejects message, if requeue is true then message will be delivered again.
+	Nack(requeue bool) error
+}
+
+// Handler - processes a single consumed message.
+// Handler is responsible for acking or nacking the delivery.
+type Handler func(ctx context.Context, delivery Delivery)
+
+// Consumer - reads messages from a message broker.
+type Consumer interface {
+	// Consume - passes every received message to the handler until ctx is done.
+	Consume(ctx context.Context, handler Handler) error
+}
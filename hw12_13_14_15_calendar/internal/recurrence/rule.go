@@ -0,0 +1,324 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported subset of RFC 5545 RRULE: FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, COUNT, UNTIL and BYDAY.
+
+var (
+	ErrInvalidRule     = errors.New("invalid recurrence rule")
+	ErrUnsupportedRule = errors.New("unsupported recurrence rule")
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+const (
+	untilDateTimeLayout = "20060102T150405Z"
+	untilDateLayout     = "20060102"
+	// maxPeriods - protection from endless iteration over the rule periods which never produce an occurrence
+	maxPeriods = 1 << 20
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum - BYDAY rule part value.
+// N is an ordinal of the weekday inside the month (negative counts from the month end),
+// zero N means every such weekday, N is allowed only for MONTHLY rules.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+func (w WeekdayNum) String() string {
+	day := strings.ToUpper(w.Weekday.String()[:2])
+	if w.N == 0 {
+		return day
+	}
+	return strconv.Itoa(w.N) + day
+}
+
+// Rule - parsed recurrence rule.
+// Zero Count and zero Until mean that the series is infinite.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	// Until - last moment (inclusive) when an occurrence could start
+	Until time.Time
+	ByDay []WeekdayNum
+}
+
+// Parse - parses RRULE value, "RRULE:" prefix is optional.
+// UNTIL must be either UTC date-time or a date, the date means the whole day (UTC) is included.
+func Parse(value string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return Rule{}, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return Rule{}, fmt.Errorf("%w: malformed rule part %q", ErrInvalidRule, part)
+		}
+		if err := rule.setPart(strings.ToUpper(kv[0]), strings.ToUpper(kv[1])); err != nil {
+			return Rule{}, err
+		}
+	}
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL must not occur in the same rule", ErrInvalidRule)
+	}
+	if rule.Freq != Monthly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return Rule{}, fmt.Errorf("%w: BYDAY ordinals are allowed only for MONTHLY rules", ErrInvalidRule)
+			}
+		}
+	}
+	return rule, nil
+}
+
+func (r *Rule) setPart(name, value string) error {
+	switch name {
+	case "FREQ":
+		switch freq := Frequency(value); freq {
+		case Daily, Weekly, Monthly:
+			r.Freq = freq
+		default:
+			return fmt.Errorf("%w: FREQ=%s", ErrUnsupportedRule, value)
+		}
+	case "INTERVAL":
+		interval, err := strconv.Atoi(value)
+		if err != nil || interval < 1 {
+			return fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+		}
+		r.Interval = interval
+	case "COUNT":
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+		}
+		r.Count = count
+	case "UNTIL":
+		until, err := parseUntil(value)
+		if err != nil {
+			return err
+		}
+		r.Until = until
+	case "BYDAY":
+		for _, v := range strings.Split(value, ",") {
+			day, err := parseWeekdayNum(v)
+			if err != nil {
+				return err
+			}
+			r.ByDay = append(r.ByDay, day)
+		}
+	default:
+		return fmt.Errorf("%w: rule part %s", ErrUnsupportedRule, name)
+	}
+	return nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse(untilDateTimeLayout, value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse(untilDateLayout, value); err == nil {
+		return until.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: UNTIL must be in %s or %s format", ErrInvalidRule, untilDateTimeLayout, untilDateLayout)
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, value)
+	}
+	weekday, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, value)
+	}
+	day := WeekdayNum{Weekday: weekday}
+	if ordinal := value[:len(value)-2]; ordinal != "" {
+		n, err := strconv.Atoi(ordinal)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, value)
+		}
+		day.N = n
+	}
+	return day, nil
+}
+
+// String - returns RRULE value without "RRULE:" prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilDateTimeLayout))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between - returns start times of the series occurrences which are inside [from, to) interval.
+// Series starts at dtstart, dtstart is expected to match the rule.
+func (r Rule) Between(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+	r.iterate(dtstart, to, func(start time.Time) {
+		if !start.Before(from) {
+			result = append(result, start)
+		}
+	})
+	return result
+}
+
+// Last - returns start time of the last series occurrence, ok is false if the series is infinite.
+func (r Rule) Last(dtstart time.Time) (last time.Time, ok bool) {
+	if r.Count == 0 && r.Until.IsZero() {
+		return time.Time{}, false
+	}
+	last = dtstart
+	r.iterate(dtstart, time.Time{}, func(start time.Time) {
+		last = start
+	})
+	return last, true
+}
+
+// iterate - calls yield for every occurrence start before limit in chronological order,
+// zero limit means iterating until COUNT or UNTIL is reached.
+func (r Rule) iterate(dtstart, limit time.Time, yield func(start time.Time)) {
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		periodStart, candidates := r.period(dtstart, period)
+		if !limit.IsZero() && !periodStart.Before(limit) {
+			return
+		}
+		if !r.Until.IsZero() && periodStart.After(r.Until) {
+			return
+		}
+		for _, start := range candidates {
+			if start.Before(dtstart) {
+				continue
+			}
+			if (!limit.IsZero() && !start.Before(limit)) || (!r.Until.IsZero() && start.After(r.Until)) {
+				return
+			}
+			yield(start)
+			count++
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// period - returns start of the n-th rule period and sorted occurrence candidates inside it.
+func (r Rule) period(dtstart time.Time, n int) (time.Time, []time.Time) {
+	y, m, d := dtstart.Date()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+	}
+	midnight := func(t time.Time) time.Time {
+		year, month, day := t.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+
+	switch r.Freq {
+	case Daily:
+		day := at(y, m, d+n*r.Interval)
+		if len(r.ByDay) > 0 && !r.hasWeekday(day.Weekday()) {
+			return midnight(day), nil
+		}
+		return midnight(day), []time.Time{day}
+	case Weekly:
+		// weeks start on Monday
+		weekStart := at(y, m, d-(int(dtstart.Weekday())+6)%7+7*n*r.Interval)
+		if len(r.ByDay) == 0 {
+			return midnight(weekStart), []time.Time{weekStart.AddDate(0, 0, (int(dtstart.Weekday())+6)%7)}
+		}
+		var candidates []time.Time
+		for offset := 0; offset < 7; offset++ {
+			day := weekStart.AddDate(0, 0, offset)
+			if r.hasWeekday(day.Weekday()) {
+				candidates = append(candidates, day)
+			}
+		}
+		return midnight(weekStart), candidates
+	default:
+		monthStart := time.Date(y, m+time.Month(n*r.Interval), 1, 0, 0, 0, 0, dtstart.Location())
+		year, month, _ := monthStart.Date()
+		daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+		if len(r.ByDay) == 0 {
+			if d > daysInMonth {
+				return monthStart, nil
+			}
+			return monthStart, []time.Time{at(year, month, d)}
+		}
+		var candidates []time.Time
+		for day := 1; day <= daysInMonth; day++ {
+			if r.matchesMonthDay(year, month, day, daysInMonth) {
+				candidates = append(candidates, at(year, month, day))
+			}
+		}
+		return monthStart, candidates
+	}
+}
+
+func (r Rule) hasWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matchesMonthDay(year int, month time.Month, day, daysInMonth int) bool {
+	weekday := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+	for _, byDay := range r.ByDay {
+		if byDay.Weekday != weekday {
+			continue
+		}
+		switch {
+		case byDay.N == 0:
+			return true
+		case byDay.N > 0 && (day-1)/7+1 == byDay.N:
+			return true
+		case byDay.N < 0 && (daysInMonth-day)/7+1 == -byDay.N:
+			return true
+		}
+	}
+	return false
+}
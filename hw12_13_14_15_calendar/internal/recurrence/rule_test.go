@@ -0,0 +1,167 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(day int, hour int) time.Time {
+	return time.Date(2021, time.September, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	t.Run("valid rules", func(t *testing.T) {
+		for _, value := range []string{
+			"FREQ=DAILY",
+			"FREQ=DAILY;INTERVAL=2;COUNT=10",
+			"FREQ=WEEKLY;UNTIL=20211231T000000Z;BYDAY=MO,WE,FR",
+			"FREQ=MONTHLY;BYDAY=-1FR",
+		} {
+			rule, err := Parse("RRULE:" + value)
+			require.NoError(t, err, value)
+			require.Equal(t, value, rule.String())
+		}
+	})
+
+	t.Run("until date includes the whole day", func(t *testing.T) {
+		rule, err := Parse("FREQ=DAILY;UNTIL=20210930")
+		require.NoError(t, err)
+		require.True(t, rule.Until.Equal(date(30, 0).AddDate(0, 0, 1).Add(-time.Nanosecond)))
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;COUNT=-1",
+			"FREQ=DAILY;COUNT=2;UNTIL=20211231T000000Z",
+			"FREQ=DAILY;UNTIL=tomorrow",
+			"FREQ=WEEKLY;BYDAY=XX",
+			"FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=MONTHLY;BYDAY=6MO",
+			"FREQ",
+		} {
+			_, err := Parse(value)
+			require.ErrorIs(t, err, ErrInvalidRule, value)
+		}
+	})
+
+	t.Run("unsupported rules", func(t *testing.T) {
+		for _, value := range []string{
+			"FREQ=YEARLY",
+			"FREQ=MONTHLY;BYMONTHDAY=15",
+		} {
+			_, err := Parse(value)
+			require.ErrorIs(t, err, ErrUnsupportedRule, value)
+		}
+	})
+}
+
+func TestBetween(t *testing.T) {
+	// 2021-09-01 is Wednesday
+	dtstart := date(1, 10)
+
+	tests := []struct {
+		name     string
+		rule     string
+		from, to time.Time
+		expected []time.Time
+	}{
+		{
+			name:     "daily",
+			rule:     "FREQ=DAILY",
+			from:     date(3, 0),
+			to:       date(6, 0),
+			expected: []time.Time{date(3, 10), date(4, 10), date(5, 10)},
+		},
+		{
+			name:     "daily with interval and count",
+			rule:     "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			from:     date(1, 0),
+			to:       date(30, 0),
+			expected: []time.Time{date(1, 10), date(3, 10), date(5, 10)},
+		},
+		{
+			name:     "daily on weekdays",
+			rule:     "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			from:     date(3, 0),
+			to:       date(8, 0),
+			expected: []time.Time{date(3, 10), date(6, 10), date(7, 10)},
+		},
+		{
+			name:     "weekly until",
+			rule:     "FREQ=WEEKLY;UNTIL=20210915T100000Z",
+			from:     date(1, 0),
+			to:       date(30, 0),
+			expected: []time.Time{date(1, 10), date(8, 10), date(15, 10)},
+		},
+		{
+			name:     "weekly by days skips days before start",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			from:     date(1, 0),
+			to:       date(16, 0),
+			expected: []time.Time{date(1, 10), date(13, 10), date(15, 10)},
+		},
+		{
+			name: "monthly by day of month",
+			rule: "FREQ=MONTHLY;COUNT=3",
+			from: date(1, 0),
+			to:   date(1, 0).AddDate(1, 0, 0),
+			expected: []time.Time{
+				date(1, 10),
+				time.Date(2021, time.October, 1, 10, 0, 0, 0, time.UTC),
+				time.Date(2021, time.November, 1, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "monthly by last friday",
+			rule: "FREQ=MONTHLY;BYDAY=-1FR",
+			from: date(1, 0),
+			to:   time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				date(24, 10),
+				time.Date(2021, time.October, 29, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "count includes occurrences before interval",
+			rule:     "FREQ=DAILY;COUNT=3",
+			from:     date(3, 0),
+			to:       date(30, 0),
+			expected: []time.Time{date(3, 10)},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := Parse(tc.rule)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rule.Between(dtstart, tc.from, tc.to))
+		})
+	}
+}
+
+func TestMonthlySkipsMonthsWithoutDay(t *testing.T) {
+	rule, err := Parse("FREQ=MONTHLY;COUNT=2")
+	require.NoError(t, err)
+	dtstart := time.Date(2021, time.August, 31, 10, 0, 0, 0, time.UTC)
+	require.Equal(t, []time.Time{dtstart, time.Date(2021, time.October, 31, 10, 0, 0, 0, time.UTC)},
+		rule.Between(dtstart, dtstart, dtstart.AddDate(1, 0, 0)))
+}
+
+func TestLast(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;COUNT=3")
+	require.NoError(t, err)
+	last, ok := rule.Last(date(1, 10))
+	require.True(t, ok)
+	require.Equal(t, date(15, 10), last)
+
+	rule, err = Parse("FREQ=WEEKLY")
+	require.NoError(t, err)
+	_, ok = rule.Last(date(1, 10))
+	require.False(t, ok)
+}
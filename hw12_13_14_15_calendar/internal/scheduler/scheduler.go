@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"go.uber.org/zap"
+)
+
+type Scheduler struct {
+	repo      app.EventRepository
+	publisher queue.Publisher
+	cfg       config.SchedulingConfig
+	retention config.RetentionConfig
+	// lastScan - end of the previously scanned interval, next scan starts from here
+	lastScan time.Time
+}
+
+func New(repo app.EventRepository, publisher queue.Publisher, cfg config.SchedulingConfig, retention config.RetentionConfig) *Scheduler {
+	return &Scheduler{repo: repo, publisher: publisher, cfg: cfg, retention: retention}
+}
+
+// Run function is periodically scanning events storage and purging old events until ctx is done.
+// This function is blocking so it must be called in separate goroutine.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.lastScan = time.Now()
+	scanTicker := time.NewTicker(s.cfg.ScanInterval)
+	defer scanTicker.Stop()
+	purgeTicker := time.NewTicker(s.cfg.PurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-scanTicker.C:
+			if err := s.Scan(ctx, now); err != nil {
+				zap.L().Error("error during events scan", zap.Error(err))
+			}
+		case now := <-purgeTicker.C:
+			if err := s.Purge(ctx, now); err != nil {
+				zap.L().Error("error during old events purge", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Purge - removes events ended earlier than retention period before now.
+func (s *Scheduler) Purge(ctx context.Context, now time.Time) error {
+	_, err := app.PurgeEvents(ctx, s.repo, now.Add(-s.retention.Period), s.retention.DryRun)
+	return err
+}
+
+// Scan - publishes notifications for all events which notification time
+// is inside [lastScan, now) interval.
+// If some notification failed to publish, the interval will be rescanned during the next Scan.
+func (s *Scheduler) Scan(ctx context.Context, now time.Time) error {
+	events, err := s.repo.FindEventsToNotify(ctx, s.lastScan, now)
+	if err != nil {
+		return fmt.Errorf("error during finding events to notify: %w", err)
+	}
+	for _, event := range events {
+		if err := s.notify(ctx, event); err != nil {
+			return err
+		}
+	}
+	s.lastScan = now
+	return nil
+}
+
+func (s *Scheduler) notify(ctx context.Context, event storage.Event) error {
+	body, err := json.Marshal(storage.NewNotification(event))
+	if err != nil {
+		return fmt.Errorf("error during notification serialization: %w", err)
+	}
+	if err := s.publisher.Publish(ctx, body); err != nil {
+		return fmt.Errorf("error during notification publishing for event %s: %w", event.ID, err)
+	}
+	zap.L().Info("notification published", zap.String("event_id", event.ID), zap.String("owner_id", event.OwnerID))
+	return nil
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/bxcodec/faker/v3"
+	"github.com/stretchr/testify/suite"
+)
+
+var errPublishFailed = errors.New("publish failed")
+
+type stubPublisher struct {
+	messages [][]byte
+	err      error
+}
+
+func (p *stubPublisher) Publish(ctx context.Context, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, body)
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	suite.Run(t, new(schedulerSuite))
+}
+
+type schedulerSuite struct {
+	suite.Suite
+	storage   *memorystorage.MemStorage
+	publisher *stubPublisher
+	scheduler *Scheduler
+	now       time.Time
+	ctx       context.Context
+}
+
+func (s *schedulerSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.now = time.Now().Truncate(time.Nanosecond)
+	s.storage = memorystorage.NewMemStorage()
+	s.publisher = &stubPublisher{}
+	s.scheduler = New(s.storage, s.publisher, config.SchedulingConfig{
+		ScanInterval:  time.Minute,
+		PurgeInterval: time.Hour,
+	}, config.RetentionConfig{
+		Period: 24 * time.Hour,
+	})
+	s.scheduler.lastScan = s.now
+}
+
+func (s *schedulerSuite) addEvent(startTime time.Time) storage.Event {
+	var event storage.Event
+	s.Require().NoError(faker.FakeData(&event))
+	event.StartTime = startTime
+	event.EndTime = startTime.Add(time.Hour)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, event))
+	return event
+}
+
+func (s *schedulerSuite) TestOnlyStartedInIntervalEventsNotified() {
+	s.addEvent(s.now.Add(-time.Minute))
+	expected := s.addEvent(s.now.Add(30 * time.Second))
+	s.addEvent(s.now.Add(2 * time.Minute))
+
+	err := s.scheduler.Scan(s.ctx, s.now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(s.publisher.messages, 1)
+
+	var notification storage.Notification
+	s.Require().NoError(json.Unmarshal(s.publisher.messages[0], &notification))
+	s.Require().Equal(expected.ID, notification.EventID)
+	s.Require().Equal(expected.Title, notification.Title)
+	s.Require().Equal(expected.OwnerID, notification.OwnerID)
+	s.Require().True(expected.StartTime.Equal(notification.StartTime))
+}
+
+func (s *schedulerSuite) TestEventNotifiedBeforeStart() {
+	event := s.addEvent(s.now.Add(time.Hour))
+	event.NotifyBefore = 59*time.Minute + 30*time.Second
+	s.Require().NoError(s.storage.UpdateEvent(s.ctx, event))
+
+	err := s.scheduler.Scan(s.ctx, s.now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(s.publisher.messages, 1)
+}
+
+func (s *schedulerSuite) TestEventNotifiedOnlyOnce() {
+	s.addEvent(s.now.Add(30 * time.Second))
+
+	s.Require().NoError(s.scheduler.Scan(s.ctx, s.now.Add(time.Minute)))
+	s.Require().NoError(s.scheduler.Scan(s.ctx, s.now.Add(2*time.Minute)))
+	s.Require().Len(s.publisher.messages, 1)
+}
+
+func (s *schedulerSuite) TestIntervalRescannedAfterPublishError() {
+	s.addEvent(s.now.Add(30 * time.Second))
+
+	s.publisher.err = errPublishFailed
+	err := s.scheduler.Scan(s.ctx, s.now.Add(time.Minute))
+	s.Require().ErrorIs(err, errPublishFailed)
+	s.Require().Empty(s.publisher.messages)
+
+	s.publisher.err = nil
+	s.Require().NoError(s.scheduler.Scan(s.ctx, s.now.Add(2*time.Minute)))
+	s.Require().Len(s.publisher.messages, 1)
+}
+
+func (s *schedulerSuite) TestPurge() {
+	old := s.addEvent(s.now.Add(-48 * time.Hour))
+	fresh := s.addEvent(s.now.Add(-12 * time.Hour))
+
+	s.scheduler.retention.DryRun = true
+	s.Require().NoError(s.scheduler.Purge(s.ctx, s.now))
+	s.Require().Equal(int64(2), s.storage.Size(s.ctx))
+
+	s.scheduler.retention.DryRun = false
+	s.Require().NoError(s.scheduler.Purge(s.ctx, s.now))
+	s.Require().Equal(int64(1), s.storage.Size(s.ctx))
+	events, err := s.storage.FindEventsByID(s.ctx, old.OwnerID, old.ID)
+	s.Require().NoError(err)
+	s.Require().Empty(events)
+	events, err = s.storage.FindEventsByID(s.ctx, fresh.OwnerID, fresh.ID)
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+}
@@ -0,0 +1,34 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"go.uber.org/zap"
+)
+
+var _ Notifier = (*LogNotifier)(nil)
+
+// LogNotifier - "delivers" notifications by writing them into the log and the given writer (e.g. STDOUT).
+type LogNotifier struct {
+	out io.Writer
+}
+
+func NewLogNotifier(out io.Writer) *LogNotifier {
+	return &LogNotifier{out: out}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification storage.Notification) error {
+	zap.L().Info("notification",
+		zap.String("event_id", notification.EventID),
+		zap.String("title", notification.Title),
+		zap.Time("start_time", notification.StartTime),
+		zap.String("owner_id", notification.OwnerID),
+	)
+	_, err := fmt.Fprintf(n.out, "user %s: event %q starts at %s\n",
+		notification.OwnerID, notification.Title, notification.StartTime.Format(time.RFC3339))
+	return err
+}
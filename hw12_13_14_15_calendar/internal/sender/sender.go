@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"go.uber.org/zap"
+)
+
+// Notifier - delivers notification to the user.
+type Notifier interface {
+	Notify(ctx context.Context, notification storage.Notification) error
+}
+
+type StatusRepository interface {
+	SaveNotificationStatus(ctx context.Context, status storage.NotificationStatus) error
+}
+
+type Sender struct {
+	consumer queue.Consumer
+	notifier Notifier
+	statuses StatusRepository
+}
+
+func New(consumer queue.Consumer, notifier Notifier, statuses StatusRepository) *Sender {
+	return &Sender{consumer: consumer, notifier: notifier, statuses: statuses}
+}
+
+// Run function is consuming notifications until ctx is done.
+// This function is blocking so it must be called in separate goroutine.
+func (s *Sender) Run(ctx context.Context) error {
+	return s.consumer.Consume(ctx, s.handle)
+}
+
+// handle - delivers notification and saves delivery status.
+// Malformed messages are dropped, messages with not saved status are requeued to be delivered again.
+func (s *Sender) handle(ctx context.Context, delivery queue.Delivery) {
+	var notification storage.Notification
+	if err := json.Unmarshal(delivery.Body(), &notification); err != nil {
+		zap.L().Error("error during notification deserialization, message dropped", zap.Error(err))
+		s.settle(delivery.Nack(false))
+		return
+	}
+
+	status := storage.NotificationStatus{
+		EventID:   notification.EventID,
+		StartTime: notification.StartTime,
+		OwnerID:   notification.OwnerID,
+		Status:    storage.DeliveryStatusSent,
+	}
+	if err := s.notifier.Notify(ctx, notification); err != nil {
+		zap.L().Error("notification delivery failed", zap.String("event_id", notification.EventID), zap.Error(err))
+		status.Status = storage.DeliveryStatusFailed
+		status.Error = err.Error()
+	}
+	status.UpdatedAt = time.Now()
+
+	if err := s.statuses.SaveNotificationStatus(ctx, status); err != nil {
+		zap.L().Error("error during saving notification status, message requeued", zap.Error(err))
+		s.settle(delivery.Nack(true))
+		return
+	}
+	s.settle(delivery.Ack())
+}
+
+func (s *Sender) settle(err error) {
+	if err != nil {
+		zap.L().Error("error during message acknowledgement", zap.Error(err))
+	}
+}
@@ -0,0 +1,151 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue"
+	memoryqueue "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/queue/memory"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/bxcodec/faker/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var errDeliveryFailed = errors.New("delivery failed")
+
+type stubNotifier struct {
+	mu            sync.Mutex
+	notifications []storage.Notification
+	err           error
+}
+
+func (n *stubNotifier) Notify(ctx context.Context, notification storage.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestSender(t *testing.T) {
+	suite.Run(t, new(senderSuite))
+}
+
+var testTopology = queue.Topology{Exchange: "calendar", Queue: "notifications", RoutingKey: "notifications"}
+
+type senderSuite struct {
+	suite.Suite
+	broker     *memoryqueue.Broker
+	publisher  queue.Publisher
+	storage    *memorystorage.MemStorage
+	notifier   *stubNotifier
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+	done       chan struct{}
+}
+
+func (s *senderSuite) SetupTest() {
+	s.broker = memoryqueue.NewBroker()
+	s.ctx, s.cancelFunc = context.WithTimeout(context.Background(), 5*time.Second)
+	s.Require().NoError(s.broker.Declare(s.ctx, testTopology))
+	s.publisher = s.broker.Publisher(testTopology)
+	s.storage = memorystorage.NewMemStorage()
+	s.notifier = &stubNotifier{}
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		_ = New(s.broker.Consumer(testTopology), s.notifier, s.storage).Run(s.ctx)
+	}()
+}
+
+func (s *senderSuite) TearDownTest() {
+	s.cancelFunc()
+	<-s.done
+}
+
+func (s *senderSuite) publish(notification storage.Notification) {
+	body, err := json.Marshal(notification)
+	s.Require().NoError(err)
+	s.Require().NoError(s.publisher.Publish(s.ctx, body))
+}
+
+func (s *senderSuite) waitStatus(eventID string) storage.NotificationStatus {
+	var statuses []storage.NotificationStatus
+	s.Require().Eventually(func() bool {
+		var err error
+		statuses, err = s.storage.FindNotificationStatuses(s.ctx, eventID)
+		return err == nil && len(statuses) > 0
+	}, time.Second, 10*time.Millisecond)
+	s.Require().Len(statuses, 1)
+	return statuses[0]
+}
+
+func (s *senderSuite) fakeNotification() storage.Notification {
+	var notification storage.Notification
+	s.Require().NoError(faker.FakeData(&notification))
+	notification.EventID = faker.UUIDHyphenated()
+	notification.StartTime = time.Now().Truncate(time.Nanosecond)
+	return notification
+}
+
+func (s *senderSuite) TestNotificationSent() {
+	notification := s.fakeNotification()
+	s.publish(notification)
+
+	status := s.waitStatus(notification.EventID)
+	s.Require().Equal(storage.DeliveryStatusSent, status.Status)
+	s.Require().Equal(notification.OwnerID, status.OwnerID)
+	s.Require().True(notification.StartTime.Equal(status.StartTime))
+	s.Require().Empty(status.Error)
+
+	s.notifier.mu.Lock()
+	defer s.notifier.mu.Unlock()
+	s.Require().Len(s.notifier.notifications, 1)
+	s.Require().Equal(notification.Title, s.notifier.notifications[0].Title)
+}
+
+func (s *senderSuite) TestNotificationFailed() {
+	s.notifier.mu.Lock()
+	s.notifier.err = errDeliveryFailed
+	s.notifier.mu.Unlock()
+
+	notification := s.fakeNotification()
+	s.publish(notification)
+
+	status := s.waitStatus(notification.EventID)
+	s.Require().Equal(storage.DeliveryStatusFailed, status.Status)
+	s.Require().Equal(errDeliveryFailed.Error(), status.Error)
+}
+
+func (s *senderSuite) TestMalformedMessageSkipped() {
+	s.Require().NoError(s.publisher.Publish(s.ctx, []byte("not a json")))
+	notification := s.fakeNotification()
+	s.publish(notification)
+
+	status := s.waitStatus(notification.EventID)
+	s.Require().Equal(storage.DeliveryStatusSent, status.Status)
+	s.Require().Equal(0, s.broker.Len(testTopology.Queue))
+	s.Require().Equal(0, s.broker.Unacked(testTopology.Queue))
+}
+
+func TestLogNotifier(t *testing.T) {
+	out := new(bytes.Buffer)
+	notifier := NewLogNotifier(out)
+	err := notifier.Notify(context.Background(), storage.Notification{
+		EventID:   "event",
+		Title:     "Birthday",
+		StartTime: time.Date(2021, 4, 3, 0, 0, 0, 0, time.UTC),
+		OwnerID:   "owner",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "user owner: event \"Birthday\" starts at 2021-04-03T00:00:00Z\n", out.String())
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGateway - HTTP reverse proxy of the gRPC API, routes are defined by google.api.http annotations
+// of api/calendar_service.proto. Requests are proxied through conn, so they pass the same interceptors
+// as requests of gRPC clients, credentials, user ID and time zone headers are forwarded as request metadata.
+func NewGateway(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		// field names are the same as in proto and in the hand written HTTP API
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+	)
+	if err := pb.RegisterCalendarServiceHandler(ctx, mux, conn); err != nil {
+		return nil, fmt.Errorf("error during gateway handlers registration: %w", err)
+	}
+	return mux, nil
+}
+
+// gatewayHeaderMatcher - forwards user headers as metadata with the same names, the rest as grpc-gateway does.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	switch name := strings.ToLower(key); name {
+	case UserIDMetadataKey, TimeZoneMetadataKey, APIKeyMetadataKey:
+		return name, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// Dial - connects to the API started in this process, e.g. for serving it by the gateway.
+func (a API) Dial(ctx context.Context) (*grpc.ClientConn, error) {
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort("localhost", strconv.Itoa(a.port)), grpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("error during dialing grpc api: %w", err)
+	}
+	return conn, nil
+}
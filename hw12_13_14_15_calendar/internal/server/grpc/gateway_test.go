@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/bxcodec/faker/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *GRPCTestSuite) TestGateway() {
+	gateway, err := NewGateway(s.ctx, s.grpcClientConn)
+	s.Require().NoError(err)
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+	userID := faker.UUIDHyphenated()
+
+	created := new(pb.AddEventResponse)
+	s.gatewayRequest(server.URL, userID, "POST", "/api/v1/events",
+		`{"title": "Standup", "description": "daily", "start_time": "2021-09-06T10:00:00Z", "end_time": "2021-09-06T10:15:00Z"}`,
+		http.StatusOK, created)
+	s.Require().Equal("Standup", created.GetEvent().GetTitle())
+	s.Require().Equal(userID, created.GetEvent().GetOwnerId())
+	eventID := created.GetEvent().GetId()
+
+	// update mask is filled by the gateway with the fields of PATCH body
+	updated := new(pb.UpdateEventResponse)
+	s.gatewayRequest(server.URL, userID, "PATCH", "/api/v1/events/"+eventID+"?version=1",
+		`{"title": "Daily standup"}`, http.StatusOK, updated)
+	s.Require().Equal("Daily standup", updated.GetEvent().GetTitle())
+	s.Require().Equal("daily", updated.GetEvent().GetDescription())
+	s.Require().Equal(int64(2), updated.GetEvent().GetVersion())
+
+	found := new(pb.FindDayEventsResponse)
+	s.gatewayRequest(server.URL, userID, "GET", "/api/v1/events:day?day=2021-09-06T00:00:00Z&time_zone=UTC",
+		"", http.StatusOK, found)
+	s.Require().Len(found.GetEvents(), 1)
+	s.Require().Equal(eventID, found.GetEvents()[0].GetId())
+
+	s.gatewayRequest(server.URL, userID, "DELETE", "/api/v1/events/"+eventID, "", http.StatusOK, new(pb.DeleteEventResponse))
+	s.gatewayRequest(server.URL, userID, "DELETE", "/api/v1/events/"+eventID, "", http.StatusNotFound, nil)
+	s.gatewayRequest(server.URL, "", "GET", "/api/v1/events:day?day=2021-09-06T00:00:00Z", "", http.StatusUnauthorized, nil)
+}
+
+// gatewayRequest - makes request on behalf of the user and decodes response into res if it's not nil.
+func (s *GRPCTestSuite) gatewayRequest(url, userID, method, path, body string, expectedStatus int, res proto.Message) {
+	request, err := http.NewRequestWithContext(s.ctx, method, url+path, strings.NewReader(body))
+	s.Require().NoError(err)
+	if userID != "" {
+		request.Header.Set("X-User-ID", userID)
+	}
+	resp, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	s.Require().Equal(expectedStatus, resp.StatusCode, string(respBody))
+	if res != nil {
+		s.Require().NoError(protojson.Unmarshal(respBody, res))
+	}
+}
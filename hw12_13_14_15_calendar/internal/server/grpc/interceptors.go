@@ -0,0 +1,131 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/auth"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// UserIDMetadataKey - metadata key with ID of the user who makes the request, it is trusted only by auth.TrustedUserID.
+	UserIDMetadataKey = "x-user-id"
+	// APIKeyMetadataKey and AuthorizationMetadataKey - metadata keys with credentials, see auth.Credentials.
+	APIKeyMetadataKey        = "x-api-key"
+	AuthorizationMetadataKey = "authorization"
+	// TimeZoneMetadataKey - metadata key with default IANA time zone of the user who makes the request,
+	// time_zone field of the request overrides it.
+	TimeZoneMetadataKey = "x-time-zone"
+)
+
+// AuthUnaryInterceptor - puts ID of the user authenticated by the request metadata credentials into request context.
+// Requests without credentials are passed unauthenticated and are rejected by the application,
+// requests with invalid credentials are rejected right away.
+func AuthUnaryInterceptor(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authContext(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor - the same as AuthUnaryInterceptor for streaming requests.
+func AuthStreamInterceptor(authenticator auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authContext(ss.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+		wrapped := grpc_middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+	}
+}
+
+func authContext(ctx context.Context, authenticator auth.Authenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	userID, err := authenticator.Authenticate(ctx, auth.Credentials{
+		APIKey:      firstValue(md, APIKeyMetadataKey),
+		BearerToken: auth.BearerToken(firstValue(md, AuthorizationMetadataKey)),
+		UserID:      firstValue(md, UserIDMetadataKey),
+	})
+	switch {
+	case errors.Is(err, auth.ErrNoCredentials):
+		return ctx, nil
+	case err != nil:
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return app.WithUserID(ctx, userID), nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// UserUnaryInterceptor - puts time zone from the request metadata into request context.
+func UserUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, err := userContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// UserStreamInterceptor - the same as UserUnaryInterceptor for streaming requests.
+func UserStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := userContext(ss.Context())
+	if err != nil {
+		return err
+	}
+	wrapped := grpc_middleware.WrapServerStream(ss)
+	wrapped.WrappedContext = ctx
+	return handler(srv, wrapped)
+}
+
+// userContext - puts time zone from the request metadata into request context.
+func userContext(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	if values := md.Get(TimeZoneMetadataKey); len(values) > 0 {
+		var err error
+		if ctx, err = withRequestTimeZone(ctx, values[0]); err != nil {
+			return ctx, status.Errorf(codes.InvalidArgument, "time zone metadata validation error: %s", err)
+		}
+	}
+	return ctx, nil
+}
+
+// withRequestTimeZone - puts time zone passed by the client into request context, empty name is ignored.
+func withRequestTimeZone(ctx context.Context, name string) (context.Context, error) {
+	if name == "" {
+		return ctx, nil
+	}
+	loc, err := app.LoadTimeZone(name)
+	if err != nil {
+		return ctx, err
+	}
+	return app.WithTimeZone(ctx, loc), nil
+}
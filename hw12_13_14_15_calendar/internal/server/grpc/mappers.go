@@ -3,15 +3,20 @@ package grpc
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server/grpc/pb"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
-	ErrValueIsNil   = errors.New("value is nil")
-	ErrValueIsEmpty = errors.New("value is empty")
+	ErrValueIsNil        = errors.New("value is nil")
+	ErrValueIsEmpty      = errors.New("value is empty")
+	ErrNotPatchableField = errors.New("field can't be patched")
 )
 
 func MapToStorageFormat(event *pb.Event) (*storage.Event, error) {
@@ -22,22 +27,215 @@ func MapToStorageFormat(event *pb.Event) (*storage.Event, error) {
 	return &storage.Event{
 		ID:          event.Id,
 		Title:       event.Title,
-		StartTime:   event.StartTime.AsTime().Local(),
-		EndTime:     event.EndTime.AsTime().Local(),
+		StartTime:   event.StartTime.AsTime(),
+		EndTime:     event.EndTime.AsTime(),
 		Description: event.Description,
 		OwnerID:     event.OwnerId,
+		// nil duration is mapped to zero value
+		NotifyBefore:   event.NotifyBefore.AsDuration(),
+		RecurrenceRule: event.RecurrenceRule,
+		ExceptionDates: MapTimestampsToStorageFormat(event.ExceptionDates),
+		Version:        event.Version,
+		CalendarID:     event.CalendarId,
+		TimeZone:       event.TimeZone,
+	}, nil
+}
+
+// MapCreateEventDataToStorageFormat - ID and owner of the created event are set by the service, so they are not mapped.
+func MapCreateEventDataToStorageFormat(eventData *pb.AddEventRequest_CreateEventData) (storage.Event, error) {
+	if eventData == nil {
+		return storage.Event{}, errors.New("no event data provided")
+	}
+	if err := eventData.StartTime.CheckValid(); err != nil {
+		return storage.Event{}, fmt.Errorf("start time timestamp is not valid: %w", err)
+	}
+	if err := eventData.EndTime.CheckValid(); err != nil {
+		return storage.Event{}, fmt.Errorf("end time timestamp is not valid: %w", err)
+	}
+	var notifyBefore time.Duration
+	if eventData.NotifyBefore != nil {
+		if err := eventData.NotifyBefore.CheckValid(); err != nil {
+			return storage.Event{}, fmt.Errorf("notify before duration is not valid: %w", err)
+		}
+		notifyBefore = eventData.NotifyBefore.AsDuration()
+	}
+	if err := ValidateTimestamps(eventData.ExceptionDates); err != nil {
+		return storage.Event{}, fmt.Errorf("exception dates timestamps are not valid: %w", err)
+	}
+	return storage.Event{
+		Title:          eventData.Title,
+		StartTime:      eventData.StartTime.AsTime(),
+		EndTime:        eventData.EndTime.AsTime(),
+		Description:    eventData.Description,
+		NotifyBefore:   notifyBefore,
+		RecurrenceRule: eventData.RecurrenceRule,
+		ExceptionDates: MapTimestampsToStorageFormat(eventData.ExceptionDates),
+		CalendarID:     eventData.CalendarId,
+		TimeZone:       eventData.TimeZone,
 	}, nil
 }
 
 func MapToPbFormat(event storage.Event) *pb.Event {
 	return &pb.Event{
-		Id:          event.ID,
-		Title:       event.Title,
-		StartTime:   timestamppb.New(event.StartTime),
-		EndTime:     timestamppb.New(event.EndTime),
-		Description: event.Description,
-		OwnerId:     event.OwnerID,
+		Id:             event.ID,
+		Title:          event.Title,
+		StartTime:      timestamppb.New(event.StartTime),
+		EndTime:        timestamppb.New(event.EndTime),
+		Description:    event.Description,
+		OwnerId:        event.OwnerID,
+		NotifyBefore:   durationpb.New(event.NotifyBefore),
+		RecurrenceRule: event.RecurrenceRule,
+		ExceptionDates: MapTimestampsToPbFormat(event.ExceptionDates),
+		Version:        event.Version,
+		CalendarId:     event.CalendarID,
+		TimeZone:       event.TimeZone,
+	}
+}
+
+// MapToEventPatch - maps event fields of the mask paths, the rest of event fields are ignored.
+func MapToEventPatch(event *pb.Event, mask *fieldmaskpb.FieldMask) (app.EventPatch, error) {
+	var patch app.EventPatch
+	if event == nil {
+		return patch, fmt.Errorf("event validation error: %w", ErrValueIsNil)
+	}
+	if event.Id == "" {
+		return patch, fmt.Errorf("event id validation error: %w", ErrValueIsEmpty)
+	}
+	for _, path := range mask.GetPaths() {
+		switch path {
+		case "title":
+			patch.Title = &event.Title
+		case "description":
+			patch.Description = &event.Description
+		case "start_time":
+			if err := event.StartTime.CheckValid(); err != nil {
+				return patch, fmt.Errorf("start time validation error: %w", err)
+			}
+			startTime := event.StartTime.AsTime()
+			patch.StartTime = &startTime
+		case "end_time":
+			if err := event.EndTime.CheckValid(); err != nil {
+				return patch, fmt.Errorf("end time validation error: %w", err)
+			}
+			endTime := event.EndTime.AsTime()
+			patch.EndTime = &endTime
+		case "notify_before":
+			if event.NotifyBefore != nil {
+				if err := event.NotifyBefore.CheckValid(); err != nil {
+					return patch, fmt.Errorf("notify before validation error: %w", err)
+				}
+			}
+			// nil duration is mapped to zero value
+			notifyBefore := event.NotifyBefore.AsDuration()
+			patch.NotifyBefore = &notifyBefore
+		case "recurrence_rule":
+			patch.RecurrenceRule = &event.RecurrenceRule
+		case "exception_dates":
+			if err := ValidateTimestamps(event.ExceptionDates); err != nil {
+				return patch, fmt.Errorf("exception dates validation error: %w", err)
+			}
+			exceptionDates := MapTimestampsToStorageFormat(event.ExceptionDates)
+			patch.ExceptionDates = &exceptionDates
+		case "calendar_id":
+			patch.CalendarID = &event.CalendarId
+		case "time_zone":
+			patch.TimeZone = &event.TimeZone
+		default:
+			// id, owner_id and version are managed by the service
+			return patch, fmt.Errorf("%w: %s", ErrNotPatchableField, path)
+		}
+	}
+	return patch, nil
+}
+
+// MapTimestampsToStorageFormat - maps already validated timestamps.
+func MapTimestampsToStorageFormat(timestamps []*timestamppb.Timestamp) storage.ExceptionDates {
+	if len(timestamps) == 0 {
+		return nil
+	}
+	res := make(storage.ExceptionDates, 0, len(timestamps))
+	for _, v := range timestamps {
+		res = append(res, v.AsTime())
+	}
+	return res
+}
+
+func MapTimestampsToPbFormat(dates storage.ExceptionDates) []*timestamppb.Timestamp {
+	if len(dates) == 0 {
+		return nil
+	}
+	res := make([]*timestamppb.Timestamp, 0, len(dates))
+	for _, v := range dates {
+		res = append(res, timestamppb.New(v))
+	}
+	return res
+}
+
+func MapImportReportToPbFormat(report app.ImportReport) *pb.ImportEventsResponse {
+	items := make([]*pb.ImportItemResult, 0, len(report.Items))
+	for _, item := range report.Items {
+		items = append(items, &pb.ImportItemResult{
+			Uid:     item.UID,
+			EventId: item.EventID,
+			Status:  string(item.Status),
+			Error:   item.Error,
+		})
+	}
+	return &pb.ImportEventsResponse{
+		Items:   items,
+		Created: int32(report.Created),
+		Updated: int32(report.Updated),
+		Failed:  int32(report.Failed),
+	}
+}
+
+// MapBatchOperationToStorageFormat - only the message of the operation action is mapped,
+// unknown actions are left to the application to report in the operation result.
+func MapBatchOperationToStorageFormat(operation *pb.BatchOperation) (storage.BatchOperation, error) {
+	result := storage.BatchOperation{Action: storage.BatchAction(operation.GetAction())}
+	switch result.Action {
+	case storage.BatchCreate:
+		event, err := MapCreateEventDataToStorageFormat(operation.GetCreateEventData())
+		if err != nil {
+			return result, err
+		}
+		result.Event = event
+	case storage.BatchUpdate:
+		event, err := MapToStorageFormat(operation.GetEvent())
+		if err != nil {
+			return result, err
+		}
+		result.Event = *event
+	case storage.BatchDelete:
+		if operation.GetEventId() == "" {
+			return result, fmt.Errorf("event id validation error: %w", ErrValueIsEmpty)
+		}
+		result.Event = storage.Event{ID: operation.GetEventId()}
+	}
+	return result, nil
+}
+
+func MapBatchReportToPbFormat(report app.BatchReport) *pb.BatchEventsResponse {
+	items := make([]*pb.BatchItemResult, 0, len(report.Items))
+	for _, item := range report.Items {
+		items = append(items, &pb.BatchItemResult{
+			Action:  string(item.Action),
+			EventId: item.EventID,
+			Version: item.Version,
+			Status:  string(item.Status),
+			Error:   item.Error,
+		})
 	}
+	return &pb.BatchEventsResponse{Items: items, Applied: report.Applied}
+}
+
+func ValidateTimestamps(timestamps []*timestamppb.Timestamp) error {
+	for _, v := range timestamps {
+		if err := v.CheckValid(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func MapSliceToPbFormat(events []storage.Event) []*pb.Event {
@@ -48,29 +246,98 @@ func MapSliceToPbFormat(events []storage.Event) []*pb.Event {
 	return res
 }
 
+func MapSearchHitsToPbFormat(hits []storage.SearchHit) []*pb.SearchHit {
+	res := make([]*pb.SearchHit, 0, len(hits))
+	for _, v := range hits {
+		res = append(res, &pb.SearchHit{Event: MapToPbFormat(v.Event), Rank: v.Rank, Snippet: v.Snippet})
+	}
+	return res
+}
+
+func MapChangeToPbFormat(change app.EventChange) *pb.EventChange {
+	res := &pb.EventChange{Id: change.ID, Type: string(change.Type), Event: MapToPbFormat(change.Event)}
+	if change.Previous != nil {
+		res.Previous = MapToPbFormat(*change.Previous)
+	}
+	return res
+}
+
+func MapAttendeeToPbFormat(attendee storage.Attendee) *pb.Attendee {
+	return &pb.Attendee{
+		EventId: attendee.EventID,
+		UserId:  attendee.UserID,
+		Role:    string(attendee.Role),
+		Status:  string(attendee.Status),
+	}
+}
+
+func MapAttendeesToPbFormat(attendees []storage.Attendee) []*pb.Attendee {
+	res := make([]*pb.Attendee, 0, len(attendees))
+	for _, v := range attendees {
+		res = append(res, MapAttendeeToPbFormat(v))
+	}
+	return res
+}
+
+// MapCalendarToStorageFormat - owner is set by the service, so it is not mapped.
+func MapCalendarToStorageFormat(calendar *pb.Calendar) storage.Calendar {
+	return storage.Calendar{
+		ID:       calendar.GetId(),
+		Name:     calendar.GetName(),
+		Color:    calendar.GetColor(),
+		TimeZone: calendar.GetTimeZone(),
+	}
+}
+
+func MapCalendarToPbFormat(calendar storage.Calendar) *pb.Calendar {
+	return &pb.Calendar{
+		Id:       calendar.ID,
+		OwnerId:  calendar.OwnerID,
+		Name:     calendar.Name,
+		Color:    calendar.Color,
+		TimeZone: calendar.TimeZone,
+	}
+}
+
+func MapTimeIntervalToPbFormat(interval app.TimeInterval) *pb.TimeInterval {
+	return &pb.TimeInterval{Start: timestamppb.New(interval.Start), End: timestamppb.New(interval.End)}
+}
+
+func MapUsersBusyToPbFormat(users []app.UserBusy) []*pb.UserBusy {
+	res := make([]*pb.UserBusy, 0, len(users))
+	for _, user := range users {
+		busy := make([]*pb.TimeInterval, 0, len(user.Busy))
+		for _, interval := range user.Busy {
+			busy = append(busy, MapTimeIntervalToPbFormat(interval))
+		}
+		res = append(res, &pb.UserBusy{UserId: user.UserID, Busy: busy})
+	}
+	return res
+}
+
 func ValidatePbEvent(event *pb.Event) error {
 	err := func(event *pb.Event) error {
 		if event == nil {
 			return ErrValueIsNil
 		}
+		// event business rules are checked by application, only message format is checked here
 		if event.Id == "" {
 			return fmt.Errorf("id validation err: %w", ErrValueIsEmpty)
 		}
-		if event.Description == "" {
-			return fmt.Errorf("description validation err: %w", ErrValueIsEmpty)
-		}
-		if event.Title == "" {
-			return fmt.Errorf("title validation err: %w", ErrValueIsEmpty)
-		}
-		if event.OwnerId == "" {
-			return fmt.Errorf("owner id validation err: %w", ErrValueIsEmpty)
-		}
 		if err := event.StartTime.CheckValid(); err != nil {
 			return fmt.Errorf("start time validation err: %w", err)
 		}
 		if err := event.EndTime.CheckValid(); err != nil {
 			return fmt.Errorf("end time validation err: %w", err)
 		}
+		if event.NotifyBefore != nil {
+			if err := event.NotifyBefore.CheckValid(); err != nil {
+				return fmt.Errorf("notify before validation err: %w", err)
+			}
+		}
+		if err := ValidateTimestamps(event.ExceptionDates); err != nil {
+			return fmt.Errorf("exception dates validation err: %w", err)
+		}
 		return nil
 	}(event)
 	if err != nil {
@@ -78,3 +345,12 @@ func ValidatePbEvent(event *pb.Event) error {
 	}
 	return nil
 }
+
+// MapUserSettingsToStorageFormat - user is set by the service, so it is not mapped.
+func MapUserSettingsToStorageFormat(settings *pb.UserSettings) storage.UserSettings {
+	return storage.UserSettings{TimeZone: settings.GetTimeZone()}
+}
+
+func MapUserSettingsToPbFormat(settings storage.UserSettings) *pb.UserSettings {
+	return &pb.UserSettings{UserId: settings.UserID, TimeZone: settings.TimeZone}
+}
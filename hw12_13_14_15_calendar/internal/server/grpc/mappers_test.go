@@ -9,6 +9,7 @@ import (
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
 	"github.com/bxcodec/faker/v3"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -31,17 +32,19 @@ func TestMapToPbFormat(t *testing.T) {
 		err := faker.FakeData(&testEvent)
 		testEvent.StartTime = testEvent.StartTime.Truncate(time.Nanosecond).Local()
 		testEvent.EndTime = testEvent.EndTime.Truncate(time.Nanosecond).Local()
+		testEvent.NotifyBefore = time.Duration(i) * time.Minute
 		require.NoError(t, err, "error during fake event generation")
 
 		testCases = append(testCases, FromStorageTestCase{
 			input: testEvent,
 			expect: &pb.Event{
-				Id:          testEvent.ID,
-				Title:       testEvent.Title,
-				StartTime:   timestamppb.New(testEvent.StartTime),
-				EndTime:     timestamppb.New(testEvent.EndTime),
-				Description: testEvent.Description,
-				OwnerId:     testEvent.OwnerID,
+				Id:           testEvent.ID,
+				Title:        testEvent.Title,
+				StartTime:    timestamppb.New(testEvent.StartTime),
+				EndTime:      timestamppb.New(testEvent.EndTime),
+				Description:  testEvent.Description,
+				OwnerId:      testEvent.OwnerID,
+				NotifyBefore: durationpb.New(testEvent.NotifyBefore),
 			},
 			name: t.Name() + " case number " + strconv.Itoa(i),
 		})
@@ -67,6 +70,9 @@ func checkTwoPbEventsEqual(e1 *pb.Event, e2 *pb.Event) bool {
 	if !e1.EndTime.AsTime().Equal(e2.EndTime.AsTime()) {
 		return false
 	}
+	if e1.NotifyBefore.AsDuration() != e2.NotifyBefore.AsDuration() {
+		return false
+	}
 	return true
 }
 
@@ -77,6 +83,7 @@ func TestMapToStorageFormat(t *testing.T) {
 		err := faker.FakeData(&testEvent)
 		testEvent.StartTime = testEvent.StartTime.Truncate(time.Nanosecond).Local()
 		testEvent.EndTime = testEvent.EndTime.Truncate(time.Nanosecond).Local()
+		testEvent.NotifyBefore = time.Duration(i) * time.Hour
 		require.NoError(t, err, "error during fake event generation")
 
 		testCases = append(testCases, ToStorageTestCase{
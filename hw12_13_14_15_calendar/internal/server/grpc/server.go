@@ -1,67 +1,82 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/auth"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
 	grpc_zap "github.com/grpc-ecosystem/go-grpc-middleware/logging/zap"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-//go:generate protoc --proto_path=../../../api --go_out=pb --go-grpc_out=pb ../../../api/calendar_service.proto
+//go:generate protoc --proto_path=../../../api --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative --grpc-gateway_out=pb --grpc-gateway_opt=paths=source_relative --openapiv2_out=../../../api ../../../api/calendar_service.proto
 var _ pb.CalendarServiceServer = (*CalendarService)(nil)
 
 type CalendarService struct {
 	pb.UnimplementedCalendarServiceServer
 	app server.Application
+	// stopping - closed when the server stops, so streams which end only on client cancel are finished,
+	// nil channel means streams are finished by the client only
+	stopping chan struct{}
 }
 
 func (c *CalendarService) AddEvent(ctx context.Context, request *pb.AddEventRequest) (*pb.AddEventResponse, error) {
-	if request.GetCreateEventData() == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "no event data provided")
-	}
-	eventData := request.GetCreateEventData()
-
-	if err := eventData.StartTime.CheckValid(); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "start time timestamp is not valid: %s", err)
-	}
-	if err := eventData.EndTime.CheckValid(); err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "end time timestamp is not valid: %s", err)
+	eventData, err := MapCreateEventDataToStorageFormat(request.GetCreateEventData())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
 	}
-
-	event, err := c.app.CreateEvent(
-		ctx,
-		eventData.Title,
-		eventData.StartTime.AsTime(),
-		eventData.EndTime.AsTime(),
-		eventData.Description,
-		eventData.OwnerId)
+	event, err := c.app.CreateEvent(ctx, eventData)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "unable to create event: %s", err)
+		return nil, status.Errorf(errorCode(err), "unable to create event: %s", err)
 	}
 
 	return &pb.AddEventResponse{Event: MapToPbFormat(event)}, nil
 }
 
+// UpdateEvent - updates the whole event or only the fields of update_mask if it's set.
 func (c *CalendarService) UpdateEvent(ctx context.Context, request *pb.UpdateEventRequest) (*pb.UpdateEventResponse, error) {
+	if request.GetUpdateMask() != nil {
+		return c.patchEvent(ctx, request)
+	}
 	event, err := MapToStorageFormat(request.GetEvent())
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "validation error: %s", err)
 	}
-	err = c.app.UpdateEvent(ctx, *event)
+	// version of the request is authoritative, so stale event version sent back by the client is ignored
+	event.Version = request.GetVersion()
+	updated, err := c.app.UpdateEvent(ctx, *event)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to update event: %s", err)
+	}
+	return &pb.UpdateEventResponse{Event: MapToPbFormat(updated)}, nil
+}
+
+func (c *CalendarService) patchEvent(ctx context.Context, request *pb.UpdateEventRequest) (*pb.UpdateEventResponse, error) {
+	patch, err := MapToEventPatch(request.GetEvent(), request.GetUpdateMask())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "validation error: %s", err)
+	}
+	updated, err := c.app.PatchEvent(ctx, request.GetEvent().GetId(), patch, request.GetVersion())
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "unable to update event: %s", err)
+		return nil, status.Errorf(errorCode(err), "unable to update event: %s", err)
 	}
-	return &pb.UpdateEventResponse{Event: request.GetEvent()}, nil
+	return &pb.UpdateEventResponse{Event: MapToPbFormat(updated)}, nil
 }
 
 func (c *CalendarService) DeleteEvent(ctx context.Context, request *pb.DeleteEventRequest) (*pb.DeleteEventResponse, error) {
@@ -71,11 +86,21 @@ func (c *CalendarService) DeleteEvent(ctx context.Context, request *pb.DeleteEve
 	eventID := request.GetEventId()
 	err := c.app.DeleteEvent(ctx, eventID)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "unable to delete event: %s", err)
+		return nil, status.Errorf(errorCode(err), "unable to delete event: %s", err)
 	}
 	return new(pb.DeleteEventResponse), nil
 }
 
+// responseTimeZone - returns time zone period boundaries of the request are calculated in,
+// if neither the client, the listed calendars, the user nor the calendar set it, UTC of the requested timestamp is used.
+func (c *CalendarService) responseTimeZone(ctx context.Context, calendarIDs ...string) string {
+	loc, err := c.app.Location(ctx, calendarIDs...)
+	if err != nil {
+		return time.UTC.String()
+	}
+	return loc.String()
+}
+
 func (c *CalendarService) FindDayEvents(ctx context.Context, request *pb.FindDayEventsRequest) (*pb.FindDayEventsResponse, error) {
 	if request.GetDay() == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "day validation error: %s", ErrValueIsNil)
@@ -83,13 +108,20 @@ func (c *CalendarService) FindDayEvents(ctx context.Context, request *pb.FindDay
 	if err := request.GetDay().CheckValid(); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "day validation error: %s", err)
 	}
+	ctx, err := withRequestTimeZone(ctx, request.GetTimeZone())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "time zone validation error: %s", err)
+	}
 	day := request.GetDay().AsTime()
-	events, err := c.app.ListDayEvents(ctx, day)
+	events, err := c.app.ListDayEvents(ctx, day, request.GetCalendarIds()...)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "unable to find day events: %s", err)
+		return nil, status.Errorf(errorCode(err), "unable to find day events: %s", err)
 	}
 
-	return &pb.FindDayEventsResponse{Events: MapSliceToPbFormat(events)}, nil
+	return &pb.FindDayEventsResponse{
+		Events:   MapSliceToPbFormat(events),
+		TimeZone: c.responseTimeZone(ctx, request.GetCalendarIds()...),
+	}, nil
 }
 
 func (c *CalendarService) FindWeekEvents(ctx context.Context, request *pb.FindWeekEventsRequest) (*pb.FindWeekEventsResponse, error) {
@@ -99,13 +131,20 @@ func (c *CalendarService) FindWeekEvents(ctx context.Context, request *pb.FindWe
 	if err := request.GetWeek().CheckValid(); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "week validation error: %s", err)
 	}
+	ctx, err := withRequestTimeZone(ctx, request.GetTimeZone())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "time zone validation error: %s", err)
+	}
 	week := request.GetWeek().AsTime()
-	events, err := c.app.ListWeekEvents(ctx, week)
+	events, err := c.app.ListWeekEvents(ctx, week, request.GetCalendarIds()...)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "unable to find week events: %s", err)
+		return nil, status.Errorf(errorCode(err), "unable to find week events: %s", err)
 	}
 
-	return &pb.FindWeekEventsResponse{Events: MapSliceToPbFormat(events)}, nil
+	return &pb.FindWeekEventsResponse{
+		Events:   MapSliceToPbFormat(events),
+		TimeZone: c.responseTimeZone(ctx, request.GetCalendarIds()...),
+	}, nil
 }
 
 func (c *CalendarService) FindMonthEvents(ctx context.Context, request *pb.FindMonthEventsRequest) (*pb.FindMonthEventsResponse, error) {
@@ -115,18 +154,366 @@ func (c *CalendarService) FindMonthEvents(ctx context.Context, request *pb.FindM
 	if err := request.GetMonth().CheckValid(); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "month validation error: %s", err)
 	}
+	ctx, err := withRequestTimeZone(ctx, request.GetTimeZone())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "time zone validation error: %s", err)
+	}
 	month := request.GetMonth().AsTime()
-	events, err := c.app.ListMonthEvents(ctx, month)
+	events, err := c.app.ListMonthEvents(ctx, month, request.GetCalendarIds()...)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to find month events: %s", err)
+	}
+
+	return &pb.FindMonthEventsResponse{
+		Events:   MapSliceToPbFormat(events),
+		TimeZone: c.responseTimeZone(ctx, request.GetCalendarIds()...),
+	}, nil
+}
+
+func (c *CalendarService) ListEvents(ctx context.Context, request *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	if request.GetFrom() == nil || request.GetTo() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "range validation error: %s", ErrValueIsNil)
+	}
+	if err := request.GetFrom().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "range start validation error: %s", err)
+	}
+	if err := request.GetTo().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "range end validation error: %s", err)
+	}
+	ctx, err := withRequestTimeZone(ctx, request.GetTimeZone())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "time zone validation error: %s", err)
+	}
+	page, err := c.app.ListEvents(
+		ctx,
+		request.GetFrom().AsTime(),
+		request.GetTo().AsTime(),
+		app.EventFilter{Text: request.GetFilter().GetText()},
+		request.GetPageToken(),
+		int(request.GetLimit()),
+	)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to list events: %s", err)
+	}
+	return &pb.ListEventsResponse{Events: MapSliceToPbFormat(page.Events), NextPageToken: page.NextPageToken}, nil
+}
+
+func (c *CalendarService) SearchEvents(ctx context.Context, request *pb.SearchEventsRequest) (*pb.SearchEventsResponse, error) {
+	var from, to time.Time
+	if request.GetFrom() != nil {
+		if err := request.GetFrom().CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "range start validation error: %s", err)
+		}
+		from = request.GetFrom().AsTime()
+	}
+	if request.GetTo() != nil {
+		if err := request.GetTo().CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "range end validation error: %s", err)
+		}
+		to = request.GetTo().AsTime()
+	}
+	ctx, err := withRequestTimeZone(ctx, request.GetTimeZone())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "time zone validation error: %s", err)
+	}
+	hits, err := c.app.SearchEvents(ctx, request.GetQuery(), from, to, int(request.GetLimit()))
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to search events: %s", err)
+	}
+	return &pb.SearchEventsResponse{Hits: MapSearchHitsToPbFormat(hits)}, nil
+}
+
+// WatchEvents - streams changes of user events overlapping the requested range until the client cancels the call
+// or the server stops, Unavailable status is returned in the latter case, so the client should watch again.
+// Call is aborted if the client does not keep up with the changes, it should reload the events and watch again.
+func (c *CalendarService) WatchEvents(request *pb.WatchEventsRequest, stream pb.CalendarService_WatchEventsServer) error {
+	var from, to time.Time
+	if request.GetFrom() != nil {
+		if err := request.GetFrom().CheckValid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "range start validation error: %s", err)
+		}
+		from = request.GetFrom().AsTime()
+	}
+	if request.GetTo() != nil {
+		if err := request.GetTo().CheckValid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "range end validation error: %s", err)
+		}
+		to = request.GetTo().AsTime()
+	}
+	ctx := stream.Context()
+	subscription, err := c.app.WatchEvents(ctx, from, to, request.GetAfterChangeId())
+	if err != nil {
+		return status.Errorf(errorCode(err), "unable to watch events: %s", err)
+	}
+	defer subscription.Close()
+	// headers tell the client that changes made from now on are watched
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-c.stopping:
+			return status.Error(codes.Unavailable, "server is stopping")
+		case change, ok := <-subscription.Changes():
+			if !ok {
+				return status.Errorf(codes.Aborted, "events watching stopped: %s", subscription.Err())
+			}
+			if err := stream.Send(MapChangeToPbFormat(change)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *CalendarService) ExportEvents(ctx context.Context, request *pb.ExportEventsRequest) (*pb.ExportEventsResponse, error) {
+	if request.GetFrom() == nil || request.GetTo() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "range validation error: %s", ErrValueIsNil)
+	}
+	if err := request.GetFrom().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "range start validation error: %s", err)
+	}
+	if err := request.GetTo().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "range end validation error: %s", err)
+	}
+	calendar := new(bytes.Buffer)
+	err := c.app.ExportEvents(ctx, request.GetFrom().AsTime(), request.GetTo().AsTime(), calendar)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to export events: %s", err)
+	}
+	return &pb.ExportEventsResponse{Calendar: calendar.String()}, nil
+}
+
+func (c *CalendarService) ImportEvents(ctx context.Context, request *pb.ImportEventsRequest) (*pb.ImportEventsResponse, error) {
+	report, err := c.app.ImportEvents(ctx, strings.NewReader(request.GetCalendar()))
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to import events: %s", err)
+	}
+	return MapImportReportToPbFormat(report), nil
+}
+
+// BatchEvents - maps every operation of the batch first, so malformed operation fails the whole call,
+// errors of valid operations are returned in their results.
+func (c *CalendarService) BatchEvents(ctx context.Context, request *pb.BatchEventsRequest) (*pb.BatchEventsResponse, error) {
+	operations := make([]storage.BatchOperation, 0, len(request.GetOperations()))
+	for i, operation := range request.GetOperations() {
+		mapped, err := MapBatchOperationToStorageFormat(operation)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "operation %d validation error: %s", i, err)
+		}
+		operations = append(operations, mapped)
+	}
+	report, err := c.app.BatchEvents(ctx, operations)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to apply batch: %s", err)
+	}
+	return MapBatchReportToPbFormat(report), nil
+}
+
+func (c *CalendarService) InviteAttendee(ctx context.Context, request *pb.InviteAttendeeRequest) (*pb.InviteAttendeeResponse, error) {
+	if request.GetEventId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "event id validation error: %s", ErrValueIsEmpty)
+	}
+	attendee, err := c.app.InviteAttendee(ctx, request.GetEventId(), request.GetUserId(), storage.AttendeeRole(request.GetRole()))
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to invite attendee: %s", err)
+	}
+	return &pb.InviteAttendeeResponse{Attendee: MapAttendeeToPbFormat(attendee)}, nil
+}
+
+func (c *CalendarService) RemoveAttendee(ctx context.Context, request *pb.RemoveAttendeeRequest) (*pb.RemoveAttendeeResponse, error) {
+	if request.GetEventId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "event id validation error: %s", ErrValueIsEmpty)
+	}
+	if err := c.app.RemoveAttendee(ctx, request.GetEventId(), request.GetUserId()); err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to remove attendee: %s", err)
+	}
+	return new(pb.RemoveAttendeeResponse), nil
+}
+
+func (c *CalendarService) RespondToInvitation(
+	ctx context.Context,
+	request *pb.RespondToInvitationRequest,
+) (*pb.RespondToInvitationResponse, error) {
+	if request.GetEventId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "event id validation error: %s", ErrValueIsEmpty)
+	}
+	attendee, err := c.app.RespondToInvitation(ctx, request.GetEventId(), storage.RSVPStatus(request.GetStatus()))
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "unable to find month events: %s", err)
+		return nil, status.Errorf(errorCode(err), "unable to respond to invitation: %s", err)
 	}
+	return &pb.RespondToInvitationResponse{Attendee: MapAttendeeToPbFormat(attendee)}, nil
+}
 
-	return &pb.FindMonthEventsResponse{Events: MapSliceToPbFormat(events)}, nil
+func (c *CalendarService) ListAttendees(ctx context.Context, request *pb.ListAttendeesRequest) (*pb.ListAttendeesResponse, error) {
+	if request.GetEventId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "event id validation error: %s", ErrValueIsEmpty)
+	}
+	attendees, err := c.app.ListAttendees(ctx, request.GetEventId())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to list attendees: %s", err)
+	}
+	return &pb.ListAttendeesResponse{Attendees: MapAttendeesToPbFormat(attendees)}, nil
+}
+
+func (c *CalendarService) CreateCalendar(ctx context.Context, request *pb.CreateCalendarRequest) (*pb.CreateCalendarResponse, error) {
+	if request.GetCalendar() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "calendar validation error: %s", ErrValueIsNil)
+	}
+	calendar, err := c.app.CreateCalendar(ctx, MapCalendarToStorageFormat(request.GetCalendar()))
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to create calendar: %s", err)
+	}
+	return &pb.CreateCalendarResponse{Calendar: MapCalendarToPbFormat(calendar)}, nil
+}
+
+func (c *CalendarService) GetCalendar(ctx context.Context, request *pb.GetCalendarRequest) (*pb.GetCalendarResponse, error) {
+	if request.GetCalendarId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "calendar id validation error: %s", ErrValueIsEmpty)
+	}
+	calendar, err := c.app.GetCalendar(ctx, request.GetCalendarId())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to get calendar: %s", err)
+	}
+	return &pb.GetCalendarResponse{Calendar: MapCalendarToPbFormat(calendar)}, nil
+}
+
+func (c *CalendarService) UpdateCalendar(ctx context.Context, request *pb.UpdateCalendarRequest) (*pb.UpdateCalendarResponse, error) {
+	if request.GetCalendar().GetId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "calendar id validation error: %s", ErrValueIsEmpty)
+	}
+	calendar, err := c.app.UpdateCalendar(ctx, MapCalendarToStorageFormat(request.GetCalendar()))
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to update calendar: %s", err)
+	}
+	return &pb.UpdateCalendarResponse{Calendar: MapCalendarToPbFormat(calendar)}, nil
+}
+
+func (c *CalendarService) DeleteCalendar(ctx context.Context, request *pb.DeleteCalendarRequest) (*pb.DeleteCalendarResponse, error) {
+	if request.GetCalendarId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "calendar id validation error: %s", ErrValueIsEmpty)
+	}
+	if err := c.app.DeleteCalendar(ctx, request.GetCalendarId()); err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to delete calendar: %s", err)
+	}
+	return new(pb.DeleteCalendarResponse), nil
+}
+
+func (c *CalendarService) ListCalendars(ctx context.Context, request *pb.ListCalendarsRequest) (*pb.ListCalendarsResponse, error) {
+	calendars, err := c.app.ListCalendars(ctx)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to list calendars: %s", err)
+	}
+	res := make([]*pb.Calendar, 0, len(calendars))
+	for _, calendar := range calendars {
+		res = append(res, MapCalendarToPbFormat(calendar))
+	}
+	return &pb.ListCalendarsResponse{Calendars: res}, nil
+}
+
+func (c *CalendarService) GetUserSettings(ctx context.Context, request *pb.GetUserSettingsRequest) (*pb.GetUserSettingsResponse, error) {
+	settings, err := c.app.GetUserSettings(ctx)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to get user settings: %s", err)
+	}
+	return &pb.GetUserSettingsResponse{Settings: MapUserSettingsToPbFormat(settings)}, nil
+}
+
+func (c *CalendarService) UpdateUserSettings(
+	ctx context.Context,
+	request *pb.UpdateUserSettingsRequest,
+) (*pb.UpdateUserSettingsResponse, error) {
+	if request.GetSettings() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "settings validation error: %s", ErrValueIsNil)
+	}
+	settings, err := c.app.UpdateUserSettings(ctx, MapUserSettingsToStorageFormat(request.GetSettings()))
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to update user settings: %s", err)
+	}
+	return &pb.UpdateUserSettingsResponse{Settings: MapUserSettingsToPbFormat(settings)}, nil
+}
+
+func (c *CalendarService) FreeBusy(ctx context.Context, request *pb.FreeBusyRequest) (*pb.FreeBusyResponse, error) {
+	from, to, err := validateRange(request.GetFrom(), request.GetTo())
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = withRequestTimeZone(ctx, request.GetTimeZone())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "time zone validation error: %s", err)
+	}
+	users, err := c.app.FreeBusy(ctx, request.GetUserIds(), from, to)
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to get free/busy intervals: %s", err)
+	}
+	return &pb.FreeBusyResponse{Users: MapUsersBusyToPbFormat(users)}, nil
+}
+
+func (c *CalendarService) FindFreeSlot(ctx context.Context, request *pb.FindFreeSlotRequest) (*pb.FindFreeSlotResponse, error) {
+	from, to, err := validateRange(request.GetFrom(), request.GetTo())
+	if err != nil {
+		return nil, err
+	}
+	if request.GetDuration() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "duration validation error: %s", ErrValueIsNil)
+	}
+	if err := request.GetDuration().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "duration validation error: %s", err)
+	}
+	ctx, err = withRequestTimeZone(ctx, request.GetTimeZone())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "time zone validation error: %s", err)
+	}
+	slot, err := c.app.FindFreeSlot(ctx, request.GetUserIds(), from, to, request.GetDuration().AsDuration())
+	if err != nil {
+		return nil, status.Errorf(errorCode(err), "unable to find free slot: %s", err)
+	}
+	return &pb.FindFreeSlotResponse{Slot: MapTimeIntervalToPbFormat(slot)}, nil
+}
+
+// validateRange - checks that both range bounds are set and valid, the error is a grpc status.
+func validateRange(from, to *timestamppb.Timestamp) (time.Time, time.Time, error) {
+	if from == nil || to == nil {
+		return time.Time{}, time.Time{}, status.Errorf(codes.InvalidArgument, "range validation error: %s", ErrValueIsNil)
+	}
+	if err := from.CheckValid(); err != nil {
+		return time.Time{}, time.Time{}, status.Errorf(codes.InvalidArgument, "range start validation error: %s", err)
+	}
+	if err := to.CheckValid(); err != nil {
+		return time.Time{}, time.Time{}, status.Errorf(codes.InvalidArgument, "range end validation error: %s", err)
+	}
+	return from.AsTime(), to.AsTime(), nil
+}
+
+// errorCode - maps application error onto grpc status code.
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, app.ErrUnauthenticated):
+		return codes.Unauthenticated
+	case app.IsValidationError(err), errors.Is(err, ical.ErrMalformedCalendar):
+		return codes.InvalidArgument
+	case errors.Is(err, app.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, storage.ErrEventNotFound), errors.Is(err, storage.ErrAttendeeNotFound),
+		errors.Is(err, storage.ErrCalendarNotFound), errors.Is(err, app.ErrNoFreeSlot):
+		return codes.NotFound
+	case errors.Is(err, storage.ErrEventAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrCalendarNotEmpty):
+		return codes.FailedPrecondition
+	case errors.Is(err, storage.ErrVersionConflict):
+		// the client should read the event again and retry the update
+		return codes.Aborted
+	default:
+		return codes.Internal
+	}
 }
 
 type API struct {
-	Server *grpc.Server
-	port   int
+	Server   *grpc.Server
+	port     int
+	stopping chan struct{}
 }
 
 // Start function is starting grpc api server on the given port.
@@ -148,18 +535,42 @@ func (a API) Start(cancelFunc context.CancelFunc) {
 	}
 }
 
-func (a API) Stop() {
+// Stop - finishes watch streams and waits for pending calls until ctx is done,
+// then the server is stopped forcibly closing all connections.
+func (a API) Stop(ctx context.Context) {
 	zap.L().Info("GRPC server stopping...", zap.String("address", net.JoinHostPort("localhost", strconv.Itoa(a.port))))
-	a.Server.GracefulStop()
+	close(a.stopping)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		a.Server.GracefulStop()
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		zap.L().Error("GRPC server graceful stop timed out, closing connections", zap.Error(ctx.Err()))
+		a.Server.Stop()
+		<-stopped
+	}
 	zap.L().Info("GRPC server stopped")
 }
 
-func NewGRPCApi(cfg config.GRPCApiConfig, app server.Application) *API {
+// NewGRPCApi - requests are authenticated by the authenticator.
+func NewGRPCApi(cfg config.GRPCApiConfig, app server.Application, authenticator auth.Authenticator) *API {
 	srv := grpc.NewServer(
 		grpc.ConnectionTimeout(5*time.Second),
-		grpc.UnaryInterceptor(grpc_zap.UnaryServerInterceptor(zap.L())),
-		grpc.StreamInterceptor(grpc_zap.StreamServerInterceptor(zap.L())),
+		grpc.ChainUnaryInterceptor(
+			grpc_zap.UnaryServerInterceptor(zap.L()),
+			AuthUnaryInterceptor(authenticator),
+			UserUnaryInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			grpc_zap.StreamServerInterceptor(zap.L()),
+			AuthStreamInterceptor(authenticator),
+			UserStreamInterceptor,
+		),
 	)
-	pb.RegisterCalendarServiceServer(srv, &CalendarService{app: app})
-	return &API{srv, cfg.Port}
+	stopping := make(chan struct{})
+	pb.RegisterCalendarServiceServer(srv, &CalendarService{app: app, stopping: stopping})
+	return &API{srv, cfg.Port, stopping}
 }
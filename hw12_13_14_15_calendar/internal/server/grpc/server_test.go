@@ -5,16 +5,25 @@ import (
 	"errors"
 	"log"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/auth"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server/grpc/pb"
 	memorystorage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage/memory"
 	"github.com/bxcodec/faker/v3"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -23,6 +32,7 @@ type GRPCTestSuite struct {
 
 	grpcServer     *grpc.Server
 	grpcClientConn *grpc.ClientConn
+	tokens         *auth.JWT
 	ctx            context.Context
 	cancelFunc     context.CancelFunc
 }
@@ -31,12 +41,48 @@ func TestGrpc(t *testing.T) {
 	suite.Run(t, &GRPCTestSuite{})
 }
 
+func TestAPIStop(t *testing.T) {
+	lsnStub := bufconn.Listen(1024 * 1024)
+	api := NewGRPCApi(config.GRPCApiConfig{}, app.New(memorystorage.NewMemStorage()), auth.TrustedUserID{})
+	go func() {
+		if err := api.Server.Serve(lsnStub); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Fatal("error during grpc test server stating: ", err)
+		}
+	}()
+	conn, err := grpc.Dial("", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return lsnStub.Dial()
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the stream is never canceled by the client, so it must be finished by the server
+	ctx := metadata.AppendToOutgoingContext(context.Background(), UserIDMetadataKey, faker.UUIDHyphenated())
+	stream, err := pb.NewCalendarServiceClient(conn).WatchEvents(ctx, &pb.WatchEventsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	api.Stop(stopCtx)
+	require.NoError(t, stopCtx.Err(), "server is stopped forcibly")
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
 func (s *GRPCTestSuite) SetupSuite() {
 	lsnStub := bufconn.Listen(1024 * 1024)
 
 	// starting grpc server
-	s.grpcServer = grpc.NewServer(grpc.ConnectionTimeout(5 * time.Second))
-	pb.RegisterCalendarServiceServer(s.grpcServer, &CalendarService{app: app.New(memorystorage.NewMemStorage())})
+	var err error
+	s.tokens, err = auth.NewJWT([]byte(strings.Repeat("s", 32)), "", "")
+	s.Require().NoError(err)
+	authenticator := auth.Chain{s.tokens, auth.TrustedUserID{}}
+	s.grpcServer = grpc.NewServer(grpc.ConnectionTimeout(5*time.Second),
+		grpc.ChainUnaryInterceptor(AuthUnaryInterceptor(authenticator), UserUnaryInterceptor),
+		grpc.ChainStreamInterceptor(AuthStreamInterceptor(authenticator), UserStreamInterceptor),
+	)
+	pb.RegisterCalendarServiceServer(s.grpcServer, &CalendarService{app: app.New(memorystorage.NewMemStorage(), app.WithLocation(time.UTC))})
 	go func() {
 		if err := s.grpcServer.Serve(lsnStub); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 			log.Fatal("error during grpc test server stating: ", err)
@@ -71,16 +117,18 @@ func (s *GRPCTestSuite) TearDownSuite() {
 
 func (s *GRPCTestSuite) TestAddEvent() {
 	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	userID := faker.UUIDHyphenated()
+	ctx := s.userContext(userID)
 
 	data := pb.AddEventRequest_CreateEventData{
-		Title:       faker.Sentence(),
-		StartTime:   timestamppb.New(time.Now().Truncate(time.Nanosecond).Local()),
-		EndTime:     timestamppb.New(time.Now().AddDate(0, 0, 1).Truncate(time.Nanosecond).Local()),
-		Description: faker.Paragraph(),
-		OwnerId:     faker.UUIDHyphenated(),
+		Title:        faker.Sentence(),
+		StartTime:    timestamppb.New(time.Now().Truncate(time.Nanosecond).Local()),
+		EndTime:      timestamppb.New(time.Now().AddDate(0, 0, 1).Truncate(time.Nanosecond).Local()),
+		Description:  faker.Paragraph(),
+		NotifyBefore: durationpb.New(15 * time.Minute),
 	}
 
-	resp, err := client.AddEvent(s.ctx, &pb.AddEventRequest{
+	resp, err := client.AddEvent(ctx, &pb.AddEventRequest{
 		CreateEventData: &data,
 	})
 	s.Require().NoError(err)
@@ -88,11 +136,66 @@ func (s *GRPCTestSuite) TestAddEvent() {
 	s.Require().Equal(data.Description, resp.GetEvent().GetDescription())
 	s.Require().True(data.StartTime.AsTime().Equal(resp.GetEvent().GetStartTime().AsTime()))
 	s.Require().True(data.EndTime.AsTime().Equal(resp.GetEvent().GetEndTime().AsTime()))
-	s.Require().Equal(data.OwnerId, resp.GetEvent().GetOwnerId())
+	s.Require().Equal(userID, resp.GetEvent().GetOwnerId())
+	s.Require().Equal(15*time.Minute, resp.GetEvent().GetNotifyBefore().AsDuration())
+}
+
+func (s *GRPCTestSuite) TestAddInvalidEvent() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+
+	t := time.Now().Truncate(time.Nanosecond).Local()
+	data := pb.AddEventRequest_CreateEventData{
+		Title:     faker.Sentence(),
+		StartTime: timestamppb.New(t),
+		EndTime:   timestamppb.New(t.Add(-time.Hour)),
+	}
+	_, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &data})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+
+	// description is optional
+	data.EndTime = timestamppb.New(t.Add(time.Hour))
+	_, err = client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &data})
+	s.Require().NoError(err)
+}
+
+func (s *GRPCTestSuite) TestUpdateNotExistingEvent() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+
+	t := time.Now().Truncate(time.Nanosecond).Local()
+	_, err := client.UpdateEvent(ctx, &pb.UpdateEventRequest{Event: &pb.Event{
+		Id:        faker.UUIDHyphenated(),
+		Title:     faker.Sentence(),
+		StartTime: timestamppb.New(t),
+		EndTime:   timestamppb.New(t.Add(time.Hour)),
+	}, Version: 1})
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestAddBusyEvent() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+
+	t := time.Now().Truncate(time.Nanosecond).Local()
+	data := pb.AddEventRequest_CreateEventData{
+		Title:       faker.Sentence(),
+		StartTime:   timestamppb.New(t),
+		EndTime:     timestamppb.New(t.Add(time.Hour)),
+		Description: faker.Paragraph(),
+	}
+	_, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &data})
+	s.Require().NoError(err)
+
+	data.StartTime = timestamppb.New(t.Add(30 * time.Minute))
+	data.EndTime = timestamppb.New(t.Add(90 * time.Minute))
+	_, err = client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &data})
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
 }
 
 func (s *GRPCTestSuite) TestUpdateEvent() {
 	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
 
 	// adding event
 	data := pb.AddEventRequest_CreateEventData{
@@ -100,9 +203,8 @@ func (s *GRPCTestSuite) TestUpdateEvent() {
 		StartTime:   timestamppb.New(time.Now().Truncate(time.Nanosecond).Local()),
 		EndTime:     timestamppb.New(time.Now().AddDate(0, 0, 1).Truncate(time.Nanosecond).Local()),
 		Description: faker.Paragraph(),
-		OwnerId:     faker.UUIDHyphenated(),
 	}
-	resp, err := client.AddEvent(s.ctx, &pb.AddEventRequest{
+	resp, err := client.AddEvent(ctx, &pb.AddEventRequest{
 		CreateEventData: &data,
 	})
 	s.Require().NoError(err)
@@ -110,17 +212,69 @@ func (s *GRPCTestSuite) TestUpdateEvent() {
 	// updating event
 	event := resp.GetEvent()
 	event.Title = "updated"
-	updateResp, err := client.UpdateEvent(s.ctx, &pb.UpdateEventRequest{Event: event})
+	s.Require().Equal(int64(1), event.GetVersion())
+	updateResp, err := client.UpdateEvent(ctx, &pb.UpdateEventRequest{Event: event, Version: event.GetVersion()})
 	s.Require().NoError(err)
 	expected, err := MapToStorageFormat(event)
 	s.Require().NoError(err)
 	actual, err := MapToStorageFormat(updateResp.Event)
 	s.Require().NoError(err)
 	s.Require().True(expected.IsEqual(*actual))
+	s.Require().Equal(int64(2), updateResp.GetEvent().GetVersion())
+
+	// update based on the stale version is rejected
+	event.Title = "lost update"
+	_, err = client.UpdateEvent(ctx, &pb.UpdateEventRequest{Event: event, Version: 1})
+	s.Require().Equal(codes.Aborted, status.Code(err))
+	_, err = client.UpdateEvent(ctx, &pb.UpdateEventRequest{Event: event})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestPartialUpdateEvent() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+
+	data := pb.AddEventRequest_CreateEventData{
+		Title:       faker.Sentence(),
+		StartTime:   timestamppb.New(time.Now().Truncate(time.Nanosecond)),
+		EndTime:     timestamppb.New(time.Now().Add(time.Hour).Truncate(time.Nanosecond)),
+		Description: faker.Paragraph(),
+	}
+	resp, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &data})
+	s.Require().NoError(err)
+
+	// only the title is sent and updated
+	updateResp, err := client.UpdateEvent(ctx, &pb.UpdateEventRequest{
+		Event:      &pb.Event{Id: resp.GetEvent().GetId(), Title: "updated"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	})
+	s.Require().NoError(err)
+	s.Require().Equal("updated", updateResp.GetEvent().GetTitle())
+	s.Require().Equal(data.Description, updateResp.GetEvent().GetDescription())
+	s.Require().True(data.StartTime.AsTime().Equal(updateResp.GetEvent().GetStartTime().AsTime()))
+	s.Require().Equal(int64(2), updateResp.GetEvent().GetVersion())
+
+	_, err = client.UpdateEvent(ctx, &pb.UpdateEventRequest{
+		Event:      &pb.Event{Id: resp.GetEvent().GetId()},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"owner_id"}},
+	})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	_, err = client.UpdateEvent(ctx, &pb.UpdateEventRequest{
+		Event:      &pb.Event{Id: resp.GetEvent().GetId()},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"start_time"}},
+	})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	_, err = client.UpdateEvent(ctx, &pb.UpdateEventRequest{
+		Event:      &pb.Event{Id: resp.GetEvent().GetId(), Title: "stale"},
+		Version:    1,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	})
+	s.Require().Equal(codes.Aborted, status.Code(err))
 }
 
 func (s *GRPCTestSuite) TestDeleteEvent() {
 	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
 
 	// adding event
 	data := pb.AddEventRequest_CreateEventData{
@@ -128,21 +282,30 @@ func (s *GRPCTestSuite) TestDeleteEvent() {
 		StartTime:   timestamppb.New(time.Now().Truncate(time.Nanosecond).Local()),
 		EndTime:     timestamppb.New(time.Now().AddDate(0, 0, 1).Truncate(time.Nanosecond).Local()),
 		Description: faker.Paragraph(),
-		OwnerId:     faker.UUIDHyphenated(),
 	}
-	resp, err := client.AddEvent(s.ctx, &pb.AddEventRequest{
+	resp, err := client.AddEvent(ctx, &pb.AddEventRequest{
 		CreateEventData: &data,
 	})
 	s.Require().NoError(err)
 
 	// deleting event
 	eventID := resp.GetEvent().GetId()
-	_, err = client.DeleteEvent(s.ctx, &pb.DeleteEventRequest{EventId: eventID})
+	_, err = client.DeleteEvent(s.userContext(faker.UUIDHyphenated()), &pb.DeleteEventRequest{EventId: eventID})
+	s.Require().Equal(codes.NotFound, status.Code(err), "event of another user must not be found")
+	_, err = client.DeleteEvent(ctx, &pb.DeleteEventRequest{EventId: eventID})
 	s.Require().NoError(err)
 }
 
+func (s *GRPCTestSuite) TestUnauthenticated() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+
+	_, err := client.FindDayEvents(s.ctx, &pb.FindDayEventsRequest{Day: timestamppb.Now()})
+	s.Require().Equal(codes.Unauthenticated, status.Code(err))
+}
+
 func (s *GRPCTestSuite) TestFindEvents() {
 	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
 
 	// adding event
 	t := time.Now().Truncate(time.Nanosecond).Local()
@@ -151,29 +314,476 @@ func (s *GRPCTestSuite) TestFindEvents() {
 		StartTime:   timestamppb.New(t),
 		EndTime:     timestamppb.New(t.AddDate(0, 0, 1)),
 		Description: faker.Paragraph(),
-		OwnerId:     faker.UUIDHyphenated(),
 	}
-	resp, err := client.AddEvent(s.ctx, &pb.AddEventRequest{
+	resp, err := client.AddEvent(ctx, &pb.AddEventRequest{
 		CreateEventData: &data,
 	})
 	s.Require().NoError(err)
 
 	// finding day events
-	findDayResp, err := client.FindDayEvents(s.ctx, &pb.FindDayEventsRequest{Day: timestamppb.New(t)})
+	findDayResp, err := client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: timestamppb.New(t)})
 	s.Require().NoError(err)
 	s.Require().True(PbEventsContains(findDayResp.Events, resp.GetEvent()))
 
 	// finding week events
-	findWeekResp, err := client.FindWeekEvents(s.ctx, &pb.FindWeekEventsRequest{Week: timestamppb.New(t)})
+	findWeekResp, err := client.FindWeekEvents(ctx, &pb.FindWeekEventsRequest{Week: timestamppb.New(t)})
 	s.Require().NoError(err)
 	s.Require().True(PbEventsContains(findWeekResp.Events, resp.GetEvent()))
 
 	// finding month events
-	findMonthResp, err := client.FindWeekEvents(s.ctx, &pb.FindWeekEventsRequest{Week: timestamppb.New(t)})
+	findMonthResp, err := client.FindWeekEvents(ctx, &pb.FindWeekEventsRequest{Week: timestamppb.New(t)})
 	s.Require().NoError(err)
 	s.Require().True(PbEventsContains(findMonthResp.Events, resp.GetEvent()))
 }
 
+func (s *GRPCTestSuite) TestFindEventsInTimeZone() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+
+	// 2021-09-07 08:30 in Tokyo
+	t := time.Date(2021, time.September, 6, 23, 30, 0, 0, time.UTC)
+	resp, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+		Title:     faker.Sentence(),
+		StartTime: timestamppb.New(t),
+		EndTime:   timestamppb.New(t.Add(15 * time.Minute)),
+	}})
+	s.Require().NoError(err)
+
+	day := timestamppb.New(time.Date(2021, time.September, 7, 0, 0, 0, 0, time.UTC))
+	findResp, err := client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: day, TimeZone: "Asia/Tokyo"})
+	s.Require().NoError(err)
+	s.Require().Equal("Asia/Tokyo", findResp.GetTimeZone())
+	s.Require().True(PbEventsContains(findResp.GetEvents(), resp.GetEvent()))
+
+	// user default time zone is passed in metadata
+	tokyoCtx := metadata.AppendToOutgoingContext(ctx, TimeZoneMetadataKey, "Asia/Tokyo")
+	findResp, err = client.FindDayEvents(tokyoCtx, &pb.FindDayEventsRequest{Day: day})
+	s.Require().NoError(err)
+	s.Require().True(PbEventsContains(findResp.GetEvents(), resp.GetEvent()))
+
+	// request field overrides user default time zone
+	findResp, err = client.FindDayEvents(tokyoCtx, &pb.FindDayEventsRequest{Day: day, TimeZone: "UTC"})
+	s.Require().NoError(err)
+	s.Require().Equal("UTC", findResp.GetTimeZone())
+	s.Require().Empty(findResp.GetEvents())
+
+	_, err = client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: day, TimeZone: "Mars/Olympus"})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestListEvents() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+
+	t := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+			Title:     faker.Sentence(),
+			StartTime: timestamppb.New(t.AddDate(0, 0, 2-i)),
+			EndTime:   timestamppb.New(t.AddDate(0, 0, 2-i).Add(time.Hour)),
+		}})
+		s.Require().NoError(err)
+	}
+
+	request := &pb.ListEventsRequest{
+		From:  timestamppb.New(t),
+		To:    timestamppb.New(t.AddDate(0, 0, 7)),
+		Limit: 2,
+	}
+	resp, err := client.ListEvents(ctx, request)
+	s.Require().NoError(err)
+	s.Require().Len(resp.GetEvents(), 2)
+	s.Require().True(t.Equal(resp.GetEvents()[0].GetStartTime().AsTime()))
+	s.Require().True(t.AddDate(0, 0, 1).Equal(resp.GetEvents()[1].GetStartTime().AsTime()))
+	s.Require().NotEmpty(resp.GetNextPageToken())
+
+	request.PageToken = resp.GetNextPageToken()
+	resp, err = client.ListEvents(ctx, request)
+	s.Require().NoError(err)
+	s.Require().Len(resp.GetEvents(), 1)
+	s.Require().True(t.AddDate(0, 0, 2).Equal(resp.GetEvents()[0].GetStartTime().AsTime()))
+	s.Require().Empty(resp.GetNextPageToken())
+
+	request.PageToken = "not a token"
+	_, err = client.ListEvents(ctx, request)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestSearchEvents() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+
+	t := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Sprint planning", "Sprint review", "Lunch"} {
+		_, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+			Title:     title,
+			StartTime: timestamppb.New(t.AddDate(0, 0, i)),
+			EndTime:   timestamppb.New(t.AddDate(0, 0, i).Add(time.Hour)),
+		}})
+		s.Require().NoError(err)
+	}
+
+	resp, err := client.SearchEvents(ctx, &pb.SearchEventsRequest{Query: "sprint"})
+	s.Require().NoError(err)
+	s.Require().Len(resp.GetHits(), 2)
+	s.Require().Equal("Sprint planning", resp.GetHits()[0].GetEvent().GetTitle())
+	s.Require().Equal("<b>Sprint</b> planning", resp.GetHits()[0].GetSnippet())
+	s.Require().Positive(resp.GetHits()[0].GetRank())
+
+	resp, err = client.SearchEvents(ctx, &pb.SearchEventsRequest{Query: "sprint", From: timestamppb.New(t.AddDate(0, 0, 1))})
+	s.Require().NoError(err)
+	s.Require().Len(resp.GetHits(), 1)
+	s.Require().Equal("Sprint review", resp.GetHits()[0].GetEvent().GetTitle())
+
+	_, err = client.SearchEvents(ctx, &pb.SearchEventsRequest{})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestWatchEvents() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx, cancel := context.WithCancel(s.userContext(faker.UUIDHyphenated()))
+	defer cancel()
+
+	t := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	stream, err := client.WatchEvents(ctx, &pb.WatchEventsRequest{From: timestamppb.New(t)})
+	s.Require().NoError(err)
+	// stream is established when headers are received, so the subscription already exists
+	_, err = stream.Header()
+	s.Require().NoError(err)
+
+	addResp, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+		Title:     "Standup",
+		StartTime: timestamppb.New(t),
+		EndTime:   timestamppb.New(t.Add(time.Hour)),
+	}})
+	s.Require().NoError(err)
+	_, err = client.DeleteEvent(ctx, &pb.DeleteEventRequest{EventId: addResp.GetEvent().GetId()})
+	s.Require().NoError(err)
+
+	change, err := stream.Recv()
+	s.Require().NoError(err)
+	s.Require().Equal(string(app.ChangeCreated), change.GetType())
+	s.Require().Equal(addResp.GetEvent().GetId(), change.GetEvent().GetId())
+	change, err = stream.Recv()
+	s.Require().NoError(err)
+	s.Require().Equal(string(app.ChangeDeleted), change.GetType())
+	s.Require().Nil(change.GetPrevious())
+
+	cancel()
+	_, err = stream.Recv()
+	s.Require().Equal(codes.Canceled, status.Code(err))
+
+	stream, err = client.WatchEvents(s.ctx, &pb.WatchEventsRequest{})
+	s.Require().NoError(err)
+	_, err = stream.Recv()
+	s.Require().Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestImportExportEvents() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"SUMMARY:Standup",
+		"DTSTART:20210906T100000Z",
+		"DTEND:20210906T101500Z",
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:broken@example.com",
+		"SUMMARY:Without start",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	importResp, err := client.ImportEvents(ctx, &pb.ImportEventsRequest{Calendar: calendar})
+	s.Require().NoError(err)
+	s.Require().Equal(int32(1), importResp.GetCreated())
+	s.Require().Equal(int32(1), importResp.GetFailed())
+	s.Require().Len(importResp.GetItems(), 2)
+	s.Require().Equal("standup@example.com", importResp.GetItems()[0].GetUid())
+	s.Require().Equal("failed", importResp.GetItems()[1].GetStatus())
+
+	// importing the same calendar again updates previously imported event
+	importResp, err = client.ImportEvents(ctx, &pb.ImportEventsRequest{Calendar: calendar})
+	s.Require().NoError(err)
+	s.Require().Equal(int32(1), importResp.GetUpdated())
+
+	exportResp, err := client.ExportEvents(ctx, &pb.ExportEventsRequest{
+		From: timestamppb.New(time.Date(2021, time.September, 7, 0, 0, 0, 0, time.UTC)),
+		To:   timestamppb.New(time.Date(2021, time.September, 8, 0, 0, 0, 0, time.UTC)),
+	})
+	s.Require().NoError(err)
+	s.Require().Contains(exportResp.GetCalendar(), "UID:"+importResp.GetItems()[0].GetEventId())
+	s.Require().Contains(exportResp.GetCalendar(), "RRULE:FREQ=DAILY;COUNT=5")
+	s.Require().Equal(1, strings.Count(exportResp.GetCalendar(), "BEGIN:VEVENT"))
+
+	_, err = client.ImportEvents(ctx, &pb.ImportEventsRequest{Calendar: "not a calendar"})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+// userContext - returns context of the request made by the user.
+func (s *GRPCTestSuite) TestJWTAuthentication() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	userID := faker.UUIDHyphenated()
+	token, err := s.tokens.Issue(userID, time.Minute)
+	s.Require().NoError(err)
+	// event owner is the token subject whatever user ID is claimed
+	ctx := metadata.AppendToOutgoingContext(s.ctx,
+		AuthorizationMetadataKey, "Bearer "+token, UserIDMetadataKey, faker.UUIDHyphenated())
+
+	resp, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+		Title:     faker.Sentence(),
+		StartTime: timestamppb.New(time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)),
+		EndTime:   timestamppb.New(time.Date(2021, time.September, 6, 11, 0, 0, 0, time.UTC)),
+	}})
+	s.Require().NoError(err)
+	s.Require().Equal(userID, resp.GetEvent().GetOwnerId())
+
+	ctx = metadata.AppendToOutgoingContext(s.ctx, AuthorizationMetadataKey, "Bearer "+token+"x")
+	_, err = client.DeleteEvent(ctx, &pb.DeleteEventRequest{EventId: resp.GetEvent().GetId()})
+	s.Require().Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = client.DeleteEvent(s.ctx, &pb.DeleteEventRequest{EventId: resp.GetEvent().GetId()})
+	s.Require().Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestAttendees() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ownerID, attendeeID := faker.UUIDHyphenated(), faker.UUIDHyphenated()
+	owner, attendee := s.userContext(ownerID), s.userContext(attendeeID)
+	day := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	resp, err := client.AddEvent(owner, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+		Title:     faker.Sentence(),
+		StartTime: timestamppb.New(day),
+		EndTime:   timestamppb.New(day.Add(time.Hour)),
+	}})
+	s.Require().NoError(err)
+	eventID := resp.GetEvent().GetId()
+
+	_, err = client.InviteAttendee(attendee, &pb.InviteAttendeeRequest{EventId: eventID, UserId: attendeeID, Role: "editor"})
+	s.Require().Equal(codes.NotFound, status.Code(err), "event of another user must not be found")
+	_, err = client.InviteAttendee(owner, &pb.InviteAttendeeRequest{EventId: eventID, UserId: attendeeID, Role: "admin"})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	invited, err := client.InviteAttendee(owner, &pb.InviteAttendeeRequest{EventId: eventID, UserId: attendeeID, Role: "viewer"})
+	s.Require().NoError(err)
+	s.Require().Equal("needs_action", invited.GetAttendee().GetStatus())
+
+	// attended event is found for the attendee, but it could be changed only by editors
+	found, err := client.FindDayEvents(attendee, &pb.FindDayEventsRequest{Day: timestamppb.New(day)})
+	s.Require().NoError(err)
+	s.Require().Len(found.GetEvents(), 1)
+	_, err = client.DeleteEvent(attendee, &pb.DeleteEventRequest{EventId: eventID})
+	s.Require().Equal(codes.PermissionDenied, status.Code(err))
+
+	responded, err := client.RespondToInvitation(attendee, &pb.RespondToInvitationRequest{EventId: eventID, Status: "accepted"})
+	s.Require().NoError(err)
+	s.Require().Equal("accepted", responded.GetAttendee().GetStatus())
+	attendees, err := client.ListAttendees(owner, &pb.ListAttendeesRequest{EventId: eventID})
+	s.Require().NoError(err)
+	s.Require().Len(attendees.GetAttendees(), 1)
+	s.Require().Equal(attendeeID, attendees.GetAttendees()[0].GetUserId())
+
+	_, err = client.RemoveAttendee(attendee, &pb.RemoveAttendeeRequest{EventId: eventID, UserId: attendeeID})
+	s.Require().NoError(err)
+	_, err = client.ListAttendees(attendee, &pb.ListAttendeesRequest{EventId: eventID})
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestCalendars() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+	day := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+
+	_, err := client.CreateCalendar(ctx, &pb.CreateCalendarRequest{Calendar: &pb.Calendar{Name: "Work", Color: "red"}})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	created, err := client.CreateCalendar(ctx, &pb.CreateCalendarRequest{Calendar: &pb.Calendar{Name: "Work"}})
+	s.Require().NoError(err)
+	calendar := created.GetCalendar()
+
+	calendar.Color = "#0000ff"
+	_, err = client.UpdateCalendar(s.userContext(faker.UUIDHyphenated()), &pb.UpdateCalendarRequest{Calendar: calendar})
+	s.Require().Equal(codes.NotFound, status.Code(err), "calendar of another user must not be found")
+	_, err = client.UpdateCalendar(ctx, &pb.UpdateCalendarRequest{Calendar: calendar})
+	s.Require().NoError(err)
+	found, err := client.GetCalendar(ctx, &pb.GetCalendarRequest{CalendarId: calendar.GetId()})
+	s.Require().NoError(err)
+	s.Require().Equal("#0000ff", found.GetCalendar().GetColor())
+
+	event, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+		Title:      faker.Sentence(),
+		StartTime:  timestamppb.New(day),
+		EndTime:    timestamppb.New(day.Add(time.Hour)),
+		CalendarId: calendar.GetId(),
+	}})
+	s.Require().NoError(err)
+	s.Require().Equal(calendar.GetId(), event.GetEvent().GetCalendarId())
+	_, err = client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+		Title:     faker.Sentence(),
+		StartTime: timestamppb.New(day.Add(time.Hour)),
+		EndTime:   timestamppb.New(day.Add(2 * time.Hour)),
+	}})
+	s.Require().NoError(err)
+
+	events, err := client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: timestamppb.New(day), CalendarIds: []string{calendar.GetId()}})
+	s.Require().NoError(err)
+	s.Require().Len(events.GetEvents(), 1)
+	s.Require().Equal(event.GetEvent().GetId(), events.GetEvents()[0].GetId())
+
+	_, err = client.DeleteCalendar(ctx, &pb.DeleteCalendarRequest{CalendarId: calendar.GetId()})
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	_, err = client.DeleteEvent(ctx, &pb.DeleteEventRequest{EventId: event.GetEvent().GetId()})
+	s.Require().NoError(err)
+	_, err = client.DeleteCalendar(ctx, &pb.DeleteCalendarRequest{CalendarId: calendar.GetId()})
+	s.Require().NoError(err)
+	calendars, err := client.ListCalendars(ctx, &pb.ListCalendarsRequest{})
+	s.Require().NoError(err)
+	s.Require().Empty(calendars.GetCalendars())
+}
+
+func (s *GRPCTestSuite) TestUserSettings() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+	// 2021-09-06 20:00 UTC is September 7 in Tokyo
+	start := time.Date(2021, time.September, 6, 20, 0, 0, 0, time.UTC)
+	_, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+		Title:     faker.Sentence(),
+		StartTime: timestamppb.New(start),
+		EndTime:   timestamppb.New(start.Add(time.Hour)),
+	}})
+	s.Require().NoError(err)
+
+	_, err = client.UpdateUserSettings(ctx, &pb.UpdateUserSettingsRequest{Settings: &pb.UserSettings{TimeZone: "Mars/Olympus"}})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	updated, err := client.UpdateUserSettings(ctx, &pb.UpdateUserSettingsRequest{Settings: &pb.UserSettings{TimeZone: "Asia/Tokyo"}})
+	s.Require().NoError(err)
+	s.Require().Equal("Asia/Tokyo", updated.GetSettings().GetTimeZone())
+	found, err := client.GetUserSettings(ctx, &pb.GetUserSettingsRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal("Asia/Tokyo", found.GetSettings().GetTimeZone())
+
+	// the day boundaries are calculated in the user time zone if the request has no time zone
+	day := timestamppb.New(time.Date(2021, time.September, 7, 0, 0, 0, 0, time.UTC))
+	events, err := client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: day})
+	s.Require().NoError(err)
+	s.Require().Equal("Asia/Tokyo", events.GetTimeZone())
+	s.Require().Len(events.GetEvents(), 1)
+	events, err = client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: day, TimeZone: "UTC"})
+	s.Require().NoError(err)
+	s.Require().Empty(events.GetEvents())
+}
+
+func (s *GRPCTestSuite) TestFreeBusy() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	firstID, secondID := faker.UUIDHyphenated(), faker.UUIDHyphenated()
+	day := time.Date(2021, time.September, 6, 9, 0, 0, 0, time.UTC)
+	for _, userID := range []string{firstID, secondID} {
+		_, err := client.AddEvent(s.userContext(userID), &pb.AddEventRequest{CreateEventData: &pb.AddEventRequest_CreateEventData{
+			Title:     faker.Sentence(),
+			StartTime: timestamppb.New(day),
+			EndTime:   timestamppb.New(day.Add(time.Hour)),
+		}})
+		s.Require().NoError(err)
+	}
+	ctx := s.userContext(firstID)
+
+	busy, err := client.FreeBusy(ctx, &pb.FreeBusyRequest{
+		UserIds: []string{firstID, secondID},
+		From:    timestamppb.New(day),
+		To:      timestamppb.New(day.Add(4 * time.Hour)),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(busy.GetUsers(), 2)
+	s.Require().Equal(secondID, busy.GetUsers()[1].GetUserId())
+	s.Require().Len(busy.GetUsers()[1].GetBusy(), 1)
+	s.Require().True(day.Equal(busy.GetUsers()[1].GetBusy()[0].GetStart().AsTime()))
+
+	slot, err := client.FindFreeSlot(ctx, &pb.FindFreeSlotRequest{
+		UserIds:  []string{firstID, secondID},
+		From:     timestamppb.New(day),
+		To:       timestamppb.New(day.Add(4 * time.Hour)),
+		Duration: durationpb.New(30 * time.Minute),
+	})
+	s.Require().NoError(err)
+	s.Require().True(day.Add(time.Hour).Equal(slot.GetSlot().GetStart().AsTime()))
+
+	_, err = client.FindFreeSlot(ctx, &pb.FindFreeSlotRequest{
+		UserIds:  []string{firstID, secondID},
+		From:     timestamppb.New(day),
+		To:       timestamppb.New(day.Add(time.Hour)),
+		Duration: durationpb.New(30 * time.Minute),
+	})
+	s.Require().Equal(codes.NotFound, status.Code(err))
+	_, err = client.FindFreeSlot(ctx, &pb.FindFreeSlotRequest{
+		UserIds: []string{firstID},
+		From:    timestamppb.New(day),
+		To:      timestamppb.New(day.Add(time.Hour)),
+	})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCTestSuite) TestBatchEvents() {
+	client := pb.NewCalendarServiceClient(s.grpcClientConn)
+	ctx := s.userContext(faker.UUIDHyphenated())
+	day := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	newEventData := func(start time.Time) *pb.AddEventRequest_CreateEventData {
+		return &pb.AddEventRequest_CreateEventData{
+			Title:     faker.Sentence(),
+			StartTime: timestamppb.New(start),
+			EndTime:   timestamppb.New(start.Add(time.Hour)),
+		}
+	}
+	added, err := client.AddEvent(ctx, &pb.AddEventRequest{CreateEventData: newEventData(day)})
+	s.Require().NoError(err)
+	event := added.GetEvent()
+
+	// the created event overlaps the existing one, so the batch is not applied
+	resp, err := client.BatchEvents(ctx, &pb.BatchEventsRequest{Operations: []*pb.BatchOperation{
+		{Action: "create", CreateEventData: newEventData(day.Add(2 * time.Hour))},
+		{Action: "create", CreateEventData: newEventData(day.Add(30 * time.Minute))},
+	}})
+	s.Require().NoError(err)
+	s.Require().False(resp.GetApplied())
+	s.Require().Equal("aborted", resp.GetItems()[0].GetStatus())
+	s.Require().Equal("failed", resp.GetItems()[1].GetStatus())
+	events, err := client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: timestamppb.New(day)})
+	s.Require().NoError(err)
+	s.Require().Len(events.GetEvents(), 1)
+
+	event.Title = faker.Sentence()
+	resp, err = client.BatchEvents(ctx, &pb.BatchEventsRequest{Operations: []*pb.BatchOperation{
+		{Action: "update", Event: event},
+		{Action: "create", CreateEventData: newEventData(day.Add(2 * time.Hour))},
+	}})
+	s.Require().NoError(err)
+	s.Require().True(resp.GetApplied())
+	s.Require().Equal(int64(2), resp.GetItems()[0].GetVersion())
+	createdID := resp.GetItems()[1].GetEventId()
+	s.Require().NotEmpty(createdID)
+
+	resp, err = client.BatchEvents(ctx, &pb.BatchEventsRequest{Operations: []*pb.BatchOperation{
+		{Action: "delete", EventId: event.GetId()},
+		{Action: "delete", EventId: createdID},
+	}})
+	s.Require().NoError(err)
+	s.Require().True(resp.GetApplied())
+	events, err = client.FindDayEvents(ctx, &pb.FindDayEventsRequest{Day: timestamppb.New(day)})
+	s.Require().NoError(err)
+	s.Require().Empty(events.GetEvents())
+
+	_, err = client.BatchEvents(ctx, &pb.BatchEventsRequest{Operations: []*pb.BatchOperation{{Action: "delete"}}})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	_, err = client.BatchEvents(ctx, &pb.BatchEventsRequest{})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCTestSuite) userContext(userID string) context.Context {
+	return metadata.AppendToOutgoingContext(s.ctx, UserIDMetadataKey, userID)
+}
+
 func PbEventsContains(events []*pb.Event, event *pb.Event) bool {
 	e2, err := MapToStorageFormat(event)
 	if err != nil {
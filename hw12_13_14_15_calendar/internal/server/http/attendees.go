@@ -0,0 +1,88 @@
+package internalhttp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+type ListAttendeesResponse struct {
+	Attendees []storage.Attendee `json:"attendees"`
+}
+
+type InviteAttendeeData struct {
+	Role storage.AttendeeRole `json:"role"`
+}
+
+type RespondToInvitationData struct {
+	Status storage.RSVPStatus `json:"status"`
+}
+
+// ListAttendeesHandler - responds with attendees of the event the user owns or attends.
+func (s Service) ListAttendeesHandler(w http.ResponseWriter, r *http.Request) {
+	attendees, err := s.app.ListAttendees(r.Context(), mux.Vars(r)["eventId"])
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, ListAttendeesResponse{Attendees: attendees}); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// InviteAttendeeHandler - invites the path user to the event with the body role, responds with the attendee.
+// Inviting the existing attendee again changes the role.
+func (s Service) InviteAttendeeHandler(w http.ResponseWriter, r *http.Request) {
+	data := new(InviteAttendeeData)
+	if err := receiveJSON(r, data); err != nil {
+		s.failReceive(w, err)
+		return
+	}
+	vars := mux.Vars(r)
+	attendee, err := s.app.InviteAttendee(r.Context(), vars["eventId"], vars["userId"], data.Role)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, attendee); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// RemoveAttendeeHandler - removes the path user from the event attendees, responds 204 No Content.
+func (s Service) RemoveAttendeeHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := s.app.RemoveAttendee(r.Context(), vars["eventId"], vars["userId"]); err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RespondToInvitationHandler - saves response of the user to the event invitation, responds with the attendee.
+func (s Service) RespondToInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	data := new(RespondToInvitationData)
+	if err := receiveJSON(r, data); err != nil {
+		s.failReceive(w, err)
+		return
+	}
+	attendee, err := s.app.RespondToInvitation(r.Context(), mux.Vars(r)["eventId"], data.Status)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, attendee); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// failReceive - responds with the error of request body receiving.
+func (s Service) failReceive(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		s.fail(w, err, http.StatusUnsupportedMediaType)
+		return
+	}
+	s.fail(w, err, http.StatusBadRequest)
+}
@@ -0,0 +1,64 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"go.uber.org/zap"
+)
+
+// maxBatchRequestSize - max size of the batch request body in bytes.
+const maxBatchRequestSize = 10 << 20
+
+type BatchOperationData struct {
+	// Action - create, update or delete
+	Action storage.BatchAction `json:"action"`
+	// Event - created or updated event, ID of created event is generated, updated event version is required
+	Event storage.Event `json:"event"`
+	// EventID - ID of deleted event
+	EventID string `json:"event_id"`
+}
+
+type BatchEventsRequest struct {
+	Operations []BatchOperationData `json:"operations"`
+}
+
+func (r BatchEventsRequest) toOperations() []storage.BatchOperation {
+	operations := make([]storage.BatchOperation, 0, len(r.Operations))
+	for _, data := range r.Operations {
+		operation := storage.BatchOperation{Action: data.Action, Event: data.Event}
+		if data.Action == storage.BatchDelete {
+			operation.Event = storage.Event{ID: data.EventID}
+		}
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+// BatchEventsHandler - creates, updates and deletes user events all-or-nothing, responds with per operation results.
+// If the batch is not applied, the results are sent with 422 Unprocessable Entity and every failed operation has an error.
+func (s Service) BatchEventsHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchRequestSize)
+	data := new(BatchEventsRequest)
+	if err := receiveJSON(r, data); err != nil {
+		s.failReceive(w, err)
+		return
+	}
+	report, err := s.app.BatchEvents(r.Context(), data.toOperations())
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if report.Applied {
+		if err := sendJSON(w, report); err != nil {
+			s.fail(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	// status is sent before the body, so content type is set here and encoding error can only be logged
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	if err := sendJSON(w, report); err != nil {
+		zap.L().Error("error during sending rejected batch report", zap.Error(err))
+	}
+}
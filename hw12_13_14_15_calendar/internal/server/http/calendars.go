@@ -0,0 +1,100 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// calendarsResource - path of the calendars collection, calendar resources are calendarsResource + "/{calendarId}".
+const calendarsResource = "/v1/calendars"
+
+type CalendarData struct {
+	Name string `json:"name"`
+	// Color - optional #RRGGBB color
+	Color string `json:"color"`
+	// TimeZone - optional IANA time zone
+	TimeZone string `json:"time_zone"`
+}
+
+func (d CalendarData) toCalendar() storage.Calendar {
+	return storage.Calendar{Name: d.Name, Color: d.Color, TimeZone: d.TimeZone}
+}
+
+type ListCalendarsResponse struct {
+	Calendars []storage.Calendar `json:"calendars"`
+}
+
+// CreateCalendarHandler - creates calendar, responds 201 Created with the calendar and its URL in Location header.
+func (s Service) CreateCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	data := new(CalendarData)
+	if err := receiveJSON(r, data); err != nil {
+		s.failReceive(w, err)
+		return
+	}
+	calendar, err := s.app.CreateCalendar(r.Context(), data.toCalendar())
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.Header().Set("Location", calendarsResource+"/"+calendar.ID)
+	// status is sent before the body, so content type is set here and encoding error can only be logged
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := sendJSON(w, calendar); err != nil {
+		zap.L().Error("error during sending created calendar", zap.Error(err))
+	}
+}
+
+// ListCalendarsHandler - responds with calendars of the user ordered by name.
+func (s Service) ListCalendarsHandler(w http.ResponseWriter, r *http.Request) {
+	calendars, err := s.app.ListCalendars(r.Context())
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, ListCalendarsResponse{Calendars: calendars}); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+func (s Service) GetCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	calendar, err := s.app.GetCalendar(r.Context(), mux.Vars(r)["calendarId"])
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, calendar); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// ReplaceCalendarHandler - replaces all calendar fields with the body ones, the calendar ID is taken from the path.
+func (s Service) ReplaceCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	data := new(CalendarData)
+	if err := receiveJSON(r, data); err != nil {
+		s.failReceive(w, err)
+		return
+	}
+	calendar := data.toCalendar()
+	calendar.ID = mux.Vars(r)["calendarId"]
+	updated, err := s.app.UpdateCalendar(r.Context(), calendar)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, updated); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// RemoveCalendarHandler - deletes calendar without events, responds 204 No Content.
+func (s Service) RemoveCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.app.DeleteCalendar(r.Context(), mux.Vars(r)["calendarId"]); err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
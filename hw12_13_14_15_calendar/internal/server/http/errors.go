@@ -0,0 +1,88 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"go.uber.org/zap"
+)
+
+// apiV1Prefix - path prefix of REST routes, they respond with ErrorResponse bodies.
+const apiV1Prefix = "/v1/"
+
+// statusCodes - error codes of response statuses, errors sharing the same status are told apart by errorCode.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:           "invalid_argument",
+	http.StatusUnauthorized:         "unauthenticated",
+	http.StatusForbidden:            "permission_denied",
+	http.StatusNotFound:             "not_found",
+	http.StatusConflict:             "conflict",
+	http.StatusPreconditionFailed:   "precondition_failed",
+	http.StatusUnsupportedMediaType: "unsupported_media_type",
+	http.StatusPreconditionRequired: "precondition_required",
+	http.StatusServiceUnavailable:   "unavailable",
+}
+
+// ErrorResponse - error body of REST routes.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	// Code - machine readable error kind
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details - additional error data, e.g. "field" of invalid argument
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// fail - responds with the error, see writeError.
+func (s Service) fail(w http.ResponseWriter, err error, status int) {
+	writeError(w, err, status, s.structuredErrors)
+}
+
+// writeError - responds with ErrorResponse body if structured is set, otherwise with plain text as http.Error does.
+func writeError(w http.ResponseWriter, err error, status int, structured bool) {
+	if !structured {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	body := ErrorBody{Code: errorCode(err, status), Message: err.Error()}
+	var validationErr *app.ValidationError
+	if errors.As(err, &validationErr) {
+		body.Details = map[string]string{"field": validationErr.Field}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: body}); err != nil {
+		zap.L().Error("error during sending error response", zap.Error(err))
+	}
+}
+
+func errorCode(err error, status int) string {
+	switch {
+	case errors.Is(err, storage.ErrEventAlreadyExists):
+		return "already_exists"
+	case errors.Is(err, storage.ErrDateBusy):
+		return "date_busy"
+	case errors.Is(err, storage.ErrVersionConflict):
+		return "version_conflict"
+	case errors.Is(err, storage.ErrCalendarNotEmpty):
+		return "calendar_not_empty"
+	case errors.Is(err, app.ErrNoFreeSlot):
+		return "no_free_slot"
+	}
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	return "internal"
+}
+
+func isAPIV1(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiV1Prefix)
+}
@@ -0,0 +1,69 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+)
+
+// freeBusyResource - path of free/busy intervals of the users, common free slot is freeBusyResource + "/slot".
+const freeBusyResource = "/v1/freebusy"
+
+type FreeBusyResponse struct {
+	Users []app.UserBusy `json:"users"`
+}
+
+// FreeBusyHandler - responds with busy intervals of the users passed in repeated user param
+// within [from, to) range, range bounds are passed in RFC 3339 format.
+func (s Service) FreeBusyHandler(w http.ResponseWriter, r *http.Request) {
+	userIDs, from, to, err := parseFreeBusyQuery(r.URL.Query())
+	if err != nil {
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	users, err := s.app.FreeBusy(r.Context(), userIDs, from, to)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, FreeBusyResponse{Users: users}); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// FindFreeSlotHandler - responds with the earliest interval within [from, to) range when all the users are free,
+// duration param is in time.ParseDuration format, e.g. 30m, 404 Not Found is sent if there is no such interval.
+func (s Service) FindFreeSlotHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userIDs, from, to, err := parseFreeBusyQuery(query)
+	if err != nil {
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	duration, err := time.ParseDuration(query.Get("duration"))
+	if err != nil {
+		s.fail(w, fmt.Errorf("not valid duration param: %w", err), http.StatusBadRequest)
+		return
+	}
+	slot, err := s.app.FindFreeSlot(r.Context(), userIDs, from, to, duration)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, slot); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+func parseFreeBusyQuery(query url.Values) (userIDs []string, from, to time.Time, err error) {
+	if from, err = time.Parse(time.RFC3339, query.Get("from")); err != nil {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("not valid from param: %w", err)
+	}
+	if to, err = time.Parse(time.RFC3339, query.Get("to")); err != nil {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("not valid to param: %w", err)
+	}
+	return query["user"], from, to, nil
+}
@@ -1,14 +1,90 @@
 package internalhttp
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/auth"
 	"go.uber.org/zap"
 )
 
+const (
+	// UserIDHeader - header with ID of the user who makes the request, it is trusted only by auth.TrustedUserID.
+	UserIDHeader = "X-User-ID"
+	// TimeZoneHeader - header with default IANA time zone of the user who makes the request,
+	// tz query param overrides it.
+	TimeZoneHeader = "X-Time-Zone"
+	// timeZoneParam - query param with IANA time zone of the request
+	timeZoneParam = "tz"
+)
+
 var RequestTimeFormat string = "25/Feb/2020:19:11:24 +0600"
 
+// authMiddleware - puts ID of the user authenticated by the request credentials into request context.
+// Requests without credentials are passed unauthenticated and are rejected by the application,
+// requests with invalid credentials are rejected right away.
+func authMiddleware(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := authenticator.Authenticate(r.Context(), auth.Credentials{
+				APIKey:      r.Header.Get(auth.APIKeyHeader),
+				BearerToken: auth.BearerToken(r.Header.Get(auth.AuthorizationHeader)),
+				UserID:      r.Header.Get(UserIDHeader),
+			})
+			switch {
+			case errors.Is(err, auth.ErrNoCredentials):
+				next.ServeHTTP(w, r)
+			case err != nil:
+				w.Header().Set("WWW-Authenticate", `Bearer realm="calendar"`)
+				writeError(w, err, http.StatusUnauthorized, isAPIV1(r))
+			default:
+				next.ServeHTTP(w, r.WithContext(app.WithUserID(r.Context(), userID)))
+			}
+		})
+	}
+}
+
+// userMiddleware - puts time zone from the request headers and params into request context.
+func userMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		timeZone := r.URL.Query().Get(timeZoneParam)
+		if timeZone == "" {
+			timeZone = r.Header.Get(TimeZoneHeader)
+		}
+		if timeZone != "" {
+			loc, err := app.LoadTimeZone(timeZone)
+			if err != nil {
+				writeError(w, err, http.StatusBadRequest, isAPIV1(r))
+				return
+			}
+			ctx = app.WithTimeZone(ctx, loc)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// deprecated - marks responses of the route replaced by the successor one (RFC 8594 and draft Deprecation header).
+func deprecated(handler http.HandlerFunc, successor string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		handler(w, r)
+	})
+}
+
+// timeoutMiddleware - responds with 503 status if the request is not handled in requestTimeout.
+func timeoutMiddleware(next http.Handler) http.Handler {
+	return http.TimeoutHandler(next, requestTimeout, "request timeout")
+}
+
+// bulkTimeoutMiddleware - responds with 503 status if the bulk request is not handled in bulkRequestTimeout.
+func bulkTimeoutMiddleware(next http.Handler) http.Handler {
+	return http.TimeoutHandler(next, bulkRequestTimeout, "request timeout")
+}
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		delegator := NewResponseWriterDelegator(w)
@@ -42,3 +118,10 @@ func (d *ResponseWriterDelegator) WriteHeader(statusCode int) {
 	d.responseStatusCode = statusCode
 	d.ResponseWriter.WriteHeader(statusCode)
 }
+
+// Flush - lets streaming handlers flush responses through the delegator.
+func (d *ResponseWriterDelegator) Flush() {
+	if flusher, ok := d.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,134 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// MergePatchContentType - media type of JSON merge patch (RFC 7396).
+const MergePatchContentType = "application/merge-patch+json"
+
+var (
+	ErrRequiredField     = errors.New("required field can't be removed")
+	ErrNotPatchableField = errors.New("field can't be patched")
+)
+
+// PatchEventHandler - partially updates event with JSON merge patch: only fields present in the patch are changed,
+// optional fields set to null are cleared. Optional If-Match header binds the patch to the event version,
+// otherwise the patch is applied to the current event state. Responds with the updated event and its new ETag.
+func (s Service) PatchEventHandler(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["eventId"]
+	var version int64
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		var err error
+		if version, err = parseETag(ifMatch); err != nil {
+			s.fail(w, fmt.Errorf("not valid If-Match header: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+	patch, err := receiveMergePatch(r)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedMediaType) {
+			s.fail(w, err, http.StatusUnsupportedMediaType)
+			return
+		}
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+
+	updated, err := s.app.PatchEvent(r.Context(), eventID, patch, version)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.Header().Set("ETag", eventETag(updated))
+	if err := sendJSON(w, &updated); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// receiveMergePatch - reads JSON merge patch of storage.Event fields, application/json media type is accepted too.
+func receiveMergePatch(r *http.Request) (app.EventPatch, error) {
+	var patch app.EventPatch
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return patch, err
+	}
+	if mediaType != MergePatchContentType && mediaType != "application/json" {
+		return patch, ErrUnsupportedMediaType
+	}
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		return patch, err
+	}
+
+	for name, value := range fields {
+		switch name {
+		case "title":
+			patch.Title = new(string)
+			err = decodeRequiredField(name, value, patch.Title)
+		case "start_time":
+			patch.StartTime = new(time.Time)
+			err = decodeRequiredField(name, value, patch.StartTime)
+		case "end_time":
+			patch.EndTime = new(time.Time)
+			err = decodeRequiredField(name, value, patch.EndTime)
+		case "description":
+			patch.Description = new(string)
+			err = decodeOptionalField(name, value, patch.Description)
+		case "notify_before":
+			patch.NotifyBefore = new(time.Duration)
+			err = decodeOptionalField(name, value, patch.NotifyBefore)
+		case "recurrence_rule":
+			patch.RecurrenceRule = new(string)
+			err = decodeOptionalField(name, value, patch.RecurrenceRule)
+		case "exception_dates":
+			patch.ExceptionDates = new(storage.ExceptionDates)
+			err = decodeOptionalField(name, value, patch.ExceptionDates)
+		case "calendar_id":
+			patch.CalendarID = new(string)
+			err = decodeOptionalField(name, value, patch.CalendarID)
+		case "time_zone":
+			patch.TimeZone = new(string)
+			err = decodeOptionalField(name, value, patch.TimeZone)
+		default:
+			// id, owner_id and version are managed by the service
+			err = fmt.Errorf("%w: %s", ErrNotPatchableField, name)
+		}
+		if err != nil {
+			return patch, err
+		}
+	}
+	return patch, nil
+}
+
+func isNull(value json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+}
+
+func decodeRequiredField(name string, value json.RawMessage, dest interface{}) error {
+	if isNull(value) {
+		return fmt.Errorf("%w: %s", ErrRequiredField, name)
+	}
+	return decodeOptionalField(name, value, dest)
+}
+
+// decodeOptionalField - null value leaves dest zero.
+func decodeOptionalField(name string, value json.RawMessage, dest interface{}) error {
+	if isNull(value) {
+		return nil
+	}
+	if err := json.Unmarshal(value, dest); err != nil {
+		return fmt.Errorf("not valid %s field: %w", name, err)
+	}
+	return nil
+}
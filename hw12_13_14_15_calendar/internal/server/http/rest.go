@@ -0,0 +1,100 @@
+package internalhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// eventsResource - path of the events collection, event resources are eventsResource + "/{eventId}".
+const eventsResource = "/v1/events"
+
+// CreateEventHandler - creates event, responds 201 Created with the event,
+// its URL in Location header and its ETag.
+func (s Service) CreateEventHandler(w http.ResponseWriter, r *http.Request) {
+	eventData := new(CreateEventData)
+	if err := receiveJSON(r, eventData); err != nil {
+		if errors.Is(err, ErrUnsupportedMediaType) {
+			s.fail(w, err, http.StatusUnsupportedMediaType)
+			return
+		}
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+
+	event, err := s.app.CreateEvent(r.Context(), eventData.toEvent())
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.Header().Set("Location", eventsResource+"/"+event.ID)
+	w.Header().Set("ETag", eventETag(event))
+	// status is sent before the body, so content type is set here and encoding error can only be logged
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := sendJSON(w, &event); err != nil {
+		zap.L().Error("error during sending created event", zap.Error(err))
+	}
+}
+
+// GetEventHandler - responds with the event and its ETag, event times are in the request time zone.
+func (s Service) GetEventHandler(w http.ResponseWriter, r *http.Request) {
+	event, err := s.app.GetEvent(r.Context(), mux.Vars(r)["eventId"])
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.Header().Set("ETag", eventETag(event))
+	if err := sendJSON(w, &event); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// ReplaceEventHandler - replaces all event fields with the body ones if If-Match header contains ETag
+// of the current event version. Body is the same as the creation one, the event ID is taken from the path.
+func (s Service) ReplaceEventHandler(w http.ResponseWriter, r *http.Request) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		s.fail(w, ErrPreconditionRequired, http.StatusPreconditionRequired)
+		return
+	}
+	version, err := parseETag(ifMatch)
+	if err != nil {
+		s.fail(w, fmt.Errorf("not valid If-Match header: %w", err), http.StatusBadRequest)
+		return
+	}
+	eventData := new(CreateEventData)
+	if err := receiveJSON(r, eventData); err != nil {
+		if errors.Is(err, ErrUnsupportedMediaType) {
+			s.fail(w, err, http.StatusUnsupportedMediaType)
+			return
+		}
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+
+	event := eventData.toEvent()
+	event.ID = mux.Vars(r)["eventId"]
+	event.Version = version
+	updated, err := s.app.UpdateEvent(r.Context(), event)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.Header().Set("ETag", eventETag(updated))
+	if err := sendJSON(w, &updated); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// RemoveEventHandler - deletes event, responds 204 No Content.
+func (s Service) RemoveEventHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.app.DeleteEvent(r.Context(), mux.Vars(r)["eventId"]); err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
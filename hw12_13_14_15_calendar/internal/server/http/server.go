@@ -8,36 +8,116 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/api"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/auth"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
+const (
+	// requestTimeout - time limit of all requests except long-lived streams and bulk requests.
+	requestTimeout = 5 * time.Second
+	// bulkRequestTimeout - time limit of batch and import requests, they change up to thousands of events.
+	bulkRequestTimeout = time.Minute
+	// gatewayPrefix - path prefix of gRPC gateway routes, see api/calendar_service.proto.
+	gatewayPrefix = "/api/"
+	// gatewayWatchPath - gateway stream of event changes.
+	gatewayWatchPath = gatewayPrefix + "v1/events:watch"
+	// gatewayImportPath and gatewayBatchPath - gateway bulk requests.
+	gatewayImportPath = gatewayPrefix + "v1/events:import"
+	gatewayBatchPath  = gatewayPrefix + "v1/events:batch"
+)
+
 type API struct {
 	server *http.Server
 }
 
-func NewHTTPApi(cnf config.HTTPApiConfig, app server.Application) *API {
-	service := Service{app}
-	router := mux.NewRouter()
-	router.HandleFunc("/calendar/add", service.AddEventHandler).Methods("POST")
-	router.HandleFunc("/calendar/update", service.UpdateEventHandler).Methods("POST")
-	router.HandleFunc("/calendar/delete/{eventId}", service.DeleteEventHandler).Methods("POST")
+// NewHTTPApi - requests are authenticated by the authenticator,
+// gateway is the gRPC gateway served under gatewayPrefix, nil gateway is not served.
+func NewHTTPApi(
+	cnf config.HTTPApiConfig,
+	app server.Application,
+	authenticator auth.Authenticator,
+	gateway http.Handler,
+) *API {
+	stopping := make(chan struct{})
+	service := Service{app: app, stopping: stopping}
+	v1 := Service{app: app, structuredErrors: true}
+	root := mux.NewRouter()
+	// streams are not limited by write timeout, so it's applied to the rest of requests by timeoutMiddleware
+	root.HandleFunc("/calendar/stream", service.StreamEventsHandler).Methods("GET")
+	if gateway != nil {
+		root.Handle(gatewayWatchPath, gateway).Methods("GET")
+	}
+	// bulk requests have their own time limit, so they are routed before the rest of requests
+	bulk := root.NewRoute().Subrouter()
+	bulk.Use(bulkTimeoutMiddleware)
+	if gateway != nil {
+		bulk.Handle(gatewayImportPath, gateway).Methods("POST")
+		bulk.Handle(gatewayBatchPath, gateway).Methods("POST")
+	}
+	bulk.HandleFunc("/calendar/import", service.ImportEventsHandler).Methods("POST")
+	bulk.HandleFunc("/calendar/batch", service.BatchEventsHandler).Methods("POST")
+	router := root.NewRoute().Subrouter()
+	router.Use(timeoutMiddleware)
+	router.HandleFunc("/openapi.json", OpenAPIHandler).Methods("GET")
+	if gateway != nil {
+		router.PathPrefix(gatewayPrefix).Handler(gateway)
+	}
+	router.HandleFunc(eventsResource, v1.CreateEventHandler).Methods("POST")
+	router.HandleFunc(eventsResource, v1.ListEventsHandler).Methods("GET")
+	router.HandleFunc(eventsResource+"/{eventId}", v1.GetEventHandler).Methods("GET")
+	router.HandleFunc(eventsResource+"/{eventId}", v1.ReplaceEventHandler).Methods("PUT")
+	router.HandleFunc(eventsResource+"/{eventId}", v1.PatchEventHandler).Methods("PATCH")
+	router.HandleFunc(eventsResource+"/{eventId}", v1.RemoveEventHandler).Methods("DELETE")
+	router.HandleFunc(eventsResource+"/{eventId}/attendees", v1.ListAttendeesHandler).Methods("GET")
+	router.HandleFunc(eventsResource+"/{eventId}/attendees/{userId}", v1.InviteAttendeeHandler).Methods("PUT")
+	router.HandleFunc(eventsResource+"/{eventId}/attendees/{userId}", v1.RemoveAttendeeHandler).Methods("DELETE")
+	router.HandleFunc(eventsResource+"/{eventId}/rsvp", v1.RespondToInvitationHandler).Methods("PUT")
+	router.HandleFunc(calendarsResource, v1.CreateCalendarHandler).Methods("POST")
+	router.HandleFunc(calendarsResource, v1.ListCalendarsHandler).Methods("GET")
+	router.HandleFunc(calendarsResource+"/{calendarId}", v1.GetCalendarHandler).Methods("GET")
+	router.HandleFunc(calendarsResource+"/{calendarId}", v1.ReplaceCalendarHandler).Methods("PUT")
+	router.HandleFunc(calendarsResource+"/{calendarId}", v1.RemoveCalendarHandler).Methods("DELETE")
+	router.HandleFunc(settingsResource, v1.GetUserSettingsHandler).Methods("GET")
+	router.HandleFunc(settingsResource, v1.ReplaceUserSettingsHandler).Methods("PUT")
+	router.HandleFunc(freeBusyResource, v1.FreeBusyHandler).Methods("GET")
+	router.HandleFunc(freeBusyResource+"/slot", v1.FindFreeSlotHandler).Methods("GET")
+	// deprecated aliases of /v1/events routes
+	router.Handle("/calendar/add", deprecated(service.AddEventHandler, eventsResource)).Methods("POST")
+	router.Handle("/calendar/update", deprecated(service.UpdateEventHandler, eventsResource)).Methods("POST")
+	router.Handle("/calendar/delete/{eventId}", deprecated(service.DeleteEventHandler, eventsResource)).Methods("POST")
 	router.HandleFunc(
 		"/calendar/find/{period:[a-zA-Z]+}/{year:[0-9]{4}}/{month:[0-9]{2}}/{day:[0-9]{2}}",
 		service.FindEventsHandler,
 	).Methods("GET")
+	router.HandleFunc("/calendar/events", service.ListEventsHandler).Methods("GET")
+	router.HandleFunc("/calendar/events/{eventId}", service.PatchEventHandler).Methods("PATCH")
+	router.HandleFunc("/calendar/search", service.SearchEventsHandler).Methods("GET")
+	router.HandleFunc("/calendar/export", service.ExportEventsHandler).Methods("GET")
 
 	srv := &http.Server{
-		Handler:      loggingMiddleware(router),
-		Addr:         net.JoinHostPort("localhost", strconv.Itoa(cnf.Port)),
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
+		Handler:     loggingMiddleware(authMiddleware(authenticator)(userMiddleware(root))),
+		Addr:        net.JoinHostPort("localhost", strconv.Itoa(cnf.Port)),
+		ReadTimeout: 5 * time.Second,
 	}
+	// Shutdown doesn't cancel active requests, so streams are finished by the service
+	srv.RegisterOnShutdown(func() {
+		close(stopping)
+	})
 	return &API{srv}
 }
 
+// OpenAPIHandler - responds with OpenAPI document of the gRPC gateway routes.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(api.OpenAPI); err != nil {
+		zap.L().Error("error during sending OpenAPI document", zap.Error(err))
+	}
+}
+
 // Start function is starting http api server on the given port.
 // This function is blocking so it must be called in separate goroutine.
 // If server start fails, CancelFunc will be called.
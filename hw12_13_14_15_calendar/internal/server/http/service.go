@@ -1,22 +1,46 @@
 package internalhttp
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
 	"github.com/gorilla/mux"
+	"go.uber.org/zap"
 )
 
-var ErrUnsupportedMediaType = errors.New("found unsupported media type, application/json expected")
+var (
+	ErrUnsupportedMediaType = errors.New("found unsupported media type, application/json expected")
+	ErrCalendarFileRequired = errors.New("calendar file is required")
+	ErrPreconditionRequired = errors.New("If-Match header with event ETag is required")
+	ErrInvalidETag          = errors.New("ETag is not an event version")
+)
+
+const (
+	// maxCalendarSize - max size of the imported calendar in bytes
+	maxCalendarSize = 10 << 20
+	// calendarFormField - multipart form field with uploaded calendar file
+	calendarFormField = "file"
+)
 
 type Service struct {
 	app server.Application
+	// structuredErrors - respond with ErrorResponse JSON bodies instead of plain text errors
+	structuredErrors bool
+	// stopping - closed when the server shuts down, so streams which end only on client disconnect are finished,
+	// nil channel means streams are finished by the client only
+	stopping chan struct{}
 }
 
 type CreateEventData struct {
@@ -24,102 +48,315 @@ type CreateEventData struct {
 	StartTime   time.Time `json:"start_time"`
 	EndTime     time.Time `json:"end_time"`
 	Description string    `json:"description"`
-	OwnerID     string    `json:"owner_id"`
+	// NotifyBefore - optional, nanoseconds as in storage.Event
+	NotifyBefore time.Duration `json:"notify_before"`
+	// RecurrenceRule - optional RRULE, see storage.Event
+	RecurrenceRule string      `faker:"-" json:"recurrence_rule"`
+	ExceptionDates []time.Time `faker:"-" json:"exception_dates"`
+	// CalendarID - optional ID of the user calendar
+	CalendarID string `faker:"-" json:"calendar_id"`
+	// TimeZone - optional IANA time zone of recurring event series, the request time zone by default
+	TimeZone string `faker:"-" json:"time_zone"`
+}
+
+func (d CreateEventData) toEvent() storage.Event {
+	return storage.Event{
+		Title:          d.Title,
+		StartTime:      d.StartTime,
+		EndTime:        d.EndTime,
+		Description:    d.Description,
+		NotifyBefore:   d.NotifyBefore,
+		RecurrenceRule: d.RecurrenceRule,
+		ExceptionDates: d.ExceptionDates,
+		CalendarID:     d.CalendarID,
+		TimeZone:       d.TimeZone,
+	}
 }
 
 func (s Service) AddEventHandler(w http.ResponseWriter, r *http.Request) {
 	eventData := new(CreateEventData)
 	if err := receiveJSON(r, eventData); err != nil {
 		if errors.Is(err, ErrUnsupportedMediaType) {
-			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			s.fail(w, err, http.StatusUnsupportedMediaType)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.fail(w, err, http.StatusBadRequest)
 		return
 	}
 
-	event, err := s.app.CreateEvent(
-		r.Context(),
-		eventData.Title,
-		eventData.StartTime,
-		eventData.EndTime,
-		eventData.Description,
-		eventData.OwnerID,
-	)
+	event, err := s.app.CreateEvent(r.Context(), eventData.toEvent())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.fail(w, err, errorStatus(err))
 		return
 	}
+	w.Header().Set("ETag", eventETag(event))
 
 	if err := sendJSON(w, &event); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.fail(w, err, http.StatusInternalServerError)
 	}
 }
 
+// UpdateEventHandler - updates event if its current version is the one of If-Match header ETag,
+// the version sent in the body is used if there is no If-Match header. Responds with the updated event and its new ETag.
 func (s Service) UpdateEventHandler(w http.ResponseWriter, r *http.Request) {
 	event := new(storage.Event)
 	if err := receiveJSON(r, event); err != nil {
 		if errors.Is(err, ErrUnsupportedMediaType) {
-			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			s.fail(w, err, http.StatusUnsupportedMediaType)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.fail(w, err, http.StatusBadRequest)
 		return
 	}
-	err := s.app.UpdateEvent(r.Context(), *event)
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		version, err := parseETag(ifMatch)
+		if err != nil {
+			s.fail(w, fmt.Errorf("not valid If-Match header: %w", err), http.StatusBadRequest)
+			return
+		}
+		event.Version = version
+	}
+	updated, err := s.app.UpdateEvent(r.Context(), *event)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.fail(w, err, errorStatus(err))
 		return
 	}
-	if err := sendJSON(w, event); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	w.Header().Set("ETag", eventETag(updated))
+	if err := sendJSON(w, &updated); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
 	}
 }
 
 func (s Service) DeleteEventHandler(w http.ResponseWriter, r *http.Request) {
 	eventID, ok := mux.Vars(r)["eventId"]
 	if !ok || eventID == "" {
-		http.Error(w, "eventID route param is required", http.StatusBadRequest)
+		s.fail(w, errors.New("eventID route param is required"), http.StatusBadRequest)
 		return
 	}
 
 	err := s.app.DeleteEvent(r.Context(), eventID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.fail(w, err, errorStatus(err))
 		return
 	}
 }
 
+// FindEventsHandler - finds user events of the day, week or month containing the requested date,
+// the date and period boundaries are in the user time zone (tz param or X-Time-Zone header).
+// Repeated calendar param limits events to the user calendars with the IDs.
 func (s Service) FindEventsHandler(w http.ResponseWriter, r *http.Request) {
 	routeParams := mux.Vars(r)
+	calendarIDs := r.URL.Query()["calendar"]
 
 	year, _ := strconv.Atoi(routeParams["year"])
 	month, _ := strconv.Atoi(routeParams["month"])
 	day, _ := strconv.Atoi(routeParams["day"])
-	requestedDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	// requested date is a calendar date of the user, so it is built in the user time zone
+	loc, err := s.app.Location(r.Context(), calendarIDs...)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	requestedDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
 
 	var events []storage.Event
-	var err error
 	switch periodStr := routeParams["period"]; periodStr {
 	case "day":
-		events, err = s.app.ListDayEvents(r.Context(), requestedDate)
+		events, err = s.app.ListDayEvents(r.Context(), requestedDate, calendarIDs...)
 	case "week":
-		events, err = s.app.ListWeekEvents(r.Context(), requestedDate)
+		events, err = s.app.ListWeekEvents(r.Context(), requestedDate, calendarIDs...)
 	case "month":
-		events, err = s.app.ListMonthEvents(r.Context(), requestedDate)
+		events, err = s.app.ListMonthEvents(r.Context(), requestedDate, calendarIDs...)
 	default:
-		http.Error(w, "not valid period param", http.StatusBadRequest)
+		s.fail(w, errors.New("not valid period param"), http.StatusBadRequest)
 		return
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.fail(w, err, errorStatus(err))
 		return
 	}
 	if err := sendJSON(w, events); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.fail(w, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListEventsHandler - lists user events overlapping [from, to) range ordered by start time page by page,
+// range bounds are passed in RFC 3339 format, text, page_token, limit and tz params are optional.
+func (s Service) ListEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		s.fail(w, fmt.Errorf("not valid from param: %w", err), http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil {
+		s.fail(w, fmt.Errorf("not valid to param: %w", err), http.StatusBadRequest)
+		return
+	}
+	var limit int
+	if limitParam := query.Get("limit"); limitParam != "" {
+		if limit, err = strconv.Atoi(limitParam); err != nil {
+			s.fail(w, fmt.Errorf("not valid limit param: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	filter := app.EventFilter{Text: query.Get("text")}
+	page, err := s.app.ListEvents(r.Context(), from, to, filter, query.Get("page_token"), limit)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
 		return
 	}
+	if err := sendJSON(w, page); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+type SearchEventsResponse struct {
+	Hits []storage.SearchHit `json:"hits"`
+}
+
+// SearchEventsHandler - full text search over user events by q param, the most relevant events go first,
+// from and to range bounds in RFC 3339 format, limit and tz params are optional.
+func (s Service) SearchEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var from, to time.Time
+	var err error
+	if fromParam := query.Get("from"); fromParam != "" {
+		if from, err = time.Parse(time.RFC3339, fromParam); err != nil {
+			s.fail(w, fmt.Errorf("not valid from param: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if toParam := query.Get("to"); toParam != "" {
+		if to, err = time.Parse(time.RFC3339, toParam); err != nil {
+			s.fail(w, fmt.Errorf("not valid to param: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+	var limit int
+	if limitParam := query.Get("limit"); limitParam != "" {
+		if limit, err = strconv.Atoi(limitParam); err != nil {
+			s.fail(w, fmt.Errorf("not valid limit param: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	hits, err := s.app.SearchEvents(r.Context(), query.Get("q"), from, to, limit)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, SearchEventsResponse{Hits: hits}); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// ExportEventsHandler - exports user events overlapping [from, to] range as .ics file,
+// range bounds are passed in RFC 3339 format.
+func (s Service) ExportEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		s.fail(w, fmt.Errorf("not valid from param: %w", err), http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil {
+		s.fail(w, fmt.Errorf("not valid to param: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// calendar is buffered, so error status could be sent if export fails
+	calendar := new(bytes.Buffer)
+	if err := s.app.ExportEvents(r.Context(), from, to, calendar); err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", ical.ContentType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	if _, err := calendar.WriteTo(w); err != nil {
+		zap.L().Error("error during calendar sending", zap.Error(err))
+	}
+}
+
+// ImportEventsHandler - imports .ics file either sent as request body
+// or uploaded as multipart form file, responds with per event import report.
+func (s Service) ImportEventsHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCalendarSize)
+	calendar, err := receiveCalendar(r)
+	if err != nil {
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	defer calendar.Close()
+
+	report, err := s.app.ImportEvents(r.Context(), calendar)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, report); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+func receiveCalendar(r *http.Request) (io.ReadCloser, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile(calendarFormField)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			return nil, ErrCalendarFileRequired
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// errorStatus - maps application error onto http response status.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, app.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case app.IsValidationError(err), errors.Is(err, ical.ErrMalformedCalendar):
+		return http.StatusBadRequest
+	case errors.Is(err, app.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrEventNotFound), errors.Is(err, storage.ErrAttendeeNotFound),
+		errors.Is(err, storage.ErrCalendarNotFound), errors.Is(err, app.ErrNoFreeSlot):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrEventAlreadyExists), errors.Is(err, storage.ErrDateBusy),
+		errors.Is(err, storage.ErrCalendarNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// eventETag - strong ETag of the event version.
+func eventETag(event storage.Event) string {
+	return strconv.Quote(strconv.FormatInt(event.Version, 10))
+}
+
+// parseETag - returns event version of the ETag, weak ETags are accepted as well.
+func parseETag(etag string) (int64, error) {
+	etag = strings.TrimPrefix(etag, "W/")
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, ErrInvalidETag
+	}
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidETag
+	}
+	return version, nil
 }
 
 // receiveJSON reads JSON request into v.
@@ -1,15 +1,22 @@
 package internalhttp
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/auth"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/config"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/server"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
@@ -125,6 +132,11 @@ func (s *MarshallingSuite) TestReceiveJSONHelperWithEventSlice() {
 	s.Require().True(IsEqual(s.testData, resData))
 }
 
+const (
+	testUserID = "f1a200f5-3f8e-4c28-b287-82376033eaae"
+	testAPIKey = "test-api-key"
+)
+
 func TestHTTPApi(t *testing.T) {
 	suite.Run(t, new(HTTPApiSuite))
 }
@@ -174,7 +186,7 @@ func (s *HTTPApiSuite) SetupSuite() {
 	// for router tests purposes creating httptest.Server
 	api := NewHTTPApi(config.HTTPApiConfig{
 		Port: 8888,
-	}, s.mockedApp)
+	}, s.mockedApp, auth.Chain{auth.APIKeys{testAPIKey: testUserID}, auth.TrustedUserID{}}, nil)
 	s.testServer = httptest.NewServer(api.server.Handler)
 }
 
@@ -192,18 +204,15 @@ func (s *HTTPApiSuite) TestAddEventHandler() {
 
 	s.mockedApp.EXPECT().CreateEvent(
 		gomock.Any(),
-		s.testCreateData.Title,
-		gomock.Any(),
-		gomock.Any(),
-		s.testCreateData.Description,
-		s.testCreateData.OwnerID,
+		eventsMatcher{s.testCreateData.toEvent()},
 	).Return(storage.Event{
-		ID:          faker.UUIDHyphenated(),
-		Title:       s.testCreateData.Title,
-		StartTime:   s.testCreateData.StartTime,
-		EndTime:     s.testCreateData.EndTime,
-		Description: s.testCreateData.Description,
-		OwnerID:     s.testCreateData.OwnerID,
+		ID:           faker.UUIDHyphenated(),
+		Title:        s.testCreateData.Title,
+		StartTime:    s.testCreateData.StartTime,
+		EndTime:      s.testCreateData.EndTime,
+		Description:  s.testCreateData.Description,
+		OwnerID:      testUserID,
+		NotifyBefore: s.testCreateData.NotifyBefore,
 	}, nil)
 	service := &Service{app: s.mockedApp}
 	service.AddEventHandler(w, r)
@@ -223,7 +232,8 @@ func (s *HTTPApiSuite) TestAddEventHandler() {
 	s.Require().True(s.testCreateData.StartTime.Equal(resEvent.StartTime))
 	s.Require().True(s.testCreateData.EndTime.Equal(resEvent.EndTime))
 	s.Require().Equal(s.testCreateData.Description, resEvent.Description)
-	s.Require().Equal(s.testCreateData.OwnerID, resEvent.OwnerID)
+	s.Require().Equal(testUserID, resEvent.OwnerID)
+	s.Require().Equal(s.testCreateData.NotifyBefore, resEvent.NotifyBefore)
 }
 
 func (s *HTTPApiSuite) TestUpdateEventHandler() {
@@ -231,12 +241,15 @@ func (s *HTTPApiSuite) TestUpdateEventHandler() {
 	s.Require().NoError(err)
 	r := httptest.NewRequest("POST", "localhost:8080/calendar/update", bytes.NewBuffer(marshal))
 	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"3"`)
 	w := httptest.NewRecorder()
 
+	updated := s.testEvent
+	updated.Version = 4
 	s.mockedApp.EXPECT().UpdateEvent(
 		gomock.Any(),
 		eventsMatcher{s.testEvent},
-	).Return(nil)
+	).Return(updated, nil)
 	service := &Service{app: s.mockedApp}
 	service.UpdateEventHandler(w, r)
 
@@ -245,6 +258,7 @@ func (s *HTTPApiSuite) TestUpdateEventHandler() {
 
 	s.Require().Equal(http.StatusOK, resp.StatusCode)
 	s.Require().Equal("application/json", resp.Header.Get("Content-Type"))
+	s.Require().Equal(`"4"`, resp.Header.Get("ETag"))
 
 	var resEvent storage.Event
 	decoder := json.NewDecoder(resp.Body)
@@ -271,18 +285,15 @@ func (s *HTTPApiSuite) TestAddEvent() {
 
 	s.mockedApp.EXPECT().CreateEvent(
 		gomock.Any(),
-		s.testCreateData.Title,
-		gomock.Any(),
-		gomock.Any(),
-		s.testCreateData.Description,
-		s.testCreateData.OwnerID,
+		eventsMatcher{s.testCreateData.toEvent()},
 	).Return(storage.Event{
-		ID:          faker.UUIDHyphenated(),
-		Title:       s.testCreateData.Title,
-		StartTime:   s.testCreateData.StartTime,
-		EndTime:     s.testCreateData.EndTime,
-		Description: s.testCreateData.Description,
-		OwnerID:     s.testCreateData.OwnerID,
+		ID:           faker.UUIDHyphenated(),
+		Title:        s.testCreateData.Title,
+		StartTime:    s.testCreateData.StartTime,
+		EndTime:      s.testCreateData.EndTime,
+		Description:  s.testCreateData.Description,
+		OwnerID:      testUserID,
+		NotifyBefore: s.testCreateData.NotifyBefore,
 	}, nil)
 
 	client := http.Client{
@@ -307,7 +318,54 @@ func (s *HTTPApiSuite) TestAddEvent() {
 	s.Require().True(s.testCreateData.StartTime.Equal(resEvent.StartTime))
 	s.Require().True(s.testCreateData.EndTime.Equal(resEvent.EndTime))
 	s.Require().Equal(s.testCreateData.Description, resEvent.Description)
-	s.Require().Equal(s.testCreateData.OwnerID, resEvent.OwnerID)
+	s.Require().Equal(testUserID, resEvent.OwnerID)
+	s.Require().Equal(s.testCreateData.NotifyBefore, resEvent.NotifyBefore)
+}
+
+func (s *HTTPApiSuite) TestAddBusyEvent() {
+	marshal, err := json.Marshal(s.testCreateData)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/add", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	s.mockedApp.EXPECT().CreateEvent(
+		gomock.Any(),
+		eventsMatcher{s.testCreateData.toEvent()},
+	).Return(storage.Event{}, fmt.Errorf("error during creating event: %w", storage.ErrDateBusy))
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusConflict, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestAddInvalidEvent() {
+	marshal, err := json.Marshal(s.testCreateData)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/add", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	s.mockedApp.EXPECT().CreateEvent(
+		gomock.Any(),
+		eventsMatcher{s.testCreateData.toEvent()},
+	).Return(storage.Event{}, &app.ValidationError{Field: "title", Err: app.ErrEmptyTitle})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
 }
 
 func (s *HTTPApiSuite) TestUpdateEvent() {
@@ -316,11 +374,12 @@ func (s *HTTPApiSuite) TestUpdateEvent() {
 	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/update", bytes.NewBuffer(marshal))
 	s.Require().NoError(err)
 	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `W/"3"`)
 
 	s.mockedApp.EXPECT().UpdateEvent(
 		gomock.Any(),
-		eventsMatcher{s.testEvent},
-	).Return(nil)
+		versionMatcher{3},
+	).Return(s.testEvent, nil)
 
 	client := http.Client{
 		Timeout: 2 * time.Second,
@@ -342,12 +401,109 @@ func (s *HTTPApiSuite) TestUpdateEvent() {
 	s.Require().True(s.testEvent.IsEqual(resEvent))
 }
 
+func (s *HTTPApiSuite) TestUpdateEventWithoutIfMatch() {
+	event := s.testEvent
+	event.Version = 5
+	marshal, err := json.Marshal(event)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/update", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	// legacy clients send the version in the body
+	s.mockedApp.EXPECT().UpdateEvent(gomock.Any(), versionMatcher{5}).Return(event, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestUpdateStaleEvent() {
+	marshal, err := json.Marshal(s.testEvent)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/update", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"1"`)
+
+	s.mockedApp.EXPECT().UpdateEvent(gomock.Any(), versionMatcher{1}).Return(storage.Event{}, storage.ErrVersionConflict)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestPatchEvent() {
+	r, err := http.NewRequestWithContext(s.ctx, "PATCH", s.testServer.URL+"/calendar/events/TEST_EVENT_ID",
+		strings.NewReader(`{"title": "updated", "description": null}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", MergePatchContentType)
+	r.Header.Set(UserIDHeader, testUserID)
+	r.Header.Set("If-Match", `"2"`)
+
+	updated := s.testEvent
+	updated.Title, updated.Description, updated.Version = "updated", "", 3
+	s.mockedApp.EXPECT().PatchEvent(userMatcher{testUserID}, "TEST_EVENT_ID", gomock.Any(), int64(2)).DoAndReturn(
+		func(ctx context.Context, eventID string, patch app.EventPatch, version int64) (storage.Event, error) {
+			s.Require().Equal("updated", *patch.Title)
+			s.Require().Equal("", *patch.Description)
+			s.Require().Nil(patch.StartTime)
+			return updated, nil
+		})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal(`"3"`, resp.Header.Get("ETag"))
+
+	var resEvent storage.Event
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resEvent))
+	s.Require().True(updated.IsEqual(resEvent))
+}
+
+func (s *HTTPApiSuite) TestPatchEventWithInvalidPatch() {
+	for _, patch := range []string{`{"title": null}`, `{"owner_id": "another owner"}`, `{"start_time": "tomorrow"}`} {
+		r, err := http.NewRequestWithContext(s.ctx, "PATCH", s.testServer.URL+"/calendar/events/TEST_EVENT_ID",
+			strings.NewReader(patch))
+		s.Require().NoError(err)
+		r.Header.Set("Content-Type", MergePatchContentType)
+		r.Header.Set(UserIDHeader, testUserID)
+
+		client := http.Client{
+			Timeout: 2 * time.Second,
+		}
+		resp, err := client.Do(r)
+		s.Require().NoError(err)
+		s.Require().NoError(resp.Body.Close())
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode, patch)
+	}
+}
+
 func (s *HTTPApiSuite) TestDeleteEvent() {
 	request, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/delete/TEST_EVENT_ID", nil)
 	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
 
 	s.mockedApp.EXPECT().DeleteEvent(
-		gomock.Any(),
+		userMatcher{testUserID},
 		gomock.Eq("TEST_EVENT_ID"),
 	).Return(nil)
 
@@ -360,15 +516,43 @@ func (s *HTTPApiSuite) TestDeleteEvent() {
 		s.Require().NoError(resp.Body.Close())
 	}()
 	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("true", resp.Header.Get("Deprecation"))
+	s.Require().Equal(`</v1/events>; rel="successor-version"`, resp.Header.Get("Link"))
 }
 
-func (s *HTTPApiSuite) TestFindEvents() {
+func (s *HTTPApiSuite) TestFindEventsWithoutUser() {
 	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/calendar/find/day/2021/08/25", nil)
 	s.Require().NoError(err)
 
+	s.mockedApp.EXPECT().Location(gomock.Any()).Return(time.UTC, nil)
 	s.mockedApp.EXPECT().ListDayEvents(
 		gomock.Any(),
 		gomock.Any(),
+	).Return(nil, app.ErrUnauthenticated)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestFindEvents() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/calendar/find/day/2021/08/25?tz=Asia/Tokyo", nil)
+	s.Require().NoError(err)
+	request.Header.Set(TimeZoneHeader, "Europe/Moscow")
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	s.Require().NoError(err)
+	// tz param overrides user default time zone
+	s.mockedApp.EXPECT().Location(timeZoneMatcher{"Asia/Tokyo"}).Return(tokyo, nil)
+	s.mockedApp.EXPECT().ListDayEvents(
+		timeZoneMatcher{"Asia/Tokyo"},
+		time.Date(2021, time.August, 25, 0, 0, 0, 0, tokyo),
 	).Return(s.testSlice, nil)
 
 	client := http.Client{
@@ -388,23 +572,1013 @@ func (s *HTTPApiSuite) TestFindEvents() {
 	s.Require().True(IsEqual(s.testSlice, result))
 }
 
-func IsEqual(s1 []storage.Event, s2 []storage.Event) bool {
-	if s1 == nil && s2 == nil {
-		return true
+func (s *HTTPApiSuite) TestFindEventsWithUnknownTimeZone() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/calendar/find/day/2021/08/25", nil)
+	s.Require().NoError(err)
+	request.Header.Set(TimeZoneHeader, "Mars/Olympus")
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
 	}
-	if s1 == nil || s2 == nil {
-		return false
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestFindEventsWithoutTimeZone() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/calendar/find/day/2021/08/25", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().Location(gomock.Any()).Return(nil, &app.ValidationError{Field: "time_zone", Err: app.ErrTimeZoneRequired})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
 	}
-	if len(s1) != len(s2) {
-		return false
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestListEvents() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/calendar/events?from=2021-08-01T00:00:00Z&to=2021-09-01T00:00:00Z&text=meet&page_token=token&limit=10", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().ListEvents(
+		userMatcher{testUserID},
+		time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, time.September, 1, 0, 0, 0, 0, time.UTC),
+		app.EventFilter{Text: "meet"},
+		"token",
+		10,
+	).Return(app.EventsPage{Events: s.testSlice, NextPageToken: "next token"}, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var page app.EventsPage
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&page))
+	s.Require().True(IsEqual(s.testSlice, page.Events))
+	s.Require().Equal("next token", page.NextPageToken)
+}
+
+func (s *HTTPApiSuite) TestListEventsWithInvalidLimit() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/calendar/events?from=2021-08-01T00:00:00Z&to=2021-09-01T00:00:00Z&limit=ten", nil)
+	s.Require().NoError(err)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
 	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
 
-	for i, e := range s1 {
-		if !e.IsEqual(s2[i]) {
-			return false
+func (s *HTTPApiSuite) TestSearchEvents() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/calendar/search?q=code+review&from=2021-08-01T00:00:00Z&limit=10", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	hits := []storage.SearchHit{{Event: s.testSlice[0], Rank: 1.4, Snippet: "<b>code</b> <b>review</b>"}}
+	s.mockedApp.EXPECT().SearchEvents(
+		userMatcher{testUserID},
+		"code review",
+		time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC),
+		time.Time{},
+		10,
+	).Return(hits, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var result SearchEventsResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+	s.Require().Len(result.Hits, 1)
+	s.Require().True(IsEqual(s.testSlice[:1], []storage.Event{result.Hits[0].Event}))
+	s.Require().Equal(hits[0].Snippet, result.Hits[0].Snippet)
+}
+
+func (s *HTTPApiSuite) TestSearchEventsWithEmptyQuery() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/calendar/search", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().SearchEvents(userMatcher{testUserID}, "", time.Time{}, time.Time{}, 0).
+		Return(nil, &app.ValidationError{Field: "query", Err: app.ErrEmptyQuery})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestStreamEvents() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, "GET", s.testServer.URL+"/calendar/stream?from=2021-08-01T00:00:00Z", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+	request.Header.Set(LastEventIDHeader, "instance-1")
+
+	hub := app.NewChangeHub()
+	subscription, err := hub.Subscribe(testUserID, "", nil)
+	s.Require().NoError(err)
+	s.mockedApp.EXPECT().WatchEvents(
+		userMatcher{testUserID},
+		time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC),
+		time.Time{},
+		"instance-1",
+	).Return(subscription, nil)
+
+	// stream lives longer than request timeout, so client timeout is not set
+	resp, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	event := s.testEvent
+	event.OwnerID = testUserID
+	s.Require().NoError(hub.PublishChange(ctx, app.EventChange{Type: app.ChangeCreated, Event: event}))
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		s.Require().NoError(err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
 		}
+		lines = append(lines, line)
 	}
-	return true
+	s.Require().Len(lines, 3)
+	s.Require().True(strings.HasPrefix(lines[0], "id: "))
+	s.Require().Equal("event: created", lines[1])
+	var change app.EventChange
+	s.Require().NoError(json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &change))
+	s.Require().Equal(strings.TrimPrefix(lines[0], "id: "), change.ID)
+	s.Require().True(event.IsEqual(change.Event))
+}
+
+func (s *HTTPApiSuite) TestStreamEventsShutdown() {
+	api := NewHTTPApi(config.HTTPApiConfig{}, s.mockedApp, auth.TrustedUserID{}, nil)
+	lsn, err := net.Listen("tcp", "localhost:0")
+	s.Require().NoError(err)
+	go func() {
+		if err := api.server.Serve(lsn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.T().Log("error during http test server serving: ", err)
+		}
+	}()
+
+	request, err := http.NewRequestWithContext(s.ctx, "GET", "http://"+lsn.Addr().String()+"/calendar/stream", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+	subscription, err := app.NewChangeHub().Subscribe(testUserID, "", nil)
+	s.Require().NoError(err)
+	s.mockedApp.EXPECT().WatchEvents(userMatcher{testUserID}, time.Time{}, time.Time{}, "").Return(subscription, nil)
+
+	resp, err := http.DefaultClient.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	// the client never disconnects, so the stream must be finished by the server
+	ctx, cancel := context.WithTimeout(s.ctx, 2*time.Second)
+	defer cancel()
+	s.Require().NoError(api.Stop(ctx))
+	_, err = io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+}
+
+func (s *HTTPApiSuite) TestStreamEventsWithInvalidLastEventID() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/calendar/stream?last_event_id=broken", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().WatchEvents(userMatcher{testUserID}, time.Time{}, time.Time{}, "broken").
+		Return(nil, &app.ValidationError{Field: "after_change_id", Err: app.ErrInvalidChangeID})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestExportEvents() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/calendar/export?from=2021-08-01T00:00:00Z&to=2021-09-01T00:00:00Z", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().ExportEvents(
+		userMatcher{testUserID},
+		time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, time.September, 1, 0, 0, 0, 0, time.UTC),
+		gomock.Any(),
+	).DoAndReturn(func(ctx context.Context, from, to time.Time, w io.Writer) error {
+		_, err := io.WriteString(w, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+		return err
+	})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	s.Require().Equal("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", string(body))
+}
+
+func (s *HTTPApiSuite) TestExportEventsWithInvalidRange() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/calendar/export?from=yesterday", nil)
+	s.Require().NoError(err)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestOpenAPI() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/openapi.json", nil)
+	s.Require().NoError(err)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("application/json", resp.Header.Get("Content-Type"))
+	var document struct {
+		Swagger string                     `json:"swagger"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&document))
+	s.Require().Equal("2.0", document.Swagger)
+	s.Require().Contains(document.Paths, "/api/v1/events")
+}
+
+func (s *HTTPApiSuite) TestCreateEventResource() {
+	marshal, err := json.Marshal(s.testCreateData)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/v1/events", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	created := s.testCreateData.toEvent()
+	created.ID = "TEST_EVENT_ID"
+	created.OwnerID = testUserID
+	created.Version = 1
+	s.mockedApp.EXPECT().CreateEvent(gomock.Any(), eventsMatcher{s.testCreateData.toEvent()}).Return(created, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Require().Equal("application/json", resp.Header.Get("Content-Type"))
+	s.Require().Equal("/v1/events/TEST_EVENT_ID", resp.Header.Get("Location"))
+	s.Require().Equal(`"1"`, resp.Header.Get("ETag"))
+
+	var resEvent storage.Event
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resEvent))
+	s.Require().True(created.IsEqual(resEvent))
+}
+
+func (s *HTTPApiSuite) TestCreateDuplicateEventResource() {
+	marshal, err := json.Marshal(s.testCreateData)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/v1/events", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	s.mockedApp.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).
+		Return(storage.Event{}, fmt.Errorf("error during creating event: %w", storage.ErrEventAlreadyExists))
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("already_exists", errResp.Error.Code)
+}
+
+func (s *HTTPApiSuite) TestCreateInvalidEventResource() {
+	marshal, err := json.Marshal(s.testCreateData)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/v1/events", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	s.mockedApp.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).
+		Return(storage.Event{}, &app.ValidationError{Field: "title", Err: app.ErrEmptyTitle})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	s.Require().Equal("application/json", resp.Header.Get("Content-Type"))
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("invalid_argument", errResp.Error.Code)
+	s.Require().Equal(map[string]string{"field": "title"}, errResp.Error.Details)
+}
+
+func (s *HTTPApiSuite) TestGetEventResource() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/v1/events/TEST_EVENT_ID", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	event := s.testEvent
+	event.Version = 5
+	s.mockedApp.EXPECT().GetEvent(userMatcher{testUserID}, gomock.Eq("TEST_EVENT_ID")).Return(event, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal(`"5"`, resp.Header.Get("ETag"))
+	var resEvent storage.Event
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resEvent))
+	s.Require().True(event.IsEqual(resEvent))
+}
+
+func (s *HTTPApiSuite) TestGetMissingEventResource() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/v1/events/TEST_EVENT_ID", nil)
+	s.Require().NoError(err)
+
+	s.mockedApp.EXPECT().GetEvent(gomock.Any(), gomock.Eq("TEST_EVENT_ID")).Return(storage.Event{}, storage.ErrEventNotFound)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("not_found", errResp.Error.Code)
+	s.Require().Equal(storage.ErrEventNotFound.Error(), errResp.Error.Message)
+}
+
+func (s *HTTPApiSuite) TestReplaceEventResource() {
+	marshal, err := json.Marshal(s.testCreateData)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "PUT", s.testServer.URL+"/v1/events/TEST_EVENT_ID", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"2"`)
+
+	replaced := s.testCreateData.toEvent()
+	replaced.ID = "TEST_EVENT_ID"
+	s.mockedApp.EXPECT().UpdateEvent(gomock.Any(), gomock.All(eventsMatcher{replaced}, versionMatcher{2})).
+		DoAndReturn(func(ctx context.Context, event storage.Event) (storage.Event, error) {
+			event.Version++
+			return event, nil
+		})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal(`"3"`, resp.Header.Get("ETag"))
+}
+
+func (s *HTTPApiSuite) TestReplaceEventResourceWithoutIfMatch() {
+	marshal, err := json.Marshal(s.testCreateData)
+	s.Require().NoError(err)
+	r, err := http.NewRequestWithContext(s.ctx, "PUT", s.testServer.URL+"/v1/events/TEST_EVENT_ID", bytes.NewBuffer(marshal))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusPreconditionRequired, resp.StatusCode)
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("precondition_required", errResp.Error.Code)
+}
+
+func (s *HTTPApiSuite) TestRemoveEventResource() {
+	request, err := http.NewRequestWithContext(s.ctx, "DELETE", s.testServer.URL+"/v1/events/TEST_EVENT_ID", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().DeleteEvent(userMatcher{testUserID}, gomock.Eq("TEST_EVENT_ID")).Return(nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestAPIKeyAuthentication() {
+	request, err := http.NewRequestWithContext(s.ctx, "DELETE", s.testServer.URL+"/v1/events/TEST_EVENT_ID", nil)
+	s.Require().NoError(err)
+	request.Header.Set(auth.APIKeyHeader, testAPIKey)
+
+	s.mockedApp.EXPECT().DeleteEvent(userMatcher{testUserID}, gomock.Eq("TEST_EVENT_ID")).Return(nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestInvalidAPIKey() {
+	request, err := http.NewRequestWithContext(s.ctx, "DELETE", s.testServer.URL+"/v1/events/TEST_EVENT_ID", nil)
+	s.Require().NoError(err)
+	request.Header.Set(auth.APIKeyHeader, "unknown-api-key")
+	// claimed user ID does not help when credentials are invalid
+	request.Header.Set(UserIDHeader, testUserID)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	s.Require().NotEmpty(resp.Header.Get("WWW-Authenticate"))
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("unauthenticated", errResp.Error.Code)
+}
+
+func (s *HTTPApiSuite) TestInviteAttendee() {
+	r, err := http.NewRequestWithContext(s.ctx, "PUT", s.testServer.URL+"/v1/events/TEST_EVENT_ID/attendees/TEST_ATTENDEE_ID",
+		strings.NewReader(`{"role": "editor"}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(UserIDHeader, testUserID)
+
+	attendee := storage.Attendee{
+		EventID: "TEST_EVENT_ID",
+		UserID:  "TEST_ATTENDEE_ID",
+		Role:    storage.AttendeeRoleEditor,
+		Status:  storage.RSVPNeedsAction,
+	}
+	s.mockedApp.EXPECT().
+		InviteAttendee(userMatcher{testUserID}, gomock.Eq("TEST_EVENT_ID"), gomock.Eq("TEST_ATTENDEE_ID"), gomock.Eq(storage.AttendeeRoleEditor)).
+		Return(attendee, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var resAttendee storage.Attendee
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resAttendee))
+	s.Require().Equal(attendee, resAttendee)
+}
+
+func (s *HTTPApiSuite) TestInviteAttendeeByNotOwner() {
+	r, err := http.NewRequestWithContext(s.ctx, "PUT", s.testServer.URL+"/v1/events/TEST_EVENT_ID/attendees/TEST_ATTENDEE_ID",
+		strings.NewReader(`{"role": "viewer"}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+
+	s.mockedApp.EXPECT().InviteAttendee(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(storage.Attendee{}, app.ErrForbidden)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("permission_denied", errResp.Error.Code)
+}
+
+func (s *HTTPApiSuite) TestListAttendees() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET", s.testServer.URL+"/v1/events/TEST_EVENT_ID/attendees", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	attendees := []storage.Attendee{
+		{EventID: "TEST_EVENT_ID", UserID: "first", Role: storage.AttendeeRoleViewer, Status: storage.RSVPAccepted},
+		{EventID: "TEST_EVENT_ID", UserID: "second", Role: storage.AttendeeRoleEditor, Status: storage.RSVPTentative},
+	}
+	s.mockedApp.EXPECT().ListAttendees(userMatcher{testUserID}, gomock.Eq("TEST_EVENT_ID")).Return(attendees, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var res ListAttendeesResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&res))
+	s.Require().Equal(attendees, res.Attendees)
+}
+
+func (s *HTTPApiSuite) TestRemoveAttendee() {
+	request, err := http.NewRequestWithContext(s.ctx, "DELETE", s.testServer.URL+"/v1/events/TEST_EVENT_ID/attendees/"+testUserID, nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().RemoveAttendee(userMatcher{testUserID}, gomock.Eq("TEST_EVENT_ID"), gomock.Eq(testUserID)).Return(nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestRespondToInvitation() {
+	r, err := http.NewRequestWithContext(s.ctx, "PUT", s.testServer.URL+"/v1/events/TEST_EVENT_ID/rsvp",
+		strings.NewReader(`{"status": "accepted"}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(UserIDHeader, testUserID)
+
+	attendee := storage.Attendee{
+		EventID: "TEST_EVENT_ID",
+		UserID:  testUserID,
+		Role:    storage.AttendeeRoleViewer,
+		Status:  storage.RSVPAccepted,
+	}
+	s.mockedApp.EXPECT().RespondToInvitation(userMatcher{testUserID}, gomock.Eq("TEST_EVENT_ID"), gomock.Eq(storage.RSVPAccepted)).
+		Return(attendee, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var resAttendee storage.Attendee
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resAttendee))
+	s.Require().Equal(attendee, resAttendee)
+}
+
+func (s *HTTPApiSuite) TestFindCalendarEvents() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/calendar/find/week/2021/08/25?calendar=WORK_ID&calendar=HOME_ID", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	s.Require().NoError(err)
+	// the requested date is in the common time zone of the calendars
+	s.mockedApp.EXPECT().Location(userMatcher{testUserID}, gomock.Eq("WORK_ID"), gomock.Eq("HOME_ID")).Return(tokyo, nil)
+	s.mockedApp.EXPECT().ListWeekEvents(
+		userMatcher{testUserID},
+		time.Date(2021, time.August, 25, 0, 0, 0, 0, tokyo),
+		gomock.Eq("WORK_ID"),
+		gomock.Eq("HOME_ID"),
+	).Return(s.testSlice, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestCreateCalendarResource() {
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/v1/calendars",
+		strings.NewReader(`{"name": "Work", "color": "#ff0000", "time_zone": "Europe/Berlin"}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(UserIDHeader, testUserID)
+
+	created := storage.Calendar{ID: "TEST_CALENDAR_ID", OwnerID: testUserID, Name: "Work", Color: "#ff0000", TimeZone: "Europe/Berlin"}
+	s.mockedApp.EXPECT().
+		CreateCalendar(userMatcher{testUserID}, gomock.Eq(storage.Calendar{Name: "Work", Color: "#ff0000", TimeZone: "Europe/Berlin"})).
+		Return(created, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Require().Equal("/v1/calendars/TEST_CALENDAR_ID", resp.Header.Get("Location"))
+	var resCalendar storage.Calendar
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resCalendar))
+	s.Require().Equal(created, resCalendar)
+}
+
+func (s *HTTPApiSuite) TestReplaceUserSettingsResource() {
+	r, err := http.NewRequestWithContext(s.ctx, "PUT", s.testServer.URL+"/v1/settings",
+		strings.NewReader(`{"time_zone": "Europe/Berlin"}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(UserIDHeader, testUserID)
+
+	saved := storage.UserSettings{UserID: testUserID, TimeZone: "Europe/Berlin"}
+	s.mockedApp.EXPECT().
+		UpdateUserSettings(userMatcher{testUserID}, gomock.Eq(storage.UserSettings{TimeZone: "Europe/Berlin"})).
+		Return(saved, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var resSettings storage.UserSettings
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resSettings))
+	s.Require().Equal(saved, resSettings)
+}
+
+func (s *HTTPApiSuite) TestRemoveNotEmptyCalendarResource() {
+	request, err := http.NewRequestWithContext(s.ctx, "DELETE", s.testServer.URL+"/v1/calendars/TEST_CALENDAR_ID", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().DeleteCalendar(userMatcher{testUserID}, gomock.Eq("TEST_CALENDAR_ID")).
+		Return(storage.ErrCalendarNotEmpty)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("calendar_not_empty", errResp.Error.Code)
+}
+
+func (s *HTTPApiSuite) TestFreeBusy() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/v1/freebusy?user=alice&user=bob&from=2021-09-06T09:00:00Z&to=2021-09-06T17:00:00Z", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	from := time.Date(2021, time.September, 6, 9, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.September, 6, 17, 0, 0, 0, time.UTC)
+	busy := []app.UserBusy{
+		{UserID: "alice", Busy: []app.TimeInterval{{Start: from.Add(time.Hour), End: from.Add(2 * time.Hour)}}},
+		{UserID: "bob", Busy: []app.TimeInterval{}},
+	}
+	s.mockedApp.EXPECT().FreeBusy(userMatcher{testUserID}, gomock.Eq([]string{"alice", "bob"}), from, to).
+		Return(busy, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var freeBusyResp FreeBusyResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&freeBusyResp))
+	s.Require().Equal(busy, freeBusyResp.Users)
+}
+
+func (s *HTTPApiSuite) TestFindFreeSlot() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/v1/freebusy/slot?user=alice&from=2021-09-06T09:00:00Z&to=2021-09-06T17:00:00Z&duration=30m", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	from := time.Date(2021, time.September, 6, 9, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.September, 6, 17, 0, 0, 0, time.UTC)
+	s.mockedApp.EXPECT().FindFreeSlot(userMatcher{testUserID}, gomock.Eq([]string{"alice"}), from, to, 30*time.Minute).
+		Return(app.TimeInterval{}, app.ErrNoFreeSlot)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	var errResp ErrorResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("no_free_slot", errResp.Error.Code)
+}
+
+func (s *HTTPApiSuite) TestFindFreeSlotWithInvalidDuration() {
+	request, err := http.NewRequestWithContext(s.ctx, "GET",
+		s.testServer.URL+"/v1/freebusy/slot?user=alice&from=2021-09-06T09:00:00Z&to=2021-09-06T17:00:00Z&duration=half", nil)
+	s.Require().NoError(err)
+	request.Header.Set(UserIDHeader, testUserID)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(request)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HTTPApiSuite) TestBatchEvents() {
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/batch", strings.NewReader(`{"operations": [
+		{"action": "create", "event": {"title": "Standup", "start_time": "2021-09-06T10:00:00Z", "end_time": "2021-09-06T11:00:00Z"}},
+		{"action": "delete", "event_id": "TEST_EVENT_ID"}
+	]}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(UserIDHeader, testUserID)
+
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	operations := []storage.BatchOperation{
+		{Action: storage.BatchCreate, Event: storage.Event{Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)}},
+		{Action: storage.BatchDelete, Event: storage.Event{ID: "TEST_EVENT_ID"}},
+	}
+	report := app.BatchReport{Items: []app.BatchItemResult{
+		{Action: storage.BatchCreate, EventID: "NEW_EVENT_ID", Status: app.BatchStatusAborted, Error: app.ErrBatchAborted.Error()},
+		{Action: storage.BatchDelete, EventID: "TEST_EVENT_ID", Status: app.BatchStatusFailed, Error: storage.ErrEventNotFound.Error()},
+	}}
+	s.mockedApp.EXPECT().BatchEvents(userMatcher{testUserID}, gomock.Eq(operations)).Return(report, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	var resReport app.BatchReport
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resReport))
+	s.Require().Equal(report, resReport)
+}
+
+func (s *HTTPApiSuite) TestAppliedBatchEvents() {
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/batch", strings.NewReader(`{"operations": [
+		{"action": "delete", "event_id": "TEST_EVENT_ID"}
+	]}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(UserIDHeader, testUserID)
+
+	operations := []storage.BatchOperation{{Action: storage.BatchDelete, Event: storage.Event{ID: "TEST_EVENT_ID"}}}
+	report := app.BatchReport{Applied: true, Items: []app.BatchItemResult{
+		{Action: storage.BatchDelete, EventID: "TEST_EVENT_ID", Status: app.BatchStatusApplied},
+	}}
+	s.mockedApp.EXPECT().BatchEvents(userMatcher{testUserID}, gomock.Eq(operations)).Return(report, nil)
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var resReport app.BatchReport
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&resReport))
+	s.Require().Equal(report, resReport)
+}
+
+func (s *HTTPApiSuite) TestEmptyBatchEvents() {
+	r, err := http.NewRequestWithContext(s.ctx, "POST", s.testServer.URL+"/calendar/batch", strings.NewReader(`{"operations": []}`))
+	s.Require().NoError(err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(UserIDHeader, testUserID)
+
+	s.mockedApp.EXPECT().BatchEvents(userMatcher{testUserID}, gomock.Len(0)).
+		Return(app.BatchReport{}, &app.ValidationError{Field: "operations", Err: app.ErrEmptyBatch})
+
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Do(r)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(resp.Body.Close())
+	}()
+
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func IsEqual(s1 []storage.Event, s2 []storage.Event) bool {
+	if s1 == nil && s2 == nil {
+		return true
+	}
+	if s1 == nil || s2 == nil {
+		return false
+	}
+	if len(s1) != len(s2) {
+		return false
+	}
+
+	for i, e := range s1 {
+		if !e.IsEqual(s2[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// userMatcher - matches context of the request made by the user.
+type versionMatcher struct {
+	version int64
+}
+
+func (m versionMatcher) Matches(x interface{}) bool {
+	event, ok := x.(storage.Event)
+	return ok && event.Version == m.version
+}
+
+func (m versionMatcher) String() string {
+	return fmt.Sprintf("is event of version %d", m.version)
+}
+
+type userMatcher struct {
+	userID string
+}
+
+func (m userMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	userID, err := app.UserID(ctx)
+	return err == nil && userID == m.userID
+}
+
+func (m userMatcher) String() string {
+	return fmt.Sprintf("is context of user %s", m.userID)
+}
+
+// timeZoneMatcher - matches context of the request made in the time zone.
+type timeZoneMatcher struct {
+	name string
+}
+
+func (m timeZoneMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	loc, ok := app.TimeZone(ctx)
+	return ok && loc.String() == m.name
+}
+
+func (m timeZoneMatcher) String() string {
+	return fmt.Sprintf("is context of request in %s time zone", m.name)
 }
 
 type eventsMatcher struct {
@@ -0,0 +1,43 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+)
+
+// settingsResource - path of settings of the user who made the request.
+const settingsResource = "/v1/settings"
+
+type UserSettingsData struct {
+	// TimeZone - optional IANA time zone used for requests without time zone
+	TimeZone string `json:"time_zone"`
+}
+
+func (s Service) GetUserSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.app.GetUserSettings(r.Context())
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, settings); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
+
+// ReplaceUserSettingsHandler - replaces all settings of the user with the body ones.
+func (s Service) ReplaceUserSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	data := new(UserSettingsData)
+	if err := receiveJSON(r, data); err != nil {
+		s.failReceive(w, err)
+		return
+	}
+	settings, err := s.app.UpdateUserSettings(r.Context(), storage.UserSettings{TimeZone: data.TimeZone})
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	if err := sendJSON(w, settings); err != nil {
+		s.fail(w, err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,102 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
+	"go.uber.org/zap"
+)
+
+const (
+	// LastEventIDHeader - header EventSource sends on reconnection with ID of the last received change.
+	LastEventIDHeader = "Last-Event-ID"
+	// lastEventIDParam - the same as LastEventIDHeader for clients which can't set headers
+	lastEventIDParam = "last_event_id"
+	// streamHeartbeatInterval - comments are sent this often to keep idle stream open through proxies
+	streamHeartbeatInterval = 15 * time.Second
+)
+
+// StreamEventsHandler - streams changes of user events overlapping optional [from, to) range as server-sent events,
+// range bounds are passed in RFC 3339 format. Event type is the change type, event ID is the change ID
+// and data is JSON encoded app.EventChange. Stream is resumed after Last-Event-ID header or last_event_id param,
+// "reset" event is sent if missed changes are not available anymore, so the client should reload events.
+// Stream is closed if the client does not keep up with the changes or the server shuts down,
+// the client may reconnect and resume then.
+func (s Service) StreamEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.fail(w, errors.New("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	var from, to time.Time
+	var err error
+	if fromParam := query.Get("from"); fromParam != "" {
+		if from, err = time.Parse(time.RFC3339, fromParam); err != nil {
+			s.fail(w, fmt.Errorf("not valid from param: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if toParam := query.Get("to"); toParam != "" {
+		if to, err = time.Parse(time.RFC3339, toParam); err != nil {
+			s.fail(w, fmt.Errorf("not valid to param: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+	lastEventID := r.Header.Get(LastEventIDHeader)
+	if lastEventID == "" {
+		lastEventID = query.Get(lastEventIDParam)
+	}
+
+	subscription, err := s.app.WatchEvents(r.Context(), from, to, lastEventID)
+	if err != nil {
+		s.fail(w, err, errorStatus(err))
+		return
+	}
+	defer subscription.Close()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// disables response buffering by nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.stopping:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case change, ok := <-subscription.Changes():
+			if !ok {
+				zap.L().Warn("events stream closed", zap.Error(subscription.Err()))
+				return
+			}
+			if err := writeChange(w, change); err != nil {
+				zap.L().Error("error during sending event change", zap.Error(err))
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeChange(w http.ResponseWriter, change app.EventChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("error during event change encoding: %w", err)
+	}
+	// encoded JSON has no new lines, so it is a single data line
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+	return err
+}
@@ -6,9 +6,11 @@ package server
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 
+	app "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
 	storage "github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -36,19 +38,63 @@ func (m *MockApplication) EXPECT() *MockApplicationMockRecorder {
 	return m.recorder
 }
 
+// BatchEvents mocks base method.
+func (m *MockApplication) BatchEvents(arg0 context.Context, arg1 []storage.BatchOperation) (app.BatchReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchEvents", arg0, arg1)
+	ret0, _ := ret[0].(app.BatchReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchEvents indicates an expected call of BatchEvents.
+func (mr *MockApplicationMockRecorder) BatchEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchEvents", reflect.TypeOf((*MockApplication)(nil).BatchEvents), arg0, arg1)
+}
+
+// CreateCalendar mocks base method.
+func (m *MockApplication) CreateCalendar(arg0 context.Context, arg1 storage.Calendar) (storage.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCalendar", arg0, arg1)
+	ret0, _ := ret[0].(storage.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCalendar indicates an expected call of CreateCalendar.
+func (mr *MockApplicationMockRecorder) CreateCalendar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCalendar", reflect.TypeOf((*MockApplication)(nil).CreateCalendar), arg0, arg1)
+}
+
 // CreateEvent mocks base method.
-func (m *MockApplication) CreateEvent(arg0 context.Context, arg1 string, arg2, arg3 time.Time, arg4, arg5 string) (storage.Event, error) {
+func (m *MockApplication) CreateEvent(arg0 context.Context, arg1 storage.Event) (storage.Event, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateEvent", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret := m.ctrl.Call(m, "CreateEvent", arg0, arg1)
 	ret0, _ := ret[0].(storage.Event)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateEvent indicates an expected call of CreateEvent.
-func (mr *MockApplicationMockRecorder) CreateEvent(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+func (mr *MockApplicationMockRecorder) CreateEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEvent", reflect.TypeOf((*MockApplication)(nil).CreateEvent), arg0, arg1)
+}
+
+// DeleteCalendar mocks base method.
+func (m *MockApplication) DeleteCalendar(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendar", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendar indicates an expected call of DeleteCalendar.
+func (mr *MockApplicationMockRecorder) DeleteCalendar(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEvent", reflect.TypeOf((*MockApplication)(nil).CreateEvent), arg0, arg1, arg2, arg3, arg4, arg5)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendar", reflect.TypeOf((*MockApplication)(nil).DeleteCalendar), arg0, arg1)
 }
 
 // DeleteEvent mocks base method.
@@ -65,61 +111,365 @@ func (mr *MockApplicationMockRecorder) DeleteEvent(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEvent", reflect.TypeOf((*MockApplication)(nil).DeleteEvent), arg0, arg1)
 }
 
+// ExportEvents mocks base method.
+func (m *MockApplication) ExportEvents(arg0 context.Context, arg1, arg2 time.Time, arg3 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportEvents", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportEvents indicates an expected call of ExportEvents.
+func (mr *MockApplicationMockRecorder) ExportEvents(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportEvents", reflect.TypeOf((*MockApplication)(nil).ExportEvents), arg0, arg1, arg2, arg3)
+}
+
+// FindFreeSlot mocks base method.
+func (m *MockApplication) FindFreeSlot(arg0 context.Context, arg1 []string, arg2, arg3 time.Time, arg4 time.Duration) (app.TimeInterval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFreeSlot", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(app.TimeInterval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFreeSlot indicates an expected call of FindFreeSlot.
+func (mr *MockApplicationMockRecorder) FindFreeSlot(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFreeSlot", reflect.TypeOf((*MockApplication)(nil).FindFreeSlot), arg0, arg1, arg2, arg3, arg4)
+}
+
+// FreeBusy mocks base method.
+func (m *MockApplication) FreeBusy(arg0 context.Context, arg1 []string, arg2, arg3 time.Time) ([]app.UserBusy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreeBusy", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]app.UserBusy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreeBusy indicates an expected call of FreeBusy.
+func (mr *MockApplicationMockRecorder) FreeBusy(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeBusy", reflect.TypeOf((*MockApplication)(nil).FreeBusy), arg0, arg1, arg2, arg3)
+}
+
+// GetCalendar mocks base method.
+func (m *MockApplication) GetCalendar(arg0 context.Context, arg1 string) (storage.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendar", arg0, arg1)
+	ret0, _ := ret[0].(storage.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendar indicates an expected call of GetCalendar.
+func (mr *MockApplicationMockRecorder) GetCalendar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendar", reflect.TypeOf((*MockApplication)(nil).GetCalendar), arg0, arg1)
+}
+
+// GetEvent mocks base method.
+func (m *MockApplication) GetEvent(arg0 context.Context, arg1 string) (storage.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvent", arg0, arg1)
+	ret0, _ := ret[0].(storage.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvent indicates an expected call of GetEvent.
+func (mr *MockApplicationMockRecorder) GetEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvent", reflect.TypeOf((*MockApplication)(nil).GetEvent), arg0, arg1)
+}
+
+// GetUserSettings mocks base method.
+func (m *MockApplication) GetUserSettings(arg0 context.Context) (storage.UserSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSettings", arg0)
+	ret0, _ := ret[0].(storage.UserSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSettings indicates an expected call of GetUserSettings.
+func (mr *MockApplicationMockRecorder) GetUserSettings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSettings", reflect.TypeOf((*MockApplication)(nil).GetUserSettings), arg0)
+}
+
+// ImportEvents mocks base method.
+func (m *MockApplication) ImportEvents(arg0 context.Context, arg1 io.Reader) (app.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportEvents", arg0, arg1)
+	ret0, _ := ret[0].(app.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportEvents indicates an expected call of ImportEvents.
+func (mr *MockApplicationMockRecorder) ImportEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportEvents", reflect.TypeOf((*MockApplication)(nil).ImportEvents), arg0, arg1)
+}
+
+// InviteAttendee mocks base method.
+func (m *MockApplication) InviteAttendee(arg0 context.Context, arg1, arg2 string, arg3 storage.AttendeeRole) (storage.Attendee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InviteAttendee", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(storage.Attendee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InviteAttendee indicates an expected call of InviteAttendee.
+func (mr *MockApplicationMockRecorder) InviteAttendee(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InviteAttendee", reflect.TypeOf((*MockApplication)(nil).InviteAttendee), arg0, arg1, arg2, arg3)
+}
+
+// ListAttendees mocks base method.
+func (m *MockApplication) ListAttendees(arg0 context.Context, arg1 string) ([]storage.Attendee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttendees", arg0, arg1)
+	ret0, _ := ret[0].([]storage.Attendee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttendees indicates an expected call of ListAttendees.
+func (mr *MockApplicationMockRecorder) ListAttendees(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttendees", reflect.TypeOf((*MockApplication)(nil).ListAttendees), arg0, arg1)
+}
+
+// ListCalendars mocks base method.
+func (m *MockApplication) ListCalendars(arg0 context.Context) ([]storage.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCalendars", arg0)
+	ret0, _ := ret[0].([]storage.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCalendars indicates an expected call of ListCalendars.
+func (mr *MockApplicationMockRecorder) ListCalendars(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCalendars", reflect.TypeOf((*MockApplication)(nil).ListCalendars), arg0)
+}
+
 // ListDayEvents mocks base method.
-func (m *MockApplication) ListDayEvents(arg0 context.Context, arg1 time.Time) ([]storage.Event, error) {
+func (m *MockApplication) ListDayEvents(arg0 context.Context, arg1 time.Time, arg2 ...string) ([]storage.Event, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListDayEvents", arg0, arg1)
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListDayEvents", varargs...)
 	ret0, _ := ret[0].([]storage.Event)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListDayEvents indicates an expected call of ListDayEvents.
-func (mr *MockApplicationMockRecorder) ListDayEvents(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockApplicationMockRecorder) ListDayEvents(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDayEvents", reflect.TypeOf((*MockApplication)(nil).ListDayEvents), arg0, arg1)
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDayEvents", reflect.TypeOf((*MockApplication)(nil).ListDayEvents), varargs...)
+}
+
+// ListEvents mocks base method.
+func (m *MockApplication) ListEvents(arg0 context.Context, arg1, arg2 time.Time, arg3 app.EventFilter, arg4 string, arg5 int) (app.EventsPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(app.EventsPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockApplicationMockRecorder) ListEvents(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockApplication)(nil).ListEvents), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
 // ListMonthEvents mocks base method.
-func (m *MockApplication) ListMonthEvents(arg0 context.Context, arg1 time.Time) ([]storage.Event, error) {
+func (m *MockApplication) ListMonthEvents(arg0 context.Context, arg1 time.Time, arg2 ...string) ([]storage.Event, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListMonthEvents", arg0, arg1)
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListMonthEvents", varargs...)
 	ret0, _ := ret[0].([]storage.Event)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListMonthEvents indicates an expected call of ListMonthEvents.
-func (mr *MockApplicationMockRecorder) ListMonthEvents(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockApplicationMockRecorder) ListMonthEvents(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMonthEvents", reflect.TypeOf((*MockApplication)(nil).ListMonthEvents), arg0, arg1)
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMonthEvents", reflect.TypeOf((*MockApplication)(nil).ListMonthEvents), varargs...)
 }
 
 // ListWeekEvents mocks base method.
-func (m *MockApplication) ListWeekEvents(arg0 context.Context, arg1 time.Time) ([]storage.Event, error) {
+func (m *MockApplication) ListWeekEvents(arg0 context.Context, arg1 time.Time, arg2 ...string) ([]storage.Event, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListWeekEvents", arg0, arg1)
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListWeekEvents", varargs...)
 	ret0, _ := ret[0].([]storage.Event)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListWeekEvents indicates an expected call of ListWeekEvents.
-func (mr *MockApplicationMockRecorder) ListWeekEvents(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockApplicationMockRecorder) ListWeekEvents(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWeekEvents", reflect.TypeOf((*MockApplication)(nil).ListWeekEvents), arg0, arg1)
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWeekEvents", reflect.TypeOf((*MockApplication)(nil).ListWeekEvents), varargs...)
 }
 
-// UpdateEvent mocks base method.
-func (m *MockApplication) UpdateEvent(arg0 context.Context, arg1 storage.Event) error {
+// Location mocks base method.
+func (m *MockApplication) Location(arg0 context.Context, arg1 ...string) (*time.Location, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateEvent", arg0, arg1)
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Location", varargs...)
+	ret0, _ := ret[0].(*time.Location)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Location indicates an expected call of Location.
+func (mr *MockApplicationMockRecorder) Location(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Location", reflect.TypeOf((*MockApplication)(nil).Location), varargs...)
+}
+
+// PatchEvent mocks base method.
+func (m *MockApplication) PatchEvent(arg0 context.Context, arg1 string, arg2 app.EventPatch, arg3 int64) (storage.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchEvent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(storage.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchEvent indicates an expected call of PatchEvent.
+func (mr *MockApplicationMockRecorder) PatchEvent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchEvent", reflect.TypeOf((*MockApplication)(nil).PatchEvent), arg0, arg1, arg2, arg3)
+}
+
+// RemoveAttendee mocks base method.
+func (m *MockApplication) RemoveAttendee(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAttendee", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// RemoveAttendee indicates an expected call of RemoveAttendee.
+func (mr *MockApplicationMockRecorder) RemoveAttendee(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAttendee", reflect.TypeOf((*MockApplication)(nil).RemoveAttendee), arg0, arg1, arg2)
+}
+
+// RespondToInvitation mocks base method.
+func (m *MockApplication) RespondToInvitation(arg0 context.Context, arg1 string, arg2 storage.RSVPStatus) (storage.Attendee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RespondToInvitation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.Attendee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RespondToInvitation indicates an expected call of RespondToInvitation.
+func (mr *MockApplicationMockRecorder) RespondToInvitation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RespondToInvitation", reflect.TypeOf((*MockApplication)(nil).RespondToInvitation), arg0, arg1, arg2)
+}
+
+// SearchEvents mocks base method.
+func (m *MockApplication) SearchEvents(arg0 context.Context, arg1 string, arg2, arg3 time.Time, arg4 int) ([]storage.SearchHit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchEvents", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]storage.SearchHit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchEvents indicates an expected call of SearchEvents.
+func (mr *MockApplicationMockRecorder) SearchEvents(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchEvents", reflect.TypeOf((*MockApplication)(nil).SearchEvents), arg0, arg1, arg2, arg3, arg4)
+}
+
+// UpdateCalendar mocks base method.
+func (m *MockApplication) UpdateCalendar(arg0 context.Context, arg1 storage.Calendar) (storage.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCalendar", arg0, arg1)
+	ret0, _ := ret[0].(storage.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCalendar indicates an expected call of UpdateCalendar.
+func (mr *MockApplicationMockRecorder) UpdateCalendar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCalendar", reflect.TypeOf((*MockApplication)(nil).UpdateCalendar), arg0, arg1)
+}
+
+// UpdateEvent mocks base method.
+func (m *MockApplication) UpdateEvent(arg0 context.Context, arg1 storage.Event) (storage.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEvent", arg0, arg1)
+	ret0, _ := ret[0].(storage.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // UpdateEvent indicates an expected call of UpdateEvent.
 func (mr *MockApplicationMockRecorder) UpdateEvent(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEvent", reflect.TypeOf((*MockApplication)(nil).UpdateEvent), arg0, arg1)
 }
+
+// UpdateUserSettings mocks base method.
+func (m *MockApplication) UpdateUserSettings(arg0 context.Context, arg1 storage.UserSettings) (storage.UserSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserSettings", arg0, arg1)
+	ret0, _ := ret[0].(storage.UserSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserSettings indicates an expected call of UpdateUserSettings.
+func (mr *MockApplicationMockRecorder) UpdateUserSettings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserSettings", reflect.TypeOf((*MockApplication)(nil).UpdateUserSettings), arg0, arg1)
+}
+
+// WatchEvents mocks base method.
+func (m *MockApplication) WatchEvents(arg0 context.Context, arg1, arg2 time.Time, arg3 string) (*app.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchEvents", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*app.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchEvents indicates an expected call of WatchEvents.
+func (mr *MockApplicationMockRecorder) WatchEvents(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchEvents", reflect.TypeOf((*MockApplication)(nil).WatchEvents), arg0, arg1, arg2, arg3)
+}
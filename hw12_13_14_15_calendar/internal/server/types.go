@@ -2,17 +2,67 @@ package server
 
 import (
 	"context"
+	"io"
 	"time"
 
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/app"
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
 )
 
+// Application - calendar use cases, all of them are made on behalf of the user
+// put into request context by app.WithUserID.
+//
 //go:generate mockgen --build_flags=--mod=mod -destination=./mock_types.go -package=server . Application
 type Application interface {
-	CreateEvent(ctx context.Context, title string, startTime, endTime time.Time, description, ownerID string) (storage.Event, error)
-	UpdateEvent(ctx context.Context, event storage.Event) error
+	CreateEvent(ctx context.Context, event storage.Event) (storage.Event, error)
+	// GetEvent - returns storage.ErrEventNotFound if the user neither owns nor attends event with the ID.
+	GetEvent(ctx context.Context, eventID string) (storage.Event, error)
+	// UpdateEvent - updates event if its version is event.Version, returns the event with the new version.
+	UpdateEvent(ctx context.Context, event storage.Event) (storage.Event, error)
+	// PatchEvent - updates only the patch fields, zero version means the current event version.
+	PatchEvent(ctx context.Context, eventID string, patch app.EventPatch, version int64) (storage.Event, error)
 	DeleteEvent(ctx context.Context, eventID string) error
-	ListDayEvents(ctx context.Context, date time.Time) ([]storage.Event, error)
-	ListWeekEvents(ctx context.Context, date time.Time) ([]storage.Event, error)
-	ListMonthEvents(ctx context.Context, date time.Time) ([]storage.Event, error)
+	// InviteAttendee - invites the user to the event with the role, only the event owner can invite.
+	InviteAttendee(ctx context.Context, eventID, userID string, role storage.AttendeeRole) (storage.Attendee, error)
+	// RemoveAttendee - the owner removes any attendee, attendees remove only themselves.
+	RemoveAttendee(ctx context.Context, eventID, userID string) error
+	RespondToInvitation(ctx context.Context, eventID string, response storage.RSVPStatus) (storage.Attendee, error)
+	ListAttendees(ctx context.Context, eventID string) ([]storage.Attendee, error)
+	// Location - returns time zone calendar dates of the request are interpreted in,
+	// time zone of the calendars with the IDs is used if all of them have the same one and the client didn't pass its one.
+	Location(ctx context.Context, calendarIDs ...string) (*time.Location, error)
+	// ListDayEvents, ListWeekEvents and ListMonthEvents - list events of the period the date is in,
+	// only events of the given calendars of the user are listed if calendar IDs are passed.
+	ListDayEvents(ctx context.Context, date time.Time, calendarIDs ...string) ([]storage.Event, error)
+	ListWeekEvents(ctx context.Context, date time.Time, calendarIDs ...string) ([]storage.Event, error)
+	ListMonthEvents(ctx context.Context, date time.Time, calendarIDs ...string) ([]storage.Event, error)
+	ListEvents(
+		ctx context.Context,
+		from, to time.Time,
+		filter app.EventFilter,
+		pageToken string,
+		limit int,
+	) (app.EventsPage, error)
+	SearchEvents(ctx context.Context, text string, from, to time.Time, limit int) ([]storage.SearchHit, error)
+	// WatchEvents - subscribes to user event changes, subscription must be closed by the caller.
+	WatchEvents(ctx context.Context, from, to time.Time, afterChangeID string) (*app.Subscription, error)
+	ExportEvents(ctx context.Context, from, to time.Time, w io.Writer) error
+	ImportEvents(ctx context.Context, r io.Reader) (app.ImportReport, error)
+	// BatchEvents - applies operations all-or-nothing, error is returned only if the batch itself is invalid.
+	BatchEvents(ctx context.Context, operations []storage.BatchOperation) (app.BatchReport, error)
+	CreateCalendar(ctx context.Context, calendar storage.Calendar) (storage.Calendar, error)
+	// GetCalendar - returns storage.ErrCalendarNotFound if the user has no calendar with the ID.
+	GetCalendar(ctx context.Context, calendarID string) (storage.Calendar, error)
+	UpdateCalendar(ctx context.Context, calendar storage.Calendar) (storage.Calendar, error)
+	// DeleteCalendar - returns storage.ErrCalendarNotEmpty if the calendar has events.
+	DeleteCalendar(ctx context.Context, calendarID string) error
+	ListCalendars(ctx context.Context) ([]storage.Calendar, error)
+	GetUserSettings(ctx context.Context) (storage.UserSettings, error)
+	// UpdateUserSettings - replaces settings of the user, default time zone of the user is used
+	// for requests without time zone.
+	UpdateUserSettings(ctx context.Context, settings storage.UserSettings) (storage.UserSettings, error)
+	// FreeBusy - returns busy intervals of the users within [from, to) range.
+	FreeBusy(ctx context.Context, userIDs []string, from, to time.Time) ([]app.UserBusy, error)
+	// FindFreeSlot - returns app.ErrNoFreeSlot if the users have no common free interval of the duration in range.
+	FindFreeSlot(ctx context.Context, userIDs []string, from, to time.Time, duration time.Duration) (app.TimeInterval, error)
 }
@@ -0,0 +1,45 @@
+package storage
+
+import "errors"
+
+var ErrAttendeeNotFound = errors.New("attendee not found")
+
+// AttendeeRole - what the attendee is allowed to do with the event besides viewing it.
+type AttendeeRole string
+
+const (
+	AttendeeRoleViewer AttendeeRole = "viewer"
+	AttendeeRoleEditor AttendeeRole = "editor"
+)
+
+func (r AttendeeRole) IsValid() bool {
+	return r == AttendeeRoleViewer || r == AttendeeRoleEditor
+}
+
+// RSVPStatus - attendee response to the invitation.
+type RSVPStatus string
+
+const (
+	// RSVPNeedsAction - attendee has not responded yet, every invitation starts with it.
+	RSVPNeedsAction RSVPStatus = "needs_action"
+	RSVPAccepted    RSVPStatus = "accepted"
+	RSVPDeclined    RSVPStatus = "declined"
+	RSVPTentative   RSVPStatus = "tentative"
+)
+
+// IsResponse - checks that status is one attendee can respond with.
+func (s RSVPStatus) IsResponse() bool {
+	return s == RSVPAccepted || s == RSVPDeclined || s == RSVPTentative
+}
+
+// Attendee - пользователь, приглашенный на событие владельцем.
+type Attendee struct {
+	// ID события;
+	EventID string `db:"event_id" json:"event_id"`
+	// ID приглашенного пользователя;
+	UserID string `db:"user_id" json:"user_id"`
+	// Роль приглашенного: просмотр или редактирование события;
+	Role AttendeeRole `db:"role" json:"role"`
+	// Ответ на приглашение.
+	Status RSVPStatus `db:"status" json:"status"`
+}
@@ -0,0 +1,38 @@
+package storage
+
+import "fmt"
+
+// BatchAction - kind of batch operation.
+type BatchAction string
+
+const (
+	BatchCreate BatchAction = "create"
+	BatchUpdate BatchAction = "update"
+	BatchDelete BatchAction = "delete"
+)
+
+func (a BatchAction) IsValid() bool {
+	return a == BatchCreate || a == BatchUpdate || a == BatchDelete
+}
+
+// BatchOperation - operation of the batch, it is checked the same way as the single event operation of the action:
+// created event must have unique ID, updated event must have the stored version,
+// only ID and OwnerID of deleted event are used.
+type BatchOperation struct {
+	Action BatchAction
+	Event  Event
+}
+
+// BatchError - the batch is not applied because operation with Index failed with Err.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch operation %d error: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/recurrence"
+)
+
+// BusyCheckHorizon - recurring events are checked for conflicts only within this period after the series start,
+// because infinite series could not be checked entirely.
+const BusyCheckHorizon = 2 * 365 * 24 * time.Hour
+
+// IsEventInsideTimeInterval - checks whether the event overlaps time interval.
+// Examples below:
+// intervalStart eventStart eventEnd intervalEnd - is OK.
+// eventStart intervalStart eventEnd intervalEnd - is OK.
+// eventStart intervalStart intervalEnd eventEnd - is OK.
+// intervalStart eventStart intervalEnd eventEnd - is OK.
+// intervalStart intervalEnd eventStart eventEnd - not OK.
+// eventStart eventEnd intervalStart intervalEnd - not OK.
+func IsEventInsideTimeInterval(intervalStart, intervalEnd, eventStart, eventEnd time.Time) bool {
+	// intervalEnd > eventStart && intervalStart < eventEnd
+	return intervalEnd.After(eventStart) && intervalStart.Before(eventEnd)
+}
+
+// BusyInterval - returns the interval where the event could conflict with other events.
+func (e Event) BusyInterval() (from, to time.Time, err error) {
+	if !e.IsRecurring() {
+		return e.StartTime, e.EndTime, nil
+	}
+	rule, err := recurrence.Parse(e.RecurrenceRule)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("error during event %s recurrence rule parsing: %w", e.ID, err)
+	}
+	dtstart, err := e.seriesStart()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to = e.StartTime.Add(BusyCheckHorizon)
+	if lastStart, ok := rule.Last(dtstart); ok && lastStart.Before(to) {
+		to = lastStart.Add(e.EndTime.Sub(e.StartTime))
+	}
+	return e.StartTime, to, nil
+}
+
+// ConflictsWith - checks whether any occurrence of the event overlaps any occurrence of other event
+// inside the event busy interval. Events which only touch each other (one ends when another starts) do not conflict.
+func (e Event) ConflictsWith(other Event) (bool, error) {
+	from, to, err := e.BusyInterval()
+	if err != nil {
+		return false, err
+	}
+	occurrences, err := e.Occurrences(from, to)
+	if err != nil {
+		return false, err
+	}
+	otherOccurrences, err := other.Occurrences(from, to)
+	if err != nil {
+		return false, err
+	}
+	for _, occurrence := range occurrences {
+		// occurrences are sorted by start time
+		for _, otherOccurrence := range otherOccurrences {
+			if !otherOccurrence.StartTime.Before(occurrence.EndTime) {
+				break
+			}
+			if otherOccurrence.EndTime.After(occurrence.StartTime) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// FindConflict - returns the first of owner events which conflicts with the event, ok is false if there is no one.
+// The event itself (event with the same ID) and events of other owners are skipped.
+func FindConflict(event Event, events []Event) (conflict Event, ok bool, err error) {
+	for _, other := range events {
+		if other.ID == event.ID || other.OwnerID != event.OwnerID {
+			continue
+		}
+		conflicts, err := event.ConflictsWith(other)
+		if err != nil {
+			return Event{}, false, err
+		}
+		if conflicts {
+			return other, true, nil
+		}
+	}
+	return Event{}, false, nil
+}
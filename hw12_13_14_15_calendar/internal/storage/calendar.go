@@ -0,0 +1,22 @@
+package storage
+
+import "errors"
+
+var (
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrCalendarNotEmpty = errors.New("calendar has events")
+)
+
+// Calendar - именованный набор событий пользователя.
+type Calendar struct {
+	// ID - уникальный идентификатор календаря (UUID);
+	ID string `db:"id" json:"id"`
+	// ID пользователя, владельца календаря;
+	OwnerID string `db:"owner_id" json:"owner_id"`
+	// Название календаря;
+	Name string `db:"name" json:"name"`
+	// Цвет событий календаря в формате #RRGGBB, опционально;
+	Color string `db:"color" json:"color"`
+	// Часовой пояс календаря по умолчанию (IANA), опционально.
+	TimeZone string `db:"time_zone" json:"time_zone"`
+}
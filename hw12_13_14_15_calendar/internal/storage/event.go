@@ -1,13 +1,22 @@
 package storage
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/recurrence"
 )
 
 var (
 	ErrEventNotFound      = errors.New("event not found")
 	ErrEventAlreadyExists = errors.New("event already exists")
+	ErrDateBusy           = errors.New("date is busy by another event")
+	ErrVersionConflict    = errors.New("event was changed by another request")
+	ErrUnknownTimeZone    = errors.New("unknown event time zone")
 )
 
 type Event struct {
@@ -23,12 +32,223 @@ type Event struct {
 	Description string `faker:"paragraph" db:"description" json:"description"`
 	// ID пользователя, владельца события
 	OwnerID string `faker:"uuid_hyphenated" db:"owner_id" json:"owner_id"`
+	// За сколько времени высылать уведомление, опционально (нулевое значение - уведомление в момент начала события).
+	NotifyBefore time.Duration `faker:"-" db:"notify_before" json:"notify_before"`
+	// Правило повторения события в формате RRULE (RFC 5545), опционально (пустая строка - событие не повторяется);
+	RecurrenceRule string `faker:"-" db:"recurrence_rule" json:"recurrence_rule"`
+	// Даты начала исключенных из серии повторений события, опционально.
+	ExceptionDates ExceptionDates `faker:"-" db:"exception_dates" json:"exception_dates"`
+	// Версия события, увеличивается при каждом изменении.
+	Version int64 `faker:"-" db:"version" json:"version"`
+	// ID календаря владельца, в котором находится событие, опционально (пустая строка - вне календарей).
+	CalendarID string `faker:"-" db:"calendar_id" json:"calendar_id"`
+	// Часовой пояс (IANA), в котором повторяется серия событий, опционально (пустая строка - часовой пояс времени начала).
+	TimeZone string `faker:"-" db:"time_zone" json:"time_zone"`
 }
 
 // IsEqual - check two events is equal, this function is mostly used in tests.
 func (e1 Event) IsEqual(e2 Event) bool {
-	if e1.ID != e2.ID || e1.Title != e2.Title || e1.Description != e2.Description || e1.OwnerID != e2.OwnerID {
+	if e1.ID != e2.ID || e1.Title != e2.Title || e1.Description != e2.Description || e1.OwnerID != e2.OwnerID ||
+		e1.NotifyBefore != e2.NotifyBefore || e1.RecurrenceRule != e2.RecurrenceRule || e1.CalendarID != e2.CalendarID ||
+		e1.TimeZone != e2.TimeZone || len(e1.ExceptionDates) != len(e2.ExceptionDates) {
 		return false
 	}
+	for i := range e1.ExceptionDates {
+		if !e1.ExceptionDates[i].Equal(e2.ExceptionDates[i]) {
+			return false
+		}
+	}
 	return e1.StartTime.Equal(e2.StartTime) && e1.EndTime.Equal(e2.EndTime)
 }
+
+func (e Event) IsRecurring() bool {
+	return e.RecurrenceRule != ""
+}
+
+// NotificationTime - moment when the event notification must be sent.
+func (e Event) NotificationTime() time.Time {
+	return e.StartTime.Add(-e.NotifyBefore)
+}
+
+// Occurrences - returns event occurrences which overlap [from, to] interval.
+// Not recurring event has the only occurrence - event itself.
+// Every occurrence of recurring event keeps the series ID and has its own start and end time.
+func (e Event) Occurrences(from, to time.Time) ([]Event, error) {
+	duration := e.EndTime.Sub(e.StartTime)
+	occurrences, err := e.occurrencesStartedIn(from.Add(-duration), to)
+	if err != nil {
+		return nil, err
+	}
+	result := occurrences[:0]
+	for _, occurrence := range occurrences {
+		if occurrence.EndTime.After(from) {
+			result = append(result, occurrence)
+		}
+	}
+	return result, nil
+}
+
+// OccurrencesToNotify - returns event occurrences which notification time is inside [from, to) interval.
+func (e Event) OccurrencesToNotify(from, to time.Time) ([]Event, error) {
+	return e.occurrencesStartedIn(from.Add(e.NotifyBefore), to.Add(e.NotifyBefore))
+}
+
+// EndedBefore - checks whether the event (the last occurrence for recurring event) ended before t.
+// Infinite recurring events never end.
+func (e Event) EndedBefore(t time.Time) (bool, error) {
+	if !e.IsRecurring() {
+		return e.EndTime.Before(t), nil
+	}
+	rule, err := recurrence.Parse(e.RecurrenceRule)
+	if err != nil {
+		return false, fmt.Errorf("error during event %s recurrence rule parsing: %w", e.ID, err)
+	}
+	dtstart, err := e.seriesStart()
+	if err != nil {
+		return false, err
+	}
+	lastStart, ok := rule.Last(dtstart)
+	if !ok {
+		return false, nil
+	}
+	return lastStart.Add(e.EndTime.Sub(e.StartTime)).Before(t), nil
+}
+
+// Overlaps - checks whether the event (any occurrence for recurring event) overlaps [from, to) interval,
+// zero from or to means the interval is not bounded from that side.
+func (e Event) Overlaps(from, to time.Time) (bool, error) {
+	if !to.IsZero() && !e.StartTime.Before(to) {
+		return false, nil
+	}
+	if from.IsZero() {
+		return true, nil
+	}
+	if !e.IsRecurring() {
+		return e.EndTime.After(from), nil
+	}
+	if to.IsZero() {
+		ended, err := e.EndedBefore(from)
+		return !ended, err
+	}
+	occurrences, err := e.Occurrences(from, to)
+	return len(occurrences) > 0, err
+}
+
+// occurrencesStartedIn - returns event occurrences which start time is inside [from, to) interval.
+func (e Event) occurrencesStartedIn(from, to time.Time) ([]Event, error) {
+	if !e.IsRecurring() {
+		if !e.StartTime.Before(from) && e.StartTime.Before(to) {
+			return []Event{e}, nil
+		}
+		return nil, nil
+	}
+	rule, err := recurrence.Parse(e.RecurrenceRule)
+	if err != nil {
+		return nil, fmt.Errorf("error during event %s recurrence rule parsing: %w", e.ID, err)
+	}
+	dtstart, err := e.seriesStart()
+	if err != nil {
+		return nil, err
+	}
+	duration := e.EndTime.Sub(e.StartTime)
+	var result []Event
+	for _, start := range rule.Between(dtstart, from, to) {
+		if e.ExceptionDates.Contains(start) {
+			continue
+		}
+		occurrence := e
+		occurrence.StartTime = start
+		occurrence.EndTime = start.Add(duration)
+		result = append(result, occurrence)
+	}
+	return result, nil
+}
+
+// seriesStart - returns the series start in the series time zone, so occurrences keep their wall clock time
+// and weekday whatever location the stored start time has (e.g. UTC read from database or fixed offset from JSON).
+func (e Event) seriesStart() (time.Time, error) {
+	if e.TimeZone == "" {
+		return e.StartTime, nil
+	}
+	loc, err := loadLocation(e.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: event %s time zone %q", ErrUnknownTimeZone, e.ID, e.TimeZone)
+	}
+	return e.StartTime.In(loc), nil
+}
+
+// locations - cache of loaded time zones, time.LoadLocation reads time zone database on every call.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// ExpandOccurrences - replaces every event with its occurrences which overlap [from, to] interval.
+func ExpandOccurrences(events []Event, from, to time.Time) ([]Event, error) {
+	var result []Event
+	for _, event := range events {
+		occurrences, err := event.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, occurrences...)
+	}
+	return result, nil
+}
+
+// ExceptionDates - start times of occurrences excluded from recurring event series.
+// In database it is stored as json array.
+type ExceptionDates []time.Time
+
+func (d ExceptionDates) Contains(t time.Time) bool {
+	for _, date := range d {
+		if date.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d ExceptionDates) Value() (driver.Value, error) {
+	if d == nil {
+		return "[]", nil
+	}
+	value, err := json.Marshal([]time.Time(d))
+	if err != nil {
+		return nil, fmt.Errorf("error during exception dates serialization: %w", err)
+	}
+	return string(value), nil
+}
+
+func (d *ExceptionDates) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported exception dates db type %T", src)
+	}
+	var dates []time.Time
+	if err := json.Unmarshal(data, &dates); err != nil {
+		return fmt.Errorf("error during exception dates deserialization: %w", err)
+	}
+	if len(dates) == 0 {
+		dates = nil
+	}
+	*d = dates
+	return nil
+}
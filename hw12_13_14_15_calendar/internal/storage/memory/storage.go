@@ -2,6 +2,9 @@ package memorystorage
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,83 +17,526 @@ type MemStorage struct {
 	// TODO only its advantage is that we can get event by id in complexity of O(1)
 	// TODO maybe even a simple slice will perform better because it's sequential
 	store map[string]storage.Event
+	// event IDs by owner ID, used for busy checks and owner scoped queries
+	owners map[string]map[string]struct{}
+	// event IDs by words of their title and description, used for full text search
+	words map[string]map[string]struct{}
+	// notification statuses by notification key (see notificationKey)
+	statuses map[string]storage.NotificationStatus
+	// attendees by event ID and user ID, they are kept while event is updated and dropped with the event
+	attendees map[string]map[string]storage.Attendee
+	// event IDs by attendee user ID, used for attendee scoped queries
+	attended map[string]map[string]struct{}
+	// calendars by ID
+	calendars map[string]storage.Calendar
+	// settings by user ID
+	settings map[string]storage.UserSettings
 }
 
 func (s *MemStorage) AddEvent(ctx context.Context, event storage.Event) error {
 	s.rw.Lock()
 	defer s.rw.Unlock()
+	return s.addEvent(event)
+}
+
+func (s *MemStorage) UpdateEvent(ctx context.Context, event storage.Event) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	return s.updateEvent(event)
+}
+
+// addEvent - must be called under write lock.
+func (s *MemStorage) addEvent(event storage.Event) error {
 	if _, ok := s.store[event.ID]; ok {
 		return storage.ErrEventAlreadyExists
 	}
-	s.store[event.ID] = event
+	if err := s.checkBusy(event); err != nil {
+		return err
+	}
+	s.put(event)
 	return nil
 }
 
-func (s *MemStorage) UpdateEvent(ctx context.Context, event storage.Event) error {
+// updateEvent - must be called under write lock.
+func (s *MemStorage) updateEvent(event storage.Event) error {
+	if !s.owns(event.OwnerID, event.ID) {
+		return storage.ErrEventNotFound
+	}
+	if s.store[event.ID].Version != event.Version {
+		return storage.ErrVersionConflict
+	}
+	if err := s.checkBusy(event); err != nil {
+		return err
+	}
+	event.Version++
+	s.remove(event.ID)
+	s.put(event)
+	return nil
+}
+
+// checkBusy - checks that event doesn't overlap other events of the same owner, must be called under write lock.
+func (s *MemStorage) checkBusy(event storage.Event) error {
+	ownerEvents := make([]storage.Event, 0, len(s.owners[event.OwnerID]))
+	for eventID := range s.owners[event.OwnerID] {
+		ownerEvents = append(ownerEvents, s.store[eventID])
+	}
+	conflict, busy, err := storage.FindConflict(event, ownerEvents)
+	if err != nil {
+		return err
+	}
+	if busy {
+		return fmt.Errorf("%w: conflicts with event %s", storage.ErrDateBusy, conflict.ID)
+	}
+	return nil
+}
+
+func (s *MemStorage) DeleteEvent(ctx context.Context, ownerID, eventID string) error {
 	s.rw.Lock()
 	defer s.rw.Unlock()
-	if _, ok := s.store[event.ID]; !ok {
+	return s.deleteEvent(ownerID, eventID)
+}
+
+// deleteEvent - must be called under write lock.
+func (s *MemStorage) deleteEvent(ownerID, eventID string) error {
+	if !s.owns(ownerID, eventID) {
 		return storage.ErrEventNotFound
 	}
-	s.store[event.ID] = event
+	s.remove(eventID)
+	s.dropAttendees(eventID)
 	return nil
 }
 
-func (s *MemStorage) DeleteEvent(ctx context.Context, eventID string) error {
+// ApplyBatch - applies operations in order under the write lock,
+// if one of them fails the applied ones are undone in reverse order.
+func (s *MemStorage) ApplyBatch(ctx context.Context, operations []storage.BatchOperation) error {
 	s.rw.Lock()
 	defer s.rw.Unlock()
-	if _, ok := s.store[eventID]; !ok {
-		return storage.ErrEventNotFound
+	undo := make([]func(), 0, len(operations))
+	for i, operation := range operations {
+		revert, err := s.apply(operation)
+		if err != nil {
+			for j := len(undo) - 1; j >= 0; j-- {
+				undo[j]()
+			}
+			return &storage.BatchError{Index: i, Err: err}
+		}
+		undo = append(undo, revert)
 	}
-	delete(s.store, eventID)
 	return nil
 }
 
-func (s *MemStorage) FindEventsInInterval(ctx context.Context, intervalStart, intervalEnd time.Time) ([]storage.Event, error) {
+// apply - applies batch operation, returns function reverting it, must be called under write lock.
+func (s *MemStorage) apply(operation storage.BatchOperation) (revert func(), err error) {
+	event := operation.Event
+	previous := s.store[event.ID]
+	switch operation.Action {
+	case storage.BatchCreate:
+		err = s.addEvent(event)
+		revert = func() {
+			s.remove(event.ID)
+		}
+	case storage.BatchUpdate:
+		err = s.updateEvent(event)
+		revert = func() {
+			s.remove(event.ID)
+			s.put(previous)
+		}
+	case storage.BatchDelete:
+		attendees := s.attendees[event.ID]
+		err = s.deleteEvent(event.OwnerID, event.ID)
+		revert = func() {
+			s.put(previous)
+			if len(attendees) > 0 {
+				s.attendees[event.ID] = attendees
+			}
+			for userID := range attendees {
+				addToIndex(s.attended, userID, event.ID)
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown batch action %q", operation.Action)
+	}
+	return revert, err
+}
+
+// owns - checks that event exists and belongs to the owner, must be called under lock.
+func (s *MemStorage) owns(ownerID, eventID string) bool {
+	_, ok := s.owners[ownerID][eventID]
+	return ok
+}
+
+// visible - checks that event exists and the user is its owner or attendee, must be called under lock.
+func (s *MemStorage) visible(userID, eventID string) bool {
+	_, attends := s.attended[userID][eventID]
+	return attends || s.owns(userID, eventID)
+}
+
+// put - saves event and indexes it by owner and words, must be called under write lock.
+func (s *MemStorage) put(event storage.Event) {
+	s.store[event.ID] = event
+	addToIndex(s.owners, event.OwnerID, event.ID)
+	for _, word := range eventWords(event) {
+		addToIndex(s.words, word, event.ID)
+	}
+}
+
+// remove - removes event and its index entries, must be called under write lock.
+func (s *MemStorage) remove(eventID string) {
+	event, ok := s.store[eventID]
+	if !ok {
+		return
+	}
+	delete(s.store, eventID)
+	removeFromIndex(s.owners, event.OwnerID, eventID)
+	for _, word := range eventWords(event) {
+		removeFromIndex(s.words, word, eventID)
+	}
+}
+
+func addToIndex(index map[string]map[string]struct{}, key, eventID string) {
+	eventIDs, ok := index[key]
+	if !ok {
+		eventIDs = make(map[string]struct{})
+		index[key] = eventIDs
+	}
+	eventIDs[eventID] = struct{}{}
+}
+
+func removeFromIndex(index map[string]map[string]struct{}, key, eventID string) {
+	delete(index[key], eventID)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+func eventWords(event storage.Event) []string {
+	return append(storage.Tokenize(event.Title), storage.Tokenize(event.Description)...)
+}
+
+// FindEventsInInterval - finds events of the user as owner or attendee, declined invitations are skipped.
+// If calendar IDs are given, only owned events of these calendars are found.
+func (s *MemStorage) FindEventsInInterval(
+	ctx context.Context,
+	userID string,
+	intervalStart, intervalEnd time.Time,
+	calendarIDs ...string,
+) ([]storage.Event, error) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+	var resultEvents []storage.Event
+	eventIDs := make([]string, 0, len(s.owners[userID])+len(s.attended[userID]))
+	for eventID := range s.owners[userID] {
+		if len(calendarIDs) == 0 || containsString(calendarIDs, s.store[eventID].CalendarID) {
+			eventIDs = append(eventIDs, eventID)
+		}
+	}
+	for eventID := range s.attended[userID] {
+		if len(calendarIDs) == 0 && s.attendees[eventID][userID].Status != storage.RSVPDeclined {
+			eventIDs = append(eventIDs, eventID)
+		}
+	}
+	for _, eventID := range eventIDs {
+		event := s.store[eventID]
+		if event.IsRecurring() {
+			occurrences, err := event.Occurrences(intervalStart, intervalEnd)
+			if err != nil {
+				return nil, err
+			}
+			resultEvents = append(resultEvents, occurrences...)
+			continue
+		}
+		if storage.IsEventInsideTimeInterval(intervalStart, intervalEnd, event.StartTime, event.EndTime) {
+			resultEvents = append(resultEvents, event)
+		}
+	}
+	return resultEvents, nil
+}
+
+// SearchEvents - finds owner events containing all query words, hits are ordered by rank.
+func (s *MemStorage) SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchHit, error) {
+	terms := query.Terms()
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	// candidates are events containing the rarest term
+	candidates := s.words[terms[0]]
+	for _, term := range terms[1:] {
+		if len(s.words[term]) < len(candidates) {
+			candidates = s.words[term]
+		}
+	}
+	var hits []storage.SearchHit
+	for eventID := range candidates {
+		if !s.owns(query.OwnerID, eventID) {
+			continue
+		}
+		event := s.store[eventID]
+		rank := storage.Rank(event, terms)
+		if rank == 0 {
+			continue
+		}
+		inRange, err := query.InRange(event)
+		if err != nil {
+			return nil, err
+		}
+		if inRange {
+			hits = append(hits, storage.SearchHit{Event: event, Rank: rank, Snippet: storage.Snippet(event, terms)})
+		}
+	}
+	storage.SortHits(hits)
+	if query.Limit > 0 && len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+func (s *MemStorage) FindEventsToNotify(ctx context.Context, from, to time.Time) ([]storage.Event, error) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+	var resultEvents []storage.Event
+	for _, event := range s.store {
+		occurrences, err := event.OccurrencesToNotify(from, to)
+		if err != nil {
+			return nil, err
+		}
+		resultEvents = append(resultEvents, occurrences...)
+	}
+	return resultEvents, nil
+}
+
+func (s *MemStorage) FindEventsBefore(ctx context.Context, t time.Time) ([]storage.Event, error) {
 	s.rw.RLock()
 	defer s.rw.RUnlock()
 	var resultEvents []storage.Event
 	for _, event := range s.store {
-		if isEventInsideTimeInterval(intervalStart, intervalEnd, event.StartTime, event.EndTime) {
+		ended, err := event.EndedBefore(t)
+		if err != nil {
+			return nil, err
+		}
+		if ended {
 			resultEvents = append(resultEvents, event)
 		}
 	}
 	return resultEvents, nil
 }
 
-func (s *MemStorage) FindEventsByID(ctx context.Context, eventIDs ...string) ([]storage.Event, error) {
+func (s *MemStorage) DeleteEventsBefore(ctx context.Context, t time.Time) (int64, error) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	var deleted int64
+	for eventID, event := range s.store {
+		ended, err := event.EndedBefore(t)
+		if err != nil {
+			return deleted, err
+		}
+		if ended {
+			s.remove(eventID)
+			s.dropAttendees(eventID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// FindEventsByID - finds events of the user as owner or attendee, declined invitations are found as well.
+func (s *MemStorage) FindEventsByID(ctx context.Context, userID string, eventIDs ...string) ([]storage.Event, error) {
 	s.rw.RLock()
 	defer s.rw.RUnlock()
 
 	var resultEvents []storage.Event
 	for _, eventID := range eventIDs {
-		event, ok := s.store[eventID]
-		if ok {
-			resultEvents = append(resultEvents, event)
+		if s.visible(userID, eventID) {
+			resultEvents = append(resultEvents, s.store[eventID])
 		}
 	}
 	return resultEvents, nil
 }
 
-func (s *MemStorage) Size(ctx context.Context) int64 {
+// SaveAttendee - adds attendee to the event or replaces the existing one with the same user ID.
+func (s *MemStorage) SaveAttendee(ctx context.Context, attendee storage.Attendee) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	if _, ok := s.store[attendee.EventID]; !ok {
+		return storage.ErrEventNotFound
+	}
+	eventAttendees, ok := s.attendees[attendee.EventID]
+	if !ok {
+		eventAttendees = make(map[string]storage.Attendee)
+		s.attendees[attendee.EventID] = eventAttendees
+	}
+	eventAttendees[attendee.UserID] = attendee
+	addToIndex(s.attended, attendee.UserID, attendee.EventID)
+	return nil
+}
+
+func (s *MemStorage) DeleteAttendee(ctx context.Context, eventID, userID string) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	if _, ok := s.attendees[eventID][userID]; !ok {
+		return storage.ErrAttendeeNotFound
+	}
+	delete(s.attendees[eventID], userID)
+	if len(s.attendees[eventID]) == 0 {
+		delete(s.attendees, eventID)
+	}
+	removeFromIndex(s.attended, userID, eventID)
+	return nil
+}
+
+// FindAttendees - finds attendees of the events ordered by event ID and user ID.
+func (s *MemStorage) FindAttendees(ctx context.Context, eventIDs ...string) ([]storage.Attendee, error) {
 	s.rw.RLock()
 	defer s.rw.RUnlock()
-	return int64(len(s.store))
+	result := make([]storage.Attendee, 0)
+	found := make(map[string]bool, len(eventIDs))
+	for _, eventID := range eventIDs {
+		if found[eventID] {
+			continue
+		}
+		found[eventID] = true
+		for _, attendee := range s.attendees[eventID] {
+			result = append(result, attendee)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].EventID != result[j].EventID {
+			return result[i].EventID < result[j].EventID
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
 }
 
-// Checks whether the event inside time interval
-// Examples below:
-// intervalStart eventStart eventEnd intervalEnd - is OK.
-// eventStart intervalStart eventEnd intervalEnd - is OK.
-// eventStart intervalStart intervalEnd eventEnd - is OK.
-// intervalStart eventStart intervalEnd eventEnd - is OK.
-// intervalStart intervalEnd eventStart eventEnd - not OK.
-// eventStart eventEnd intervalStart intervalEnd - not OK.
-func isEventInsideTimeInterval(intervalStart, intervalEnd, eventStart, eventEnd time.Time) bool {
-	// intervalEnd > eventStart && intervalStart < eventEnd
-	return intervalEnd.After(eventStart) && intervalStart.Before(eventEnd)
+// dropAttendees - removes attendees of the deleted event, must be called under write lock.
+func (s *MemStorage) dropAttendees(eventID string) {
+	for userID := range s.attendees[eventID] {
+		removeFromIndex(s.attended, userID, eventID)
+	}
+	delete(s.attendees, eventID)
+}
+
+func (s *MemStorage) AddCalendar(ctx context.Context, calendar storage.Calendar) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	s.calendars[calendar.ID] = calendar
+	return nil
+}
+
+// UpdateCalendar - updates calendar of calendar.OwnerID, storage.ErrCalendarNotFound is returned if the owner has no such calendar.
+func (s *MemStorage) UpdateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	if s.calendars[calendar.ID].OwnerID != calendar.OwnerID {
+		return storage.ErrCalendarNotFound
+	}
+	s.calendars[calendar.ID] = calendar
+	return nil
+}
+
+// DeleteCalendar - deletes calendar without events, storage.ErrCalendarNotEmpty is returned if it has some.
+func (s *MemStorage) DeleteCalendar(ctx context.Context, ownerID, calendarID string) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	calendar, ok := s.calendars[calendarID]
+	if !ok || calendar.OwnerID != ownerID {
+		return storage.ErrCalendarNotFound
+	}
+	for eventID := range s.owners[ownerID] {
+		if s.store[eventID].CalendarID == calendarID {
+			return storage.ErrCalendarNotEmpty
+		}
+	}
+	delete(s.calendars, calendarID)
+	return nil
+}
+
+// FindCalendars - finds calendars of the owner ordered by name.
+func (s *MemStorage) FindCalendars(ctx context.Context, ownerID string) ([]storage.Calendar, error) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+	var result []storage.Calendar
+	for _, calendar := range s.calendars {
+		if calendar.OwnerID == ownerID {
+			result = append(result, calendar)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (s *MemStorage) SaveUserSettings(ctx context.Context, settings storage.UserSettings) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	s.settings[settings.UserID] = settings
+	return nil
+}
+
+// FindUserSettings - returns empty settings of the user if the user has not saved any.
+func (s *MemStorage) FindUserSettings(ctx context.Context, userID string) (storage.UserSettings, error) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+	if settings, ok := s.settings[userID]; ok {
+		return settings, nil
+	}
+	return storage.UserSettings{UserID: userID}, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemStorage) SaveNotificationStatus(ctx context.Context, status storage.NotificationStatus) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	s.statuses[notificationKey(status.EventID, status.StartTime)] = status
+	return nil
+}
+
+func (s *MemStorage) FindNotificationStatuses(ctx context.Context, eventID string) ([]storage.NotificationStatus, error) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+	var result []storage.NotificationStatus
+	for _, status := range s.statuses {
+		if status.EventID == eventID {
+			result = append(result, status)
+		}
+	}
+	return result, nil
+}
+
+// notification is identified by event and its start time, so rescheduled event is notified again.
+func notificationKey(eventID string, startTime time.Time) string {
+	return eventID + "/" + strconv.FormatInt(startTime.UnixNano(), 10)
+}
+
+func (s *MemStorage) Size(ctx context.Context) int64 {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+	return int64(len(s.store))
 }
 
 func NewMemStorage() *MemStorage {
-	return &MemStorage{store: make(map[string]storage.Event)}
+	return &MemStorage{
+		store:     make(map[string]storage.Event),
+		owners:    make(map[string]map[string]struct{}),
+		words:     make(map[string]map[string]struct{}),
+		statuses:  make(map[string]storage.NotificationStatus),
+		attendees: make(map[string]map[string]storage.Attendee),
+		attended:  make(map[string]map[string]struct{}),
+		calendars: make(map[string]storage.Calendar),
+		settings:  make(map[string]storage.UserSettings),
+	}
 }
@@ -28,10 +28,11 @@ func (s *memStorageSuite) SetupTest() {
 
 func (s *memStorageSuite) TestEmpty() {
 	s.Require().Equal(int64(0), s.storage.Size(s.ctx))
-	events, err := s.storage.FindEventsInInterval(s.ctx, time.Now().AddDate(-1, 0, 0), time.Now().AddDate(1, 0, 0))
+	ownerID := faker.UUIDHyphenated()
+	events, err := s.storage.FindEventsInInterval(s.ctx, ownerID, time.Now().AddDate(-1, 0, 0), time.Now().AddDate(1, 0, 0))
 	s.Require().NoError(err)
 	s.Require().Empty(events)
-	events, err = s.storage.FindEventsByID(s.ctx, []string{"1", "2", "3", "4", "5"}...)
+	events, err = s.storage.FindEventsByID(s.ctx, ownerID, []string{"1", "2", "3", "4", "5"}...)
 	s.Require().NoError(err)
 	s.Require().Empty(events)
 }
@@ -60,41 +61,67 @@ func (s *memStorageSuite) TestUpdateEvent() {
 	err = s.storage.UpdateEvent(s.ctx, testEvent)
 	s.Require().NoError(err)
 
-	foundEvents, err := s.storage.FindEventsByID(s.ctx, testEvent.ID)
+	foundEvents, err := s.storage.FindEventsByID(s.ctx, testEvent.OwnerID, testEvent.ID)
 	s.Require().NoError(err)
 	s.Require().Equal(1, len(foundEvents))
 	s.Require().Equal("some new title", foundEvents[0].Title)
+	s.Require().Equal(testEvent.Version+1, foundEvents[0].Version)
+
+	// update based on the previous version is rejected
+	testEvent.Title = "lost update"
+	s.Require().ErrorIs(s.storage.UpdateEvent(s.ctx, testEvent), storage.ErrVersionConflict)
 }
 
 func (s *memStorageSuite) TestDeleteEvent() {
 	var testEvent storage.Event
-	var addedEventsIds []string
+	var addedEvents []storage.Event
 
 	// add first element
 	err := faker.FakeData(&testEvent)
 	s.Require().NoError(err)
 	err = s.storage.AddEvent(s.ctx, testEvent)
 	s.Require().NoError(err)
-	addedEventsIds = append(addedEventsIds, testEvent.ID)
+	addedEvents = append(addedEvents, testEvent)
 
 	// add second element
 	err = faker.FakeData(&testEvent)
 	s.Require().NoError(err)
 	err = s.storage.AddEvent(s.ctx, testEvent)
 	s.Require().NoError(err)
-	addedEventsIds = append(addedEventsIds, testEvent.ID)
+	addedEvents = append(addedEvents, testEvent)
 
 	// try to delete not existing el
-	err = s.storage.DeleteEvent(s.ctx, "123456")
+	err = s.storage.DeleteEvent(s.ctx, testEvent.OwnerID, "123456")
 	s.Require().ErrorIs(err, storage.ErrEventNotFound)
 
-	for _, eventID := range addedEventsIds {
-		err := s.storage.DeleteEvent(s.ctx, eventID)
+	for _, event := range addedEvents {
+		err := s.storage.DeleteEvent(s.ctx, event.OwnerID, event.ID)
 		s.Require().NoError(err)
 	}
 	s.Require().Equal(int64(0), s.storage.Size(s.ctx))
 }
 
+func (s *memStorageSuite) TestEventsOfAnotherOwner() {
+	var testEvent storage.Event
+	s.Require().NoError(faker.FakeData(&testEvent))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, testEvent))
+	anotherOwnerID := faker.UUIDHyphenated()
+
+	events, err := s.storage.FindEventsByID(s.ctx, anotherOwnerID, testEvent.ID)
+	s.Require().NoError(err)
+	s.Require().Empty(events)
+
+	updated := testEvent
+	updated.OwnerID = anotherOwnerID
+	updated.Title = "stolen event"
+	s.Require().ErrorIs(s.storage.UpdateEvent(s.ctx, updated), storage.ErrEventNotFound)
+	s.Require().ErrorIs(s.storage.DeleteEvent(s.ctx, anotherOwnerID, testEvent.ID), storage.ErrEventNotFound)
+
+	events, err = s.storage.FindEventsByID(s.ctx, testEvent.OwnerID, testEvent.ID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Event{testEvent}, events)
+}
+
 func (s *memStorageSuite) TestMemStorageBaseUsageConcurrently() {
 	var wg sync.WaitGroup
 	concurrentUsers := 4
@@ -129,7 +156,7 @@ func (s *memStorageSuite) TestMemStorageBaseUsageConcurrently() {
 				} else {
 					go func(testEvent storage.Event) {
 						defer wg.Done()
-						err := s.storage.DeleteEvent(s.ctx, testEvent.ID)
+						err := s.storage.DeleteEvent(s.ctx, testEvent.OwnerID, testEvent.ID)
 						s.Require().NoError(err)
 					}(testEvent)
 				}
@@ -142,19 +169,422 @@ func (s *memStorageSuite) TestMemStorageBaseUsageConcurrently() {
 
 func (s *memStorageSuite) TestFindEventsInInterval() {
 	numOfTestEvents := 5
+	ownerID := faker.UUIDHyphenated()
 	allAddedEvents := make([]storage.Event, 0, numOfTestEvents)
 	for i := 0; i < numOfTestEvents; i++ {
 		var testEvent storage.Event
 		err := faker.FakeData(&testEvent)
 		s.Require().NoError(err)
+		testEvent.OwnerID = ownerID
 		testEvent.StartTime = time.Now().AddDate(0, 0, i)
 		testEvent.EndTime = testEvent.StartTime.AddDate(0, 0, 1)
 		err = s.storage.AddEvent(context.Background(), testEvent)
 		s.Require().NoError(err)
 		allAddedEvents = append(allAddedEvents, testEvent)
 	}
-	events, err := s.storage.FindEventsInInterval(context.Background(), time.Now(), time.Now().AddDate(0, 0, numOfTestEvents))
+	// event of another owner
+	var testEvent storage.Event
+	s.Require().NoError(faker.FakeData(&testEvent))
+	testEvent.StartTime = time.Now().Add(time.Hour)
+	testEvent.EndTime = testEvent.StartTime.Add(time.Hour)
+	s.Require().NoError(s.storage.AddEvent(context.Background(), testEvent))
+
+	events, err := s.storage.FindEventsInInterval(context.Background(), ownerID, time.Now(), time.Now().AddDate(0, 0, numOfTestEvents))
 	s.Require().NoError(err)
 	s.Require().Equal(len(events), len(allAddedEvents))
 	s.Require().ElementsMatch(events, allAddedEvents)
 }
+
+func (s *memStorageSuite) TestFindEventsToNotify() {
+	now := time.Now()
+	addEvent := func(startTime time.Time, notifyBefore time.Duration) storage.Event {
+		var testEvent storage.Event
+		s.Require().NoError(faker.FakeData(&testEvent))
+		testEvent.StartTime = startTime
+		testEvent.EndTime = startTime.Add(time.Hour)
+		testEvent.NotifyBefore = notifyBefore
+		s.Require().NoError(s.storage.AddEvent(s.ctx, testEvent))
+		return testEvent
+	}
+	// notification time is before interval
+	addEvent(now.Add(time.Hour), 2*time.Hour)
+	// notification time is inside interval
+	inside := addEvent(now.Add(2*time.Hour), 90*time.Minute)
+	startsInside := addEvent(now.Add(30*time.Minute), 0)
+	// notification time is exactly at the interval end
+	addEvent(now.Add(2*time.Hour), time.Hour)
+
+	events, err := s.storage.FindEventsToNotify(s.ctx, now, now.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Require().ElementsMatch([]storage.Event{inside, startsInside}, events)
+}
+
+func (s *memStorageSuite) TestEventsBefore() {
+	now := time.Now()
+	addEvent := func(endTime time.Time) storage.Event {
+		var testEvent storage.Event
+		s.Require().NoError(faker.FakeData(&testEvent))
+		testEvent.StartTime = endTime.Add(-time.Hour)
+		testEvent.EndTime = endTime
+		s.Require().NoError(s.storage.AddEvent(s.ctx, testEvent))
+		return testEvent
+	}
+	old := addEvent(now.Add(-time.Hour))
+	// event which is still in progress must not be removed
+	addEvent(now.Add(time.Minute))
+	addEvent(now)
+
+	events, err := s.storage.FindEventsBefore(s.ctx, now)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch([]storage.Event{old}, events)
+
+	deleted, err := s.storage.DeleteEventsBefore(s.ctx, now)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1), deleted)
+	s.Require().Equal(int64(2), s.storage.Size(s.ctx))
+}
+
+func (s *memStorageSuite) TestRecurringEvents() {
+	// 2021-09-06 is Monday
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	var standup storage.Event
+	s.Require().NoError(faker.FakeData(&standup))
+	standup.StartTime = start
+	standup.EndTime = start.Add(15 * time.Minute)
+	standup.RecurrenceRule = "FREQ=WEEKLY;COUNT=4;BYDAY=MO,TH"
+	standup.ExceptionDates = storage.ExceptionDates{start.AddDate(0, 0, 3)}
+	s.Require().NoError(s.storage.AddEvent(s.ctx, standup))
+
+	events, err := s.storage.FindEventsInInterval(s.ctx, standup.OwnerID, start, start.AddDate(0, 1, 0))
+	s.Require().NoError(err)
+	starts := make([]time.Time, 0, len(events))
+	for _, event := range events {
+		s.Require().Equal(standup.ID, event.ID)
+		s.Require().Equal(15*time.Minute, event.EndTime.Sub(event.StartTime))
+		starts = append(starts, event.StartTime)
+	}
+	// the second occurrence is excluded
+	s.Require().ElementsMatch([]time.Time{start, start.AddDate(0, 0, 7), start.AddDate(0, 0, 10)}, starts)
+
+	// interval ends in the middle of the second week occurrence
+	events, err = s.storage.FindEventsInInterval(s.ctx, standup.OwnerID, start.AddDate(0, 0, 7).Add(5*time.Minute), start.AddDate(0, 0, 8))
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Require().True(start.AddDate(0, 0, 7).Equal(events[0].StartTime))
+
+	events, err = s.storage.FindEventsToNotify(s.ctx, start.AddDate(0, 0, 10), start.AddDate(0, 0, 11))
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Require().True(start.AddDate(0, 0, 10).Equal(events[0].StartTime))
+
+	ended, err := s.storage.FindEventsBefore(s.ctx, start.AddDate(0, 0, 10))
+	s.Require().NoError(err)
+	s.Require().Empty(ended)
+	ended, err = s.storage.FindEventsBefore(s.ctx, start.AddDate(0, 0, 11))
+	s.Require().NoError(err)
+	s.Require().Len(ended, 1)
+}
+
+func (s *memStorageSuite) TestRecurringEventsTimeZone() {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	s.Require().NoError(err)
+	// 2021-10-18 is Monday, DST in Berlin ends on 2021-10-31
+	start := time.Date(2021, time.October, 18, 0, 30, 0, 0, berlin)
+	// start time is stored in UTC as database returns it and with fixed offset as it is parsed from JSON,
+	// weekly series at 00:30 in Berlin starts on Sunday in both of them
+	for _, loc := range []*time.Location{time.UTC, time.FixedZone("", 2*60*60)} {
+		var event storage.Event
+		s.Require().NoError(faker.FakeData(&event))
+		event.StartTime = start.In(loc)
+		event.EndTime = start.Add(time.Hour).In(loc)
+		event.RecurrenceRule = "FREQ=WEEKLY;BYDAY=MO"
+		event.ExceptionDates = nil
+		event.TimeZone = "Europe/Berlin"
+		s.Require().NoError(s.storage.AddEvent(s.ctx, event))
+
+		events, err := s.storage.FindEventsInInterval(s.ctx, event.OwnerID, start, start.AddDate(0, 0, 28))
+		s.Require().NoError(err)
+		s.Require().Len(events, 4)
+		for _, occurrence := range events {
+			local := occurrence.StartTime.In(berlin)
+			s.Require().Equal(time.Monday, local.Weekday(), local)
+			s.Require().Equal(0, local.Hour(), local)
+			s.Require().Equal(30, local.Minute(), local)
+			s.Require().Equal(time.Hour, occurrence.EndTime.Sub(occurrence.StartTime))
+		}
+	}
+}
+
+func (s *memStorageSuite) TestSearchEvents() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	ownerID := faker.UUIDHyphenated()
+	add := func(title, description string, startTime time.Time, rule string) storage.Event {
+		event := storage.Event{
+			ID:             faker.UUIDHyphenated(),
+			OwnerID:        ownerID,
+			Title:          title,
+			Description:    description,
+			StartTime:      startTime,
+			EndTime:        startTime.Add(30 * time.Minute),
+			RecurrenceRule: rule,
+		}
+		s.Require().NoError(s.storage.AddEvent(s.ctx, event))
+		return event
+	}
+	review := add("Code review", "Review of the search feature", start, "")
+	planning := add("Sprint planning", "Review backlog before the sprint", start.Add(time.Hour), "")
+	standup := add("Standup", "Daily sync, no code review", start.Add(-time.Hour), "FREQ=DAILY;COUNT=10")
+	add("Lunch", "", start.Add(2*time.Hour), "")
+
+	hits, err := s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: ownerID, Text: "REVIEW"})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 3)
+	// word found in title and description outranks the ones found only in title or description
+	s.Require().Equal(review.ID, hits[0].Event.ID)
+	s.Require().Equal(standup.ID, hits[1].Event.ID)
+	s.Require().Equal(planning.ID, hits[2].Event.ID)
+	s.Require().Equal("Code <b>review</b> <b>Review</b> of the search feature", hits[0].Snippet)
+
+	// all words must be found
+	hits, err = s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: ownerID, Text: "code review"})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 2)
+
+	// the last standup occurrence is inside the range
+	hits, err = s.storage.SearchEvents(s.ctx, storage.SearchQuery{
+		OwnerID: ownerID,
+		Text:    "review",
+		From:    start.AddDate(0, 0, 8),
+		To:      start.AddDate(0, 0, 9),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 1)
+	s.Require().Equal(standup.ID, hits[0].Event.ID)
+
+	hits, err = s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: ownerID, Text: "review", Limit: 1})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 1)
+
+	hits, err = s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: faker.UUIDHyphenated(), Text: "review"})
+	s.Require().NoError(err)
+	s.Require().Empty(hits)
+
+	// deleted and updated events are removed from the index
+	s.Require().NoError(s.storage.DeleteEvent(s.ctx, ownerID, review.ID))
+	planning.Title, planning.Description = "Sprint planning", ""
+	s.Require().NoError(s.storage.UpdateEvent(s.ctx, planning))
+	hits, err = s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: ownerID, Text: "review"})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 1)
+	s.Require().Equal(standup.ID, hits[0].Event.ID)
+
+	// event text is escaped, so only highlight tags are markup
+	add("<script>alert(1)</script>", "XSS & review", start.AddDate(0, 1, 0), "")
+	hits, err = s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: ownerID, Text: "xss"})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 1)
+	s.Require().Equal("&lt;script&gt;alert(1)&lt;/script&gt; <b>XSS</b> &amp; review", hits[0].Snippet)
+}
+
+func (s *memStorageSuite) TestDateBusy() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	newEvent := func(ownerID string, startTime time.Time, duration time.Duration) storage.Event {
+		var testEvent storage.Event
+		s.Require().NoError(faker.FakeData(&testEvent))
+		testEvent.OwnerID = ownerID
+		testEvent.StartTime = startTime
+		testEvent.EndTime = startTime.Add(duration)
+		return testEvent
+	}
+	ownerID := faker.UUIDHyphenated()
+	meeting := newEvent(ownerID, start, time.Hour)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, meeting))
+
+	// overlapping event of the same owner
+	err := s.storage.AddEvent(s.ctx, newEvent(ownerID, start.Add(30*time.Minute), time.Hour))
+	s.Require().ErrorIs(err, storage.ErrDateBusy)
+	// event which starts when another one ends
+	s.Require().NoError(s.storage.AddEvent(s.ctx, newEvent(ownerID, start.Add(time.Hour), time.Hour)))
+	// overlapping event of another owner
+	s.Require().NoError(s.storage.AddEvent(s.ctx, newEvent(faker.UUIDHyphenated(), start, time.Hour)))
+
+	// event doesn't conflict with itself on update
+	meeting.EndTime = meeting.EndTime.Add(-30 * time.Minute)
+	s.Require().NoError(s.storage.UpdateEvent(s.ctx, meeting))
+	meeting.Version++
+	meeting.EndTime = meeting.EndTime.Add(time.Hour)
+	s.Require().ErrorIs(s.storage.UpdateEvent(s.ctx, meeting), storage.ErrDateBusy)
+
+	// the next week occurrence of recurring event overlaps the meeting
+	standup := newEvent(ownerID, start.AddDate(0, 0, -7).Add(15*time.Minute), 15*time.Minute)
+	standup.RecurrenceRule = "FREQ=WEEKLY"
+	s.Require().ErrorIs(s.storage.AddEvent(s.ctx, standup), storage.ErrDateBusy)
+	standup.ExceptionDates = storage.ExceptionDates{standup.StartTime.AddDate(0, 0, 7)}
+	s.Require().NoError(s.storage.AddEvent(s.ctx, standup))
+}
+
+func (s *memStorageSuite) TestAttendees() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	var event storage.Event
+	s.Require().NoError(faker.FakeData(&event))
+	event.StartTime, event.EndTime = start, start.Add(time.Hour)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, event))
+	attendeeID := faker.UUIDHyphenated()
+
+	err := s.storage.SaveAttendee(s.ctx, storage.Attendee{EventID: "not existing id here", UserID: attendeeID})
+	s.Require().ErrorIs(err, storage.ErrEventNotFound)
+	attendee := storage.Attendee{EventID: event.ID, UserID: attendeeID, Role: storage.AttendeeRoleViewer, Status: storage.RSVPNeedsAction}
+	s.Require().NoError(s.storage.SaveAttendee(s.ctx, attendee))
+
+	// attended event is found for the attendee
+	found, err := s.storage.FindEventsByID(s.ctx, attendeeID, event.ID)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	found, err = s.storage.FindEventsInInterval(s.ctx, attendeeID, start, start.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+
+	// attendees are kept while event is updated
+	event.Title = "some new title"
+	s.Require().NoError(s.storage.UpdateEvent(s.ctx, event))
+	attendees, err := s.storage.FindAttendees(s.ctx, event.ID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Attendee{attendee}, attendees)
+	// attendees of several events are found at once, unknown events have none
+	attendees, err = s.storage.FindAttendees(s.ctx, faker.UUIDHyphenated(), event.ID, event.ID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Attendee{attendee}, attendees)
+
+	// declined event is not listed, but it is still found by ID
+	attendee.Status = storage.RSVPDeclined
+	s.Require().NoError(s.storage.SaveAttendee(s.ctx, attendee))
+	found, err = s.storage.FindEventsInInterval(s.ctx, attendeeID, start, start.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Require().Empty(found)
+	found, err = s.storage.FindEventsByID(s.ctx, attendeeID, event.ID)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+
+	s.Require().NoError(s.storage.DeleteAttendee(s.ctx, event.ID, attendeeID))
+	s.Require().ErrorIs(s.storage.DeleteAttendee(s.ctx, event.ID, attendeeID), storage.ErrAttendeeNotFound)
+	found, err = s.storage.FindEventsByID(s.ctx, attendeeID, event.ID)
+	s.Require().NoError(err)
+	s.Require().Empty(found)
+
+	// attendees are deleted with the event
+	s.Require().NoError(s.storage.SaveAttendee(s.ctx, attendee))
+	s.Require().NoError(s.storage.DeleteEvent(s.ctx, event.OwnerID, event.ID))
+	attendees, err = s.storage.FindAttendees(s.ctx, event.ID)
+	s.Require().NoError(err)
+	s.Require().Empty(attendees)
+	s.Require().Empty(s.storage.attended)
+}
+
+func (s *memStorageSuite) TestCalendars() {
+	ownerID := faker.UUIDHyphenated()
+	work := storage.Calendar{ID: faker.UUIDHyphenated(), OwnerID: ownerID, Name: "Work"}
+	home := storage.Calendar{ID: faker.UUIDHyphenated(), OwnerID: ownerID, Name: "Home", Color: "#00ff00"}
+	s.Require().NoError(s.storage.AddCalendar(s.ctx, work))
+	s.Require().NoError(s.storage.AddCalendar(s.ctx, home))
+	calendars, err := s.storage.FindCalendars(s.ctx, ownerID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Calendar{home, work}, calendars)
+
+	// calendars of another owner are not found
+	other := work
+	other.OwnerID = faker.UUIDHyphenated()
+	s.Require().ErrorIs(s.storage.UpdateCalendar(s.ctx, other), storage.ErrCalendarNotFound)
+	s.Require().ErrorIs(s.storage.DeleteCalendar(s.ctx, other.OwnerID, work.ID), storage.ErrCalendarNotFound)
+
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	newEvent := func(calendarID string, startTime time.Time) storage.Event {
+		var testEvent storage.Event
+		s.Require().NoError(faker.FakeData(&testEvent))
+		testEvent.OwnerID = ownerID
+		testEvent.CalendarID = calendarID
+		testEvent.StartTime, testEvent.EndTime = startTime, startTime.Add(time.Hour)
+		return testEvent
+	}
+	meeting := newEvent(work.ID, start)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, meeting))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, newEvent(home.ID, start.Add(time.Hour))))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, newEvent("", start.Add(2*time.Hour))))
+
+	found, err := s.storage.FindEventsInInterval(s.ctx, ownerID, start, start.Add(24*time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(found, 3)
+	found, err = s.storage.FindEventsInInterval(s.ctx, ownerID, start, start.Add(24*time.Hour), work.ID)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Require().Equal(meeting.ID, found[0].ID)
+	found, err = s.storage.FindEventsInInterval(s.ctx, ownerID, start, start.Add(24*time.Hour), work.ID, home.ID)
+	s.Require().NoError(err)
+	s.Require().Len(found, 2)
+
+	// calendar with events is not deleted
+	s.Require().ErrorIs(s.storage.DeleteCalendar(s.ctx, ownerID, work.ID), storage.ErrCalendarNotEmpty)
+	s.Require().NoError(s.storage.DeleteEvent(s.ctx, ownerID, meeting.ID))
+	s.Require().NoError(s.storage.DeleteCalendar(s.ctx, ownerID, work.ID))
+	calendars, err = s.storage.FindCalendars(s.ctx, ownerID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Calendar{home}, calendars)
+}
+
+func (s *memStorageSuite) TestApplyBatch() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	ownerID := faker.UUIDHyphenated()
+	newEvent := func(startTime time.Time) storage.Event {
+		var testEvent storage.Event
+		s.Require().NoError(faker.FakeData(&testEvent))
+		testEvent.OwnerID = ownerID
+		testEvent.StartTime, testEvent.EndTime = startTime, startTime.Add(time.Hour)
+		testEvent.Version = 1
+		return testEvent
+	}
+	meeting := newEvent(start)
+	lunch := newEvent(start.Add(2 * time.Hour))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, meeting))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, lunch))
+	attendee := storage.Attendee{EventID: lunch.ID, UserID: faker.UUIDHyphenated(), Role: storage.AttendeeRoleViewer}
+	s.Require().NoError(s.storage.SaveAttendee(s.ctx, attendee))
+
+	// the last event overlaps the first one created by the same batch, so the whole batch is undone
+	created := newEvent(start.Add(4 * time.Hour))
+	updated := meeting
+	updated.Title = "some new title"
+	err := s.storage.ApplyBatch(s.ctx, []storage.BatchOperation{
+		{Action: storage.BatchCreate, Event: created},
+		{Action: storage.BatchUpdate, Event: updated},
+		{Action: storage.BatchDelete, Event: lunch},
+		{Action: storage.BatchCreate, Event: newEvent(start.Add(4*time.Hour + 30*time.Minute))},
+	})
+	var batchErr *storage.BatchError
+	s.Require().ErrorAs(err, &batchErr)
+	s.Require().Equal(3, batchErr.Index)
+	s.Require().ErrorIs(err, storage.ErrDateBusy)
+
+	found, err := s.storage.FindEventsByID(s.ctx, ownerID, meeting.ID, lunch.ID, created.ID)
+	s.Require().NoError(err)
+	s.Require().Len(found, 2)
+	s.Require().True(meeting.IsEqual(s.storage.store[meeting.ID]))
+	s.Require().Equal(int64(1), s.storage.store[meeting.ID].Version)
+	attendees, err := s.storage.FindAttendees(s.ctx, lunch.ID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Attendee{attendee}, attendees)
+	found, err = s.storage.FindEventsInInterval(s.ctx, attendee.UserID, start, start.Add(24*time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	hits, err := s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: ownerID, Text: "some new title"})
+	s.Require().NoError(err)
+	s.Require().Empty(hits)
+
+	// deleted event frees its time for the event created after it
+	s.Require().NoError(s.storage.ApplyBatch(s.ctx, []storage.BatchOperation{
+		{Action: storage.BatchUpdate, Event: updated},
+		{Action: storage.BatchDelete, Event: lunch},
+		{Action: storage.BatchCreate, Event: newEvent(lunch.StartTime)},
+	}))
+	s.Require().Equal(int64(2), s.storage.Size(s.ctx))
+	s.Require().Equal("some new title", s.storage.store[meeting.ID].Title)
+	s.Require().Empty(s.storage.attended)
+}
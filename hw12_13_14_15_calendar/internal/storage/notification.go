@@ -0,0 +1,37 @@
+package storage
+
+import "time"
+
+// Notification - временная сущность, в БД не хранится, складывается в очередь для рассыльщика.
+type Notification struct {
+	// ID события;
+	EventID string `json:"event_id"`
+	// Заголовок события;
+	Title string `json:"title"`
+	// Дата события;
+	StartTime time.Time `json:"start_time"`
+	// Пользователь, которому отправлять.
+	OwnerID string `json:"owner_id"`
+}
+
+func NewNotification(event Event) Notification {
+	return Notification{EventID: event.ID, Title: event.Title, StartTime: event.StartTime, OwnerID: event.OwnerID}
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusSent   DeliveryStatus = "sent"
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// NotificationStatus - result of the notification delivery made by sender.
+type NotificationStatus struct {
+	EventID   string         `db:"event_id" json:"event_id"`
+	StartTime time.Time      `db:"start_time" json:"start_time"`
+	OwnerID   string         `db:"owner_id" json:"owner_id"`
+	Status    DeliveryStatus `db:"status" json:"status"`
+	// Error - delivery error description, empty if notification was sent
+	Error     string    `db:"error" json:"error"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// TitleWeight and DescriptionWeight - weights of words found in event title and description,
+	// the same as default weights of PostgreSQL ts_rank for A and B labels.
+	TitleWeight       = 1.0
+	DescriptionWeight = 0.4
+	// HighlightStart and HighlightStop - marks around matched words in search snippets.
+	HighlightStart = "<b>"
+	HighlightStop  = "</b>"
+	// SnippetWords - max number of words in search snippet.
+	SnippetWords = 20
+	// snippetWordsBeforeMatch - words kept in snippet before the first matched one
+	snippetWordsBeforeMatch = 5
+)
+
+// SearchQuery - full text search conditions.
+// Events overlapping [From, To) range are searched, zero From or To means the range is not bounded from that side.
+type SearchQuery struct {
+	OwnerID string
+	// Text - words which must be present in event title or description, words order is not important
+	Text     string
+	From, To time.Time
+	// Limit - max number of returned hits
+	Limit int
+}
+
+// Terms - lowercased unique words of the query text.
+func (q SearchQuery) Terms() []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range Tokenize(q.Text) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// InRange - checks whether the event (any occurrence for recurring event) overlaps the query range.
+func (q SearchQuery) InRange(event Event) (bool, error) {
+	return event.Overlaps(q.From, q.To)
+}
+
+// SearchHit - event found by full text search.
+type SearchHit struct {
+	Event Event `json:"event"`
+	// Rank - relevance of the event, ranks of different storages are not comparable
+	Rank float64 `json:"rank"`
+	// Snippet - HTML escaped fragment of event title and description,
+	// matched words are wrapped into HighlightStart and HighlightStop
+	Snippet string `json:"snippet"`
+}
+
+// SortHits - orders hits by rank descending, hits with equal rank are ordered by event start time and ID.
+func SortHits(hits []SearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		e1, e2 := hits[i].Event, hits[j].Event
+		switch {
+		case hits[i].Rank != hits[j].Rank:
+			return hits[i].Rank > hits[j].Rank
+		case !e1.StartTime.Equal(e2.StartTime):
+			return e1.StartTime.Before(e2.StartTime)
+		default:
+			return e1.ID < e2.ID
+		}
+	})
+}
+
+// Tokenize - splits text into lowercased words, words are sequences of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Rank - weighted frequency of the terms in event title and description,
+// zero rank is returned if any of the terms is not found.
+func Rank(event Event, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	frequencies := make(map[string]float64)
+	for _, word := range Tokenize(event.Title) {
+		frequencies[word] += TitleWeight
+	}
+	for _, word := range Tokenize(event.Description) {
+		frequencies[word] += DescriptionWeight
+	}
+	var rank float64
+	for _, term := range terms {
+		if frequencies[term] == 0 {
+			return 0
+		}
+		rank += frequencies[term]
+	}
+	return rank
+}
+
+// Snippet - returns up to SnippetWords words of event title and description around the first matched term,
+// all matched words are highlighted. Words are HTML escaped, so the snippet markup is only the highlight one.
+func Snippet(event Event, terms []string) string {
+	text := event.Title
+	if event.Description != "" {
+		text += " " + event.Description
+	}
+	words := strings.Fields(text)
+	matched := make([]bool, len(words))
+	first := -1
+	for i, word := range words {
+		for _, token := range Tokenize(word) {
+			if containsTerm(terms, token) {
+				matched[i] = true
+			}
+		}
+		if matched[i] && first < 0 {
+			first = i
+		}
+	}
+
+	start := 0
+	if first > snippetWordsBeforeMatch {
+		start = first - snippetWordsBeforeMatch
+	}
+	end := start + SnippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+	snippet := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		word := html.EscapeString(words[i])
+		if matched[i] {
+			word = HighlightStart + word + HighlightStop
+		}
+		snippet = append(snippet, word)
+	}
+	return strings.Join(snippet, " ")
+}
+
+func containsTerm(terms []string, token string) bool {
+	for _, term := range terms {
+		if term == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+package storage
+
+// UserSettings - настройки пользователя, у пользователя без сохраненных настроек все настройки пустые.
+type UserSettings struct {
+	// ID пользователя;
+	UserID string `db:"user_id" json:"user_id"`
+	// Часовой пояс пользователя по умолчанию (IANA), опционально.
+	TimeZone string `db:"time_zone" json:"time_zone"`
+}
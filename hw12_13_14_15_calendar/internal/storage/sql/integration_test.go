@@ -0,0 +1,320 @@
+//go:build integration
+// +build integration
+
+package sqlstorage
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/bxcodec/faker/v3"
+	"github.com/stretchr/testify/suite"
+)
+
+// testDSNEnv - env variable with DSN of the test database, all migrations must be applied to it, e.g.
+// goose -dir migrations postgres "$CALENDAR_TEST_DSN" up
+// Tables of the database are truncated before every test.
+const testDSNEnv = "CALENDAR_TEST_DSN"
+
+func TestDBStorage(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	suite.Run(t, &dbStorageSuite{dsn: dsn})
+}
+
+type dbStorageSuite struct {
+	suite.Suite
+	dsn     string
+	storage *DBStorage
+	ctx     context.Context
+}
+
+func (s *dbStorageSuite) SetupSuite() {
+	s.ctx = context.Background()
+	s.storage = NewDBStorage()
+	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Second)
+	defer cancel()
+	s.Require().NoError(s.storage.Connect(ctx, s.dsn))
+}
+
+func (s *dbStorageSuite) TearDownSuite() {
+	s.Require().NoError(s.storage.Close())
+}
+
+func (s *dbStorageSuite) SetupTest() {
+	_, err := s.storage.db.ExecContext(s.ctx, "TRUNCATE events, event_attendees, calendars, notification_statuses, user_settings")
+	s.Require().NoError(err)
+}
+
+func (s *dbStorageSuite) newEvent(ownerID string, start time.Time) storage.Event {
+	var event storage.Event
+	s.Require().NoError(faker.FakeData(&event))
+	event.OwnerID = ownerID
+	event.StartTime, event.EndTime = start, start.Add(time.Hour)
+	event.Version = 1
+	return event
+}
+
+func (s *dbStorageSuite) TestAddEvent() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	ownerID := faker.UUIDHyphenated()
+	event := s.newEvent(ownerID, start)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, event))
+	s.Require().ErrorIs(s.storage.AddEvent(s.ctx, event), storage.ErrEventAlreadyExists)
+	s.Require().ErrorIs(s.storage.AddEvent(s.ctx, s.newEvent(ownerID, start.Add(30*time.Minute))), storage.ErrDateBusy)
+	// events of another owner and touching events don't conflict
+	s.Require().NoError(s.storage.AddEvent(s.ctx, s.newEvent(faker.UUIDHyphenated(), start)))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, s.newEvent(ownerID, event.EndTime)))
+
+	events, err := s.storage.FindEventsInInterval(s.ctx, ownerID, start.Add(-time.Hour), start.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Require().True(event.IsEqual(events[0]))
+
+	updated := event
+	updated.Title = "some new title"
+	s.Require().NoError(s.storage.UpdateEvent(s.ctx, updated))
+	s.Require().ErrorIs(s.storage.UpdateEvent(s.ctx, updated), storage.ErrVersionConflict)
+	updated.ID = faker.UUIDHyphenated()
+	s.Require().ErrorIs(s.storage.UpdateEvent(s.ctx, updated), storage.ErrEventNotFound)
+	found, err := s.storage.FindEventsByID(s.ctx, ownerID, event.ID)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Require().Equal("some new title", found[0].Title)
+	s.Require().Equal(int64(2), found[0].Version)
+
+	s.Require().NoError(s.storage.DeleteEvent(s.ctx, ownerID, event.ID))
+	s.Require().ErrorIs(s.storage.DeleteEvent(s.ctx, ownerID, event.ID), storage.ErrEventNotFound)
+}
+
+func (s *dbStorageSuite) TestRecurringEvents() {
+	// 2021-09-06 is Monday
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	standup := s.newEvent(faker.UUIDHyphenated(), start)
+	standup.EndTime = start.Add(15 * time.Minute)
+	standup.NotifyBefore = 10 * time.Minute
+	standup.RecurrenceRule = "FREQ=WEEKLY;COUNT=4;BYDAY=MO,TH"
+	standup.ExceptionDates = storage.ExceptionDates{start.AddDate(0, 0, 3)}
+	s.Require().NoError(s.storage.AddEvent(s.ctx, standup))
+	// the series is busy at its later occurrences only
+	s.Require().ErrorIs(s.storage.AddEvent(s.ctx, s.newEvent(standup.OwnerID, start.AddDate(0, 0, 7))), storage.ErrDateBusy)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, s.newEvent(standup.OwnerID, start.AddDate(0, 0, 3))))
+
+	events, err := s.storage.FindEventsInInterval(s.ctx, standup.OwnerID, start.AddDate(0, 0, 5), start.AddDate(0, 1, 0))
+	s.Require().NoError(err)
+	starts := make([]time.Time, 0, len(events))
+	for _, event := range events {
+		s.Require().Equal(standup.ID, event.ID)
+		starts = append(starts, event.StartTime.UTC())
+	}
+	s.Require().ElementsMatch([]time.Time{start.AddDate(0, 0, 7), start.AddDate(0, 0, 10)}, starts)
+
+	notifyAt := start.AddDate(0, 0, 10).Add(-standup.NotifyBefore)
+	events, err = s.storage.FindEventsToNotify(s.ctx, notifyAt, notifyAt.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Require().True(start.AddDate(0, 0, 10).Equal(events[0].StartTime))
+}
+
+func (s *dbStorageSuite) TestRecurringEventsTimeZone() {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	s.Require().NoError(err)
+	// 2021-10-18 is Monday, DST in Berlin ends on 2021-10-31,
+	// start time is read back in UTC, where the series at 00:30 in Berlin starts on Sunday
+	start := time.Date(2021, time.October, 18, 0, 30, 0, 0, berlin)
+	series := s.newEvent(faker.UUIDHyphenated(), start)
+	series.RecurrenceRule = "FREQ=WEEKLY;BYDAY=MO"
+	series.TimeZone = "Europe/Berlin"
+	s.Require().NoError(s.storage.AddEvent(s.ctx, series))
+
+	events, err := s.storage.FindEventsInInterval(s.ctx, series.OwnerID, start, start.AddDate(0, 0, 28))
+	s.Require().NoError(err)
+	s.Require().Len(events, 4)
+	for _, occurrence := range events {
+		s.Require().Equal("Europe/Berlin", occurrence.TimeZone)
+		local := occurrence.StartTime.In(berlin)
+		s.Require().Equal(time.Monday, local.Weekday(), local)
+		s.Require().Equal(0, local.Hour(), local)
+		s.Require().Equal(30, local.Minute(), local)
+	}
+}
+
+func (s *dbStorageSuite) TestDeleteEventsBefore() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	ownerID := faker.UUIDHyphenated()
+	past := s.newEvent(ownerID, start)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, past))
+	ended := s.newEvent(ownerID, start.Add(2*time.Hour))
+	ended.RecurrenceRule = "FREQ=DAILY;COUNT=2"
+	s.Require().NoError(s.storage.AddEvent(s.ctx, ended))
+	endless := s.newEvent(ownerID, start.Add(4*time.Hour))
+	endless.RecurrenceRule = "FREQ=DAILY"
+	s.Require().NoError(s.storage.AddEvent(s.ctx, endless))
+	future := s.newEvent(ownerID, start.AddDate(0, 0, 7))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, future))
+
+	before := start.AddDate(0, 0, 3)
+	old, err := s.storage.FindEventsBefore(s.ctx, before)
+	s.Require().NoError(err)
+	s.Require().Len(old, 2)
+	deleted, err := s.storage.DeleteEventsBefore(s.ctx, before)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(2), deleted)
+
+	found, err := s.storage.FindEventsByID(s.ctx, ownerID, past.ID, ended.ID, endless.ID, future.ID)
+	s.Require().NoError(err)
+	ids := make([]string, 0, len(found))
+	for _, event := range found {
+		ids = append(ids, event.ID)
+	}
+	s.Require().ElementsMatch([]string{endless.ID, future.ID}, ids)
+}
+
+func (s *dbStorageSuite) TestAttendees() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	event := s.newEvent(faker.UUIDHyphenated(), start)
+	s.Require().NoError(s.storage.AddEvent(s.ctx, event))
+	attendee := storage.Attendee{
+		EventID: event.ID,
+		UserID:  faker.UUIDHyphenated(),
+		Role:    storage.AttendeeRoleViewer,
+		Status:  storage.RSVPNeedsAction,
+	}
+	s.Require().NoError(s.storage.SaveAttendee(s.ctx, attendee))
+
+	events, err := s.storage.FindEventsInInterval(s.ctx, attendee.UserID, start, start.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+
+	// declined invitation is not listed, but the event is still found by ID
+	attendee.Status = storage.RSVPDeclined
+	s.Require().NoError(s.storage.SaveAttendee(s.ctx, attendee))
+	attendees, err := s.storage.FindAttendees(s.ctx, event.ID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Attendee{attendee}, attendees)
+	// attendees of several events are found at once, unknown events have none
+	attendees, err = s.storage.FindAttendees(s.ctx, faker.UUIDHyphenated(), event.ID, event.ID)
+	s.Require().NoError(err)
+	s.Require().Equal([]storage.Attendee{attendee}, attendees)
+	events, err = s.storage.FindEventsInInterval(s.ctx, attendee.UserID, start, start.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Require().Empty(events)
+	events, err = s.storage.FindEventsByID(s.ctx, attendee.UserID, event.ID)
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+
+	// attendees are deleted with the event
+	s.Require().NoError(s.storage.DeleteEvent(s.ctx, event.OwnerID, event.ID))
+	attendees, err = s.storage.FindAttendees(s.ctx, event.ID)
+	s.Require().NoError(err)
+	s.Require().Empty(attendees)
+}
+
+func (s *dbStorageSuite) TestUserSettings() {
+	userID := faker.UUIDHyphenated()
+	settings, err := s.storage.FindUserSettings(s.ctx, userID)
+	s.Require().NoError(err)
+	s.Require().Equal(storage.UserSettings{UserID: userID}, settings)
+
+	settings.TimeZone = "Europe/Berlin"
+	s.Require().NoError(s.storage.SaveUserSettings(s.ctx, settings))
+	settings.TimeZone = "Asia/Tokyo"
+	s.Require().NoError(s.storage.SaveUserSettings(s.ctx, settings))
+	found, err := s.storage.FindUserSettings(s.ctx, userID)
+	s.Require().NoError(err)
+	s.Require().Equal(settings, found)
+}
+
+func (s *dbStorageSuite) TestApplyBatch() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	ownerID := faker.UUIDHyphenated()
+	meeting := s.newEvent(ownerID, start)
+	lunch := s.newEvent(ownerID, start.Add(2*time.Hour))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, meeting))
+	s.Require().NoError(s.storage.AddEvent(s.ctx, lunch))
+
+	// the last event overlaps the first one created by the same batch, so the whole batch is rolled back
+	created := s.newEvent(ownerID, start.Add(4*time.Hour))
+	updated := meeting
+	updated.Title = "some new title"
+	err := s.storage.ApplyBatch(s.ctx, []storage.BatchOperation{
+		{Action: storage.BatchCreate, Event: created},
+		{Action: storage.BatchUpdate, Event: updated},
+		{Action: storage.BatchDelete, Event: lunch},
+		{Action: storage.BatchCreate, Event: s.newEvent(ownerID, start.Add(4*time.Hour+30*time.Minute))},
+	})
+	var batchErr *storage.BatchError
+	s.Require().ErrorAs(err, &batchErr)
+	s.Require().Equal(3, batchErr.Index)
+	s.Require().ErrorIs(err, storage.ErrDateBusy)
+	found, err := s.storage.FindEventsByID(s.ctx, ownerID, meeting.ID, lunch.ID, created.ID)
+	s.Require().NoError(err)
+	s.Require().Len(found, 2)
+
+	// deleted event frees its time for the events created after it
+	s.Require().NoError(s.storage.ApplyBatch(s.ctx, []storage.BatchOperation{
+		{Action: storage.BatchUpdate, Event: updated},
+		{Action: storage.BatchDelete, Event: lunch},
+		{Action: storage.BatchCreate, Event: s.newEvent(ownerID, lunch.StartTime)},
+		{Action: storage.BatchCreate, Event: created},
+	}))
+	events, err := s.storage.FindEventsInInterval(s.ctx, ownerID, start, start.Add(24*time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(events, 3)
+}
+
+func (s *dbStorageSuite) TestListen() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	payloads := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.storage.Listen(ctx, "calendar_test", func(payload string) {
+			payloads <- payload
+		})
+	}()
+
+	// listen starts asynchronously, so notifications are sent until one of them is received
+	s.Require().Eventually(func() bool {
+		s.Require().NoError(s.storage.Notify(s.ctx, "calendar_test", "changed"))
+		select {
+		case payload := <-payloads:
+			return payload == "changed"
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		s.Require().Error(err)
+	case <-time.After(5 * time.Second):
+		s.Fail("listen is not stopped by context cancel")
+	}
+}
+
+func (s *dbStorageSuite) TestSearchEvents() {
+	start := time.Date(2021, time.September, 6, 10, 0, 0, 0, time.UTC)
+	ownerID := faker.UUIDHyphenated()
+	review := s.newEvent(ownerID, start)
+	review.Title, review.Description = "Code review", "Review of the search feature"
+	s.Require().NoError(s.storage.AddEvent(s.ctx, review))
+	script := s.newEvent(ownerID, start.Add(2*time.Hour))
+	script.Title, script.Description = "<script>alert(1)</script>", "XSS review"
+	s.Require().NoError(s.storage.AddEvent(s.ctx, script))
+
+	hits, err := s.storage.SearchEvents(s.ctx, storage.SearchQuery{OwnerID: ownerID, Text: "review"})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 2)
+	s.Require().Equal(review.ID, hits[0].Event.ID)
+	s.Require().Contains(hits[0].Snippet, "<b>review</b>")
+	// event text is escaped, so only highlight tags are markup
+	s.Require().NotContains(hits[1].Snippet, "<script>")
+	s.Require().Contains(hits[1].Snippet, "<b>review</b>")
+}
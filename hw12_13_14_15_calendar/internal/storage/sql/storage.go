@@ -3,13 +3,21 @@ package sqlstorage
 import (
 	"context"
 	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	"github.com/Raschudesny/otus_go_homeworks/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+// insertEventsSQL - named insert of an event, a slice of events is inserted with a single multi-row INSERT.
+const insertEventsSQL = `INSERT INTO events (id, title, start_time, end_time, description, owner_id, notify_before, recurrence_rule, exception_dates, version, calendar_id, time_zone)
+VALUES (:id, :title, :start_time, :end_time, :description, :owner_id, :notify_before, :recurrence_rule, :exception_dates, :version, :calendar_id, :time_zone)`
+
 type DBStorage struct {
 	db *sqlx.DB
 }
@@ -37,23 +45,39 @@ func (s *DBStorage) Close() error {
 }
 
 func (s *DBStorage) AddEvent(ctx context.Context, event storage.Event) error {
-	duplicateEvents, err := s.FindEventsByID(ctx, event.ID)
-	if err != nil {
-		return fmt.Errorf("error during duplicate check: %w", err)
-	}
-	if len(duplicateEvents) > 0 {
-		return storage.ErrEventAlreadyExists
-	}
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, "select exists(select 1 from events where id = $1)", event.ID); err != nil {
+			return fmt.Errorf("error during duplicate check: %w", err)
+		}
+		if exists {
+			return storage.ErrEventAlreadyExists
+		}
+		if err := checkBusy(ctx, tx, event); err != nil {
+			return err
+		}
 
-	_, err = s.db.NamedExecContext(ctx, "INSERT INTO events (id, title, start_time, end_time, description, owner_id) VALUES (:id, :title, :start_time, :end_time, :description, :owner_id)", &event)
-	if err != nil {
-		return fmt.Errorf("error during add event sql execution: %w", err)
-	}
-	return nil
+		_, err := tx.NamedExecContext(ctx, insertEventsSQL, &event)
+		if err != nil {
+			return fmt.Errorf("error during add event sql execution: %w", err)
+		}
+		return nil
+	})
 }
 
+// UpdateEvent - updates event of its owner, storage.ErrEventNotFound is returned if the owner has no such event,
+// storage.ErrVersionConflict is returned if the stored event version differs from event.Version.
 func (s *DBStorage) UpdateEvent(ctx context.Context, event storage.Event) error {
-	res, err := s.db.NamedExecContext(ctx, "UPDATE events SET title=:title, start_time=:start_time, end_time=:end_time, description=:description, owner_id=:owner_id WHERE id=:id", &event)
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		return updateEvent(ctx, tx, event)
+	})
+}
+
+func updateEvent(ctx context.Context, tx *sqlx.Tx, event storage.Event) error {
+	res, err := tx.NamedExecContext(ctx, `UPDATE events SET title=:title, start_time=:start_time, end_time=:end_time, description=:description,
+notify_before=:notify_before, recurrence_rule=:recurrence_rule, exception_dates=:exception_dates, calendar_id=:calendar_id,
+time_zone=:time_zone, version=version + 1
+WHERE id=:id AND owner_id=:owner_id AND version=:version`, &event)
 	if err != nil {
 		return fmt.Errorf("error during updating event: %w", err)
 	}
@@ -62,14 +86,75 @@ func (s *DBStorage) UpdateEvent(ctx context.Context, event storage.Event) error
 		return fmt.Errorf("error during rows affected by update checking: %w", err)
 	}
 	if affected == 0 {
+		var exists bool
+		err := tx.GetContext(ctx, &exists, "select exists(select 1 from events where id = $1 AND owner_id = $2)", event.ID, event.OwnerID)
+		if err != nil {
+			return fmt.Errorf("error during updated event check: %w", err)
+		}
+		if exists {
+			return storage.ErrVersionConflict
+		}
 		return storage.ErrEventNotFound
 	}
+	// updated row is excluded from the check, so it could be done after the update
+	return checkBusy(ctx, tx, event)
+}
+
+// checkBusy - checks that event doesn't overlap other events of the same owner.
+// Owner events are locked with transaction level advisory lock, so concurrent transactions
+// could not add overlapping events between the check and the transaction commit.
+// Events with excluded IDs are not checked, e.g. events of the batch inserted after the event.
+func checkBusy(ctx context.Context, tx *sqlx.Tx, event storage.Event, excludedIDs ...string) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", event.OwnerID); err != nil {
+		return fmt.Errorf("error during owner events locking: %w", err)
+	}
+	from, to, err := event.BusyInterval()
+	if err != nil {
+		return err
+	}
+	var events []storage.Event
+	err = tx.SelectContext(ctx, &events, `select * from events
+where owner_id = $1 AND id <> ALL($2) AND start_time < $4 AND (end_time > $3 OR recurrence_rule <> '')`,
+		event.OwnerID, append([]string{event.ID}, excludedIDs...), from, to)
+	if err != nil {
+		return fmt.Errorf("error during finding owner events: %w", err)
+	}
+	conflict, busy, err := storage.FindConflict(event, events)
+	if err != nil {
+		return err
+	}
+	if busy {
+		return fmt.Errorf("%w: conflicts with event %s", storage.ErrDateBusy, conflict.ID)
+	}
 	return nil
 }
 
-func (s *DBStorage) DeleteEvent(ctx context.Context, eventID string) error {
-	res, err := s.db.NamedExecContext(ctx, "DELETE FROM events WHERE id=:id", map[string]interface{}{
-		"id": eventID,
+// inTx - executes fn inside transaction, transaction is rolled back if fn fails.
+func (s *DBStorage) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error during starting transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			zap.L().Error("error during transaction rollback", zap.Error(rollbackErr))
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error during transaction commit: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStorage) DeleteEvent(ctx context.Context, ownerID, eventID string) error {
+	return deleteEvent(ctx, s.db, ownerID, eventID)
+}
+
+func deleteEvent(ctx context.Context, db sqlx.ExtContext, ownerID, eventID string) error {
+	res, err := sqlx.NamedExecContext(ctx, db, "DELETE FROM events WHERE id=:id AND owner_id=:owner_id", map[string]interface{}{
+		"id":       eventID,
+		"owner_id": ownerID,
 	})
 	if err != nil {
 		return fmt.Errorf("error during deleting event: %w", err)
@@ -84,12 +169,109 @@ func (s *DBStorage) DeleteEvent(ctx context.Context, eventID string) error {
 	return nil
 }
 
-func (s *DBStorage) FindEventsInInterval(ctx context.Context, intervalStart, intervalEnd time.Time) ([]storage.Event, error) {
-	sql := "select * from events where start_time < :intervalEnd AND end_time > :intervalStart"
-	rows, err := s.db.NamedQueryContext(ctx, sql, map[string]interface{}{
+// ApplyBatch - applies operations in order in a single transaction,
+// consecutive created events are inserted with a single multi-row INSERT.
+func (s *DBStorage) ApplyBatch(ctx context.Context, operations []storage.BatchOperation) error {
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		for first := 0; first < len(operations); {
+			if operations[first].Action == storage.BatchCreate {
+				last := first + 1
+				for last < len(operations) && operations[last].Action == storage.BatchCreate {
+					last++
+				}
+				if err := addEvents(ctx, tx, operations[first:last], first); err != nil {
+					return err
+				}
+				first = last
+				continue
+			}
+			event := operations[first].Event
+			var err error
+			switch operations[first].Action {
+			case storage.BatchUpdate:
+				err = updateEvent(ctx, tx, event)
+			case storage.BatchDelete:
+				err = deleteEvent(ctx, tx, event.OwnerID, event.ID)
+			default:
+				err = fmt.Errorf("unknown batch action %q", operations[first].Action)
+			}
+			if err != nil {
+				return &storage.BatchError{Index: first, Err: err}
+			}
+			first++
+		}
+		return nil
+	})
+}
+
+// addEvents - inserts events of create operations, offset is index of the first operation in the batch.
+// Every event is checked for conflicts with events inserted before it, so the error is the same as if
+// events were added one by one.
+func addEvents(ctx context.Context, tx *sqlx.Tx, operations []storage.BatchOperation, offset int) error {
+	events := make([]storage.Event, 0, len(operations))
+	eventIDs := make([]string, 0, len(operations))
+	seen := make(map[string]bool, len(operations))
+	for i, operation := range operations {
+		if seen[operation.Event.ID] {
+			return &storage.BatchError{Index: offset + i, Err: storage.ErrEventAlreadyExists}
+		}
+		seen[operation.Event.ID] = true
+		events = append(events, operation.Event)
+		eventIDs = append(eventIDs, operation.Event.ID)
+	}
+	var existing []string
+	if err := tx.SelectContext(ctx, &existing, "select id from events where id = ANY($1)", eventIDs); err != nil {
+		return fmt.Errorf("error during duplicate check: %w", err)
+	}
+	for i, eventID := range eventIDs {
+		if containsString(existing, eventID) {
+			return &storage.BatchError{Index: offset + i, Err: storage.ErrEventAlreadyExists}
+		}
+	}
+
+	if _, err := tx.NamedExecContext(ctx, insertEventsSQL, events); err != nil {
+		return fmt.Errorf("error during add events sql execution: %w", err)
+	}
+	for i, event := range events {
+		if err := checkBusy(ctx, tx, event, eventIDs[i+1:]...); err != nil {
+			return &storage.BatchError{Index: offset + i, Err: err}
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FindEventsInInterval - finds events of the user as owner or attendee, declined invitations are skipped.
+// If calendar IDs are given, only owned events of these calendars are found.
+func (s *DBStorage) FindEventsInInterval(
+	ctx context.Context,
+	userID string,
+	intervalStart, intervalEnd time.Time,
+	calendarIDs ...string,
+) ([]storage.Event, error) {
+	// recurring events are selected as whole series and expanded into occurrences later
+	sql := `select * from events
+where (owner_id = :userID OR id in (select event_id from event_attendees where user_id = :userID AND status <> :declined))
+  AND start_time < :intervalEnd AND (end_time > :intervalStart OR recurrence_rule <> '')`
+	args := map[string]interface{}{
+		"userID":        userID,
+		"declined":      storage.RSVPDeclined,
 		"intervalStart": intervalStart,
 		"intervalEnd":   intervalEnd,
-	})
+	}
+	if len(calendarIDs) > 0 {
+		sql += ` AND owner_id = :userID AND calendar_id = ANY(:calendarIDs)`
+		args["calendarIDs"] = calendarIDs
+	}
+	rows, err := s.db.NamedQueryContext(ctx, sql, args)
 	if err != nil {
 		return nil, fmt.Errorf("sql execution error: %w", err)
 	}
@@ -109,66 +291,398 @@ func (s *DBStorage) FindEventsInInterval(ctx context.Context, intervalStart, int
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("sql result event parsing error: %w", err)
 	}
-	return result, nil
+	return storage.ExpandOccurrences(result, intervalStart, intervalEnd)
 }
 
-func (s *DBStorage) FindEventsByID(ctx context.Context, eventIDs ...string) ([]storage.Event, error) {
+func (s *DBStorage) FindEventsToNotify(ctx context.Context, from, to time.Time) ([]storage.Event, error) {
+	// notify_before is stored in nanoseconds (as time.Duration does)
+	sql := `select * from events
+where start_time - make_interval(secs => notify_before / 1e9) < :to
+  AND (start_time - make_interval(secs => notify_before / 1e9) >= :from OR recurrence_rule <> '')`
+	var events []storage.Event
+	if err := s.namedSelect(ctx, &events, sql, map[string]interface{}{
+		"from": from,
+		"to":   to,
+	}); err != nil {
+		return nil, err
+	}
 	var result []storage.Event
-	if len(eventIDs) == 0 {
-		return nil, nil
+	for _, event := range events {
+		occurrences, err := event.OccurrencesToNotify(from, to)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, occurrences...)
+	}
+	return result, nil
+}
+
+func (s *DBStorage) FindEventsBefore(ctx context.Context, t time.Time) ([]storage.Event, error) {
+	var events []storage.Event
+	if err := s.namedSelect(ctx, &events, "select * from events where end_time < :before", map[string]interface{}{
+		"before": t,
+	}); err != nil {
+		return nil, err
 	}
-	if len(eventIDs) == 1 {
-		sql := "select * from events where id = :id"
-		rows, err := s.db.NamedQueryContext(ctx, sql, map[string]interface{}{
-			"id": eventIDs[0],
-		})
+	return endedBefore(events, t)
+}
+
+func (s *DBStorage) DeleteEventsBefore(ctx context.Context, t time.Time) (int64, error) {
+	var deleted int64
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM events WHERE recurrence_rule = '' AND end_time < $1", t)
 		if err != nil {
-			return nil, fmt.Errorf("sql execution error: %w", err)
+			return fmt.Errorf("error during deleting old events: %w", err)
+		}
+		deleted, err = res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error during rows affected by delete checking: %w", err)
 		}
-		defer func() {
-			err := rows.Close()
-			zap.L().Error("error closing sql rows", zap.Error(err))
-		}()
 
-		var event storage.Event
-		for rows.Next() {
-			if err := rows.StructScan(&event); err != nil {
-				return nil, fmt.Errorf("sql result event parsing error: %w", err)
+		// recurring series end is calculated from its rule, so candidates are checked one by one
+		var series []storage.Event
+		err = tx.SelectContext(ctx, &series, "select * from events where recurrence_rule <> '' AND end_time < $1 FOR UPDATE", t)
+		if err != nil {
+			return fmt.Errorf("sql execution error: %w", err)
+		}
+		ended, err := endedBefore(series, t)
+		if err != nil {
+			return err
+		}
+		for _, event := range ended {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM events WHERE id = $1", event.ID); err != nil {
+				return fmt.Errorf("error during deleting old event %s: %w", event.ID, err)
 			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// endedBefore - filters events which ended before t, recurring events are checked by their last occurrence.
+func endedBefore(events []storage.Event, t time.Time) ([]storage.Event, error) {
+	var result []storage.Event
+	for _, event := range events {
+		ended, err := event.EndedBefore(t)
+		if err != nil {
+			return nil, err
+		}
+		if ended {
 			result = append(result, event)
 		}
-		if err := rows.Err(); err != nil {
-			return nil, fmt.Errorf("sql result event parsing error: %w", err)
+	}
+	return result, nil
+}
+
+// FindEventsByID - finds events of the user as owner or attendee, declined invitations are found as well.
+func (s *DBStorage) FindEventsByID(ctx context.Context, userID string, eventIDs ...string) ([]storage.Event, error) {
+	if len(eventIDs) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In(`select * from events
+where (owner_id = ? OR id in (select event_id from event_attendees where user_id = ?)) AND id in (?)`, userID, userID, eventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error during preparing sql: %w", err)
+	}
+	var result []storage.Event
+	if err := s.db.SelectContext(ctx, &result, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("sql execution error: %w", err)
+	}
+	return result, nil
+}
+
+// SaveAttendee - adds attendee to the event or replaces the existing one with the same user ID.
+func (s *DBStorage) SaveAttendee(ctx context.Context, attendee storage.Attendee) error {
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var exists bool
+		err := tx.GetContext(ctx, &exists, "select exists(select 1 from events where id = $1)", attendee.EventID)
+		if err != nil {
+			return fmt.Errorf("error during attendee event check: %w", err)
+		}
+		if !exists {
+			return storage.ErrEventNotFound
+		}
+		_, err = tx.NamedExecContext(ctx, `INSERT INTO event_attendees (event_id, user_id, role, status)
+VALUES (:event_id, :user_id, :role, :status)
+ON CONFLICT (event_id, user_id) DO UPDATE SET role=:role, status=:status`, &attendee)
+		if err != nil {
+			return fmt.Errorf("error during saving attendee: %w", err)
 		}
+		return nil
+	})
+}
 
-		return result, nil
+func (s *DBStorage) DeleteAttendee(ctx context.Context, eventID, userID string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM event_attendees WHERE event_id = $1 AND user_id = $2", eventID, userID)
+	if err != nil {
+		return fmt.Errorf("error during deleting attendee: %w", err)
 	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error during rows affected by delete checking: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrAttendeeNotFound
+	}
+	return nil
+}
 
-	query := "select * from events where id in (?)"
-	query, args, err := sqlx.In(query, eventIDs)
+// FindAttendees - finds attendees of the events ordered by event ID and user ID.
+func (s *DBStorage) FindAttendees(ctx context.Context, eventIDs ...string) ([]storage.Attendee, error) {
+	if len(eventIDs) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In("select * from event_attendees where event_id in (?) order by event_id, user_id", eventIDs)
 	if err != nil {
 		return nil, fmt.Errorf("error during preparing sql: %w", err)
 	}
-	resultQuery := s.db.Rebind(query)
+	var result []storage.Attendee
+	if err := s.db.SelectContext(ctx, &result, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("sql execution error: %w", err)
+	}
+	return result, nil
+}
 
-	rows, err := s.db.QueryxContext(ctx, resultQuery, args)
-	defer func() {
-		err := rows.Close()
-		zap.L().Error("error closing sql rows", zap.Error(err))
-	}()
+func (s *DBStorage) AddCalendar(ctx context.Context, calendar storage.Calendar) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO calendars (id, owner_id, name, color, time_zone)
+VALUES (:id, :owner_id, :name, :color, :time_zone)`, &calendar)
 	if err != nil {
-		return nil, fmt.Errorf("sql execution error: %w", err)
+		return fmt.Errorf("error during adding calendar: %w", err)
 	}
+	return nil
+}
 
-	var event storage.Event
-	for rows.Next() {
-		if err := rows.StructScan(&event); err != nil {
-			return nil, fmt.Errorf("sql result event parsing error: %w", err)
+// UpdateCalendar - updates calendar of calendar.OwnerID, storage.ErrCalendarNotFound is returned if the owner has no such calendar.
+func (s *DBStorage) UpdateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	res, err := s.db.NamedExecContext(ctx, `UPDATE calendars SET name=:name, color=:color, time_zone=:time_zone
+WHERE id=:id AND owner_id=:owner_id`, &calendar)
+	if err != nil {
+		return fmt.Errorf("error during updating calendar: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error during rows affected by update checking: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrCalendarNotFound
+	}
+	return nil
+}
+
+// DeleteCalendar - deletes calendar without events, storage.ErrCalendarNotEmpty is returned if it has some.
+func (s *DBStorage) DeleteCalendar(ctx context.Context, ownerID, calendarID string) error {
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		// owner events are locked as well as by checkBusy, so no event is moved into the calendar concurrently
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", ownerID); err != nil {
+			return fmt.Errorf("error during owner events locking: %w", err)
 		}
-		result = append(result, event)
+		var hasEvents bool
+		err := tx.GetContext(ctx, &hasEvents, "select exists(select 1 from events where owner_id = $1 AND calendar_id = $2)",
+			ownerID, calendarID)
+		if err != nil {
+			return fmt.Errorf("error during calendar events check: %w", err)
+		}
+		if hasEvents {
+			return storage.ErrCalendarNotEmpty
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM calendars WHERE id = $1 AND owner_id = $2", calendarID, ownerID)
+		if err != nil {
+			return fmt.Errorf("error during deleting calendar: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error during rows affected by delete checking: %w", err)
+		}
+		if affected == 0 {
+			return storage.ErrCalendarNotFound
+		}
+		return nil
+	})
+}
+
+// FindCalendars - finds calendars of the owner ordered by name.
+func (s *DBStorage) FindCalendars(ctx context.Context, ownerID string) ([]storage.Calendar, error) {
+	var result []storage.Calendar
+	err := s.db.SelectContext(ctx, &result, "select * from calendars where owner_id = $1 order by name, id", ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("sql execution error: %w", err)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("sql result event parsing error: %w", err)
+	return result, nil
+}
+
+func (s *DBStorage) SaveUserSettings(ctx context.Context, settings storage.UserSettings) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO user_settings (user_id, time_zone) VALUES (:user_id, :time_zone)
+ON CONFLICT (user_id) DO UPDATE SET time_zone=:time_zone`, &settings)
+	if err != nil {
+		return fmt.Errorf("error during saving user settings: %w", err)
+	}
+	return nil
+}
+
+// FindUserSettings - returns empty settings of the user if the user has not saved any.
+func (s *DBStorage) FindUserSettings(ctx context.Context, userID string) (storage.UserSettings, error) {
+	var result []storage.UserSettings
+	err := s.db.SelectContext(ctx, &result, "select * from user_settings where user_id = $1", userID)
+	if err != nil {
+		return storage.UserSettings{}, fmt.Errorf("sql execution error: %w", err)
+	}
+	if len(result) == 0 {
+		return storage.UserSettings{UserID: userID}, nil
+	}
+	return result[0], nil
+}
+
+func (s *DBStorage) SaveNotificationStatus(ctx context.Context, status storage.NotificationStatus) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO notification_statuses (event_id, start_time, owner_id, status, error, updated_at)
+VALUES (:event_id, :start_time, :owner_id, :status, :error, :updated_at)
+ON CONFLICT (event_id, start_time) DO UPDATE SET status=:status, error=:error, updated_at=:updated_at`, &status)
+	if err != nil {
+		return fmt.Errorf("error during saving notification status: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStorage) FindNotificationStatuses(ctx context.Context, eventID string) ([]storage.NotificationStatus, error) {
+	var result []storage.NotificationStatus
+	err := s.db.SelectContext(ctx, &result, "select * from notification_statuses where event_id = $1", eventID)
+	if err != nil {
+		return nil, fmt.Errorf("sql execution error: %w", err)
 	}
 	return result, nil
 }
+
+// namedSelect - executes named query and scans all result rows into dest slice.
+func (s *DBStorage) namedSelect(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	query, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return fmt.Errorf("error during preparing sql: %w", err)
+	}
+	if err := s.db.SelectContext(ctx, dest, s.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("sql execution error: %w", err)
+	}
+	return nil
+}
+
+// eventSearchVector - the same expression as event_search_index is built on, otherwise the index is not used.
+const eventSearchVector = `(setweight(to_tsvector('simple', title), 'A') || ` +
+	`setweight(to_tsvector('simple', coalesce(description, '')), 'B'))`
+
+// headlineStart and headlineStop - private use characters ts_headline marks matched words with,
+// they are replaced with highlight tags after the headline is HTML escaped.
+const (
+	headlineStart = "\uE000"
+	headlineStop  = "\uE001"
+)
+
+var headlineReplacer = strings.NewReplacer(headlineStart, storage.HighlightStart, headlineStop, storage.HighlightStop)
+
+// highlightHeadline - turns ts_headline result into snippet, event text must not be sent to clients unescaped.
+func highlightHeadline(headline string) string {
+	return headlineReplacer.Replace(html.EscapeString(headline))
+}
+
+type searchRow struct {
+	storage.Event
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
+}
+
+// SearchEvents - finds owner events containing all query words, hits are ordered by rank.
+func (s *DBStorage) SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchHit, error) {
+	if len(query.Terms()) == 0 {
+		return nil, nil
+	}
+	headlineOptions := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=%d, MinWords=5",
+		headlineStart, headlineStop, storage.SnippetWords)
+	sql := `select events.*, ts_rank(` + eventSearchVector + `, query) as rank,
+  ts_headline('simple', title || ' ' || coalesce(description, ''), query, :headlineOptions) as snippet
+from events, plainto_tsquery('simple', :text) query
+where owner_id = :ownerID AND ` + eventSearchVector + ` @@ query`
+	args := map[string]interface{}{
+		"ownerID":         query.OwnerID,
+		"text":            query.Text,
+		"headlineOptions": headlineOptions,
+	}
+	if !query.To.IsZero() {
+		sql += ` AND start_time < :to`
+		args["to"] = query.To
+	}
+	if !query.From.IsZero() {
+		// recurring events are checked for occurrences in range later
+		sql += ` AND (end_time > :from OR recurrence_rule <> '')`
+		args["from"] = query.From
+	}
+	sql += ` order by rank desc, start_time, id`
+	// recurring events may be filtered out later, so the limit can be applied in sql only for unbounded range
+	if query.Limit > 0 && query.From.IsZero() {
+		sql += fmt.Sprintf(` limit %d`, query.Limit)
+	}
+
+	var rows []searchRow
+	if err := s.namedSelect(ctx, &rows, sql, args); err != nil {
+		return nil, err
+	}
+	var hits []storage.SearchHit
+	for _, row := range rows {
+		inRange, err := query.InRange(row.Event)
+		if err != nil {
+			return nil, err
+		}
+		if !inRange {
+			continue
+		}
+		hits = append(hits, storage.SearchHit{Event: row.Event, Rank: row.Rank, Snippet: highlightHeadline(row.Snippet)})
+		if len(hits) == query.Limit {
+			break
+		}
+	}
+	return hits, nil
+}
+
+// Notify - sends payload to all listeners of the channel with PostgreSQL NOTIFY.
+func (s *DBStorage) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := s.db.ExecContext(ctx, "select pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("sql execution error: %w", err)
+	}
+	return nil
+}
+
+// Listen - listens the channel on dedicated pool connection until ctx is done or connection fails.
+func (s *DBStorage) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error during getting db connection: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			zap.L().Error("error closing db connection", zap.Error(err))
+		}
+	}()
+	return conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("listen is not supported by %T driver connection", driverConn)
+		}
+		pgConn := stdlibConn.Conn()
+		if _, err := pgConn.Exec(ctx, "listen "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return fmt.Errorf("sql execution error: %w", err)
+		}
+		// connection goes back to the pool, so it must not stay subscribed
+		defer func() {
+			if _, err := pgConn.Exec(context.Background(), "unlisten "+pgx.Identifier{channel}.Sanitize()); err != nil {
+				zap.L().Error("error during unlisten", zap.Error(err))
+			}
+		}()
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("error during waiting for notification: %w", err)
+			}
+			handle(notification.Payload)
+		}
+	})
+}
@@ -94,9 +94,9 @@ func TestSql2(t *testing.T) {
 	err := DBStorage.Connect(timeout, DSN)
 	require.NoError(t, err)
 
-	err = DBStorage.DeleteEvent(timeout, "03cd6323-3590-45ec-a462-4e41dcffd8aa")
+	err = DBStorage.DeleteEvent(timeout, "98831c0e-c00b-43e5-840e-2f7a327ff14a", "03cd6323-3590-45ec-a462-4e41dcffd8aa")
 	require.NoError(t, err)
-	err = DBStorage.DeleteEvent(timeout, "554d45c9-f8be-4de1-8152-3d4b88387055")
+	err = DBStorage.DeleteEvent(timeout, "98831c0e-c00b-43e5-840e-2f7a327ff14a", "554d45c9-f8be-4de1-8152-3d4b88387055")
 	require.NoError(t, err)
 	err = DBStorage.Close()
 	require.NoError(t, err)
@@ -116,7 +116,7 @@ func TestSql3(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(time.Second),
 		Description: "some other description",
-		OwnerID:     "123455678",
+		OwnerID:     "98831c0e-c00b-43e5-840e-2f7a327ff14a",
 	})
 	require.NoError(t, err)
 	err = DBStorage.Close()
@@ -135,8 +135,13 @@ func TestFindSql(t *testing.T) {
 		require.NoError(t, err)
 	}()
 
-	events, err := DBStorage.FindEventsInInterval(timeout, time.Now(), time.Now().AddDate(0, 0, 1))
+	events, err := DBStorage.FindEventsInInterval(timeout, "98831c0e-c00b-43e5-840e-2f7a327ff14a", time.Now(), time.Now().AddDate(0, 0, 1))
 	require.NoError(t, err)
 	require.Equal(t, 2, len(events))
 	fmt.Println(events)
 }
+
+func TestHighlightHeadline(t *testing.T) {
+	headline := "<script>alert(1)</script> " + headlineStart + "XSS" + headlineStop + " & review"
+	require.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt; <b>XSS</b> &amp; review", highlightHeadline(headline))
+}
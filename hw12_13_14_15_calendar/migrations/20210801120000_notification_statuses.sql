@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notification_statuses
+(
+    event_id   uuid        not null,
+    start_time timestamptz not null,
+    owner_id   text        not null,
+    status     text        not null,
+    error      text        not null default '',
+    updated_at timestamptz not null,
+    PRIMARY KEY (event_id, start_time)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table notification_statuses;
+-- +goose StatementEnd
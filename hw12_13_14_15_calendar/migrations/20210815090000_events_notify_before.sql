@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- how long before the event start to send a notification, in nanoseconds
+ALTER TABLE events ADD COLUMN notify_before bigint not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN notify_before;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- RRULE (RFC 5545) of the recurring event, empty for single events
+ALTER TABLE events ADD COLUMN recurrence_rule text not null default '';
+-- start times of the occurrences excluded from the series, json array
+ALTER TABLE events ADD COLUMN exception_dates jsonb not null default '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN exception_dates;
+ALTER TABLE events DROP COLUMN recurrence_rule;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- full text search over title (weight A) and description (weight B),
+-- the expression must match eventSearchVector in internal/storage/sql/storage.go
+CREATE INDEX event_search_index ON events USING GIN (
+    (setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', coalesce(description, '')), 'B'))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index event_search_index;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- incremented by every update, updates based on stale version are rejected
+ALTER TABLE events ADD COLUMN version bigint not null default 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN version;
+-- +goose StatementEnd
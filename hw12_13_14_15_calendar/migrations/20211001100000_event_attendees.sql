@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE event_attendees
+(
+    event_id uuid not null REFERENCES events (id) ON DELETE CASCADE,
+    user_id  text not null,
+    role     text not null,
+    status   text not null,
+    PRIMARY KEY (event_id, user_id)
+);
+
+-- events of the attendee are looked up by user
+CREATE INDEX event_attendees_user_index ON event_attendees (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index event_attendees_user_index;
+drop table event_attendees;
+-- +goose StatementEnd
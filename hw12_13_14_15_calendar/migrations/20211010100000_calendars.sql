@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE calendars
+(
+    id        uuid PRIMARY KEY,
+    owner_id  text not null,
+    name      text not null,
+    color     text not null default '',
+    time_zone text not null default ''
+);
+
+CREATE INDEX calendar_owner_index ON calendars (owner_id);
+
+-- empty calendar id means the event is not in any calendar
+ALTER TABLE events ADD COLUMN calendar_id text not null default '';
+CREATE INDEX event_calendar_index ON events (owner_id, calendar_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index event_calendar_index;
+ALTER TABLE events DROP COLUMN calendar_id;
+drop index calendar_owner_index;
+drop table calendars;
+-- +goose StatementEnd
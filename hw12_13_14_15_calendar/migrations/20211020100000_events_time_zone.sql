@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- IANA time zone the recurring event series is expanded in, empty means the zone of start_time
+ALTER TABLE events ADD COLUMN time_zone text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN time_zone;
+-- +goose StatementEnd
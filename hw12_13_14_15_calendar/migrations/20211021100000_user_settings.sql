@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_settings
+(
+    user_id   text PRIMARY KEY,
+    -- default IANA time zone of the user, empty means the calendar one
+    time_zone text not null default ''
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table user_settings;
+-- +goose StatementEnd